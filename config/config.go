@@ -10,12 +10,25 @@ import (
 )
 
 type Config struct {
-	APPConfig          AppConfig
-	DBConfig           DBConfig
-	RefreshTokenConfig RefreshTokenConfig
-	UserClient         UserClientConfig
-	EmailConfig        EmailConfig
-	KafkaConfig        KafkaConfig
+	APPConfig            AppConfig
+	DBConfig             DBConfig
+	RefreshTokenConfig   RefreshTokenConfig
+	UserClient           UserClientConfig
+	EmailConfig          EmailConfig
+	KafkaConfig          KafkaConfig
+	MFAConfig            MFAConfig
+	WebAuthnConfig       WebAuthnConfig
+	TokenHashConfig      TokenHashConfig
+	PasswordResetConfig  PasswordResetConfig
+	PasswordPolicyConfig PasswordPolicyConfig
+	PasswordHashConfig   PasswordHashConfig
+	LockoutConfig        LockoutConfig
+	RateLimitConfig      RateLimitConfig
+	SigningKeyConfig     SigningKeyConfig
+	OAuthConfig          OAuthConfig
+	DenylistConfig       DenylistConfig
+	SessionConfig        SessionConfig
+	JanitorConfig        JanitorConfig
 }
 
 type AppConfig struct {
@@ -23,10 +36,13 @@ type AppConfig struct {
 	Version                   string `env:"APP__VERSION" default:"local"`
 	Port                      int    `env:"CONFIG__APP_CONFIG__PORT" default:"3001"`
 	KeyRollingDurationInHours int    `env:"CONFIG__APP_CONFIG__KEY_ROLLING_DURATION_IN_HOURS" default:"1"`
+	KeyRetentionInMinutes     int    `env:"CONFIG__APP_CONFIG__KEY_RETENTION_IN_MINUTES" default:"60"` // rotated out keys still verify tokens.
+	JWKSMaxAgeInSeconds       int    `env:"CONFIG__APP_CONFIG__JWKS_MAX_AGE_IN_SECONDS" default:"300"` // 5 minutes.
 	InternalGraphQLURL        string `env:"INTERNAL_GRAPHQL_URL" default:"http://localhost:5001/graphql"`
 	JWTValiditySeconds        int    `env:"CONFIG__APP_CONFIG__JWT_VALIDITY_SECONDS" default:"900"` // 15 minutes.
 	PasswordResetBaseURL      string `env:"CONFIG__APP_CONFIG__PASSWORD_RESET_BASE_URL" default:"http://localhost:3000/auth/password-reset"`
 	VerificationBaseURL       string `env:"CONFIG__APP_CONFIG__VERIFICATION_BASE_URL" default:"http://localhost:3000/auth/verify"`
+	UnlockBaseURL             string `env:"CONFIG__APP_CONFIG__UNLOCK_BASE_URL" default:"http://localhost:3000/auth/unlock"`
 	CookieDomain              string `env:"CONFIG__APP_CONFIG__COOKIE_DOMAIN" default:".weeb.vip"`
 }
 
@@ -65,6 +81,129 @@ type KafkaConfig struct {
 	ProducerTopic     string `default:"user_created" env:"KAFKA_PRODUCER_TOPIC"`
 }
 
+type MFAConfig struct {
+	Issuer                string `env:"CONFIG__MFA_CONFIG__ISSUER" default:"WEEB VIP"`
+	EncryptionKey         string `env:"CONFIG__MFA_CONFIG__ENCRYPTION_KEY" required:"true"`
+	ChallengeTTLInSeconds int    `env:"CONFIG__MFA_CONFIG__CHALLENGE_TTL_IN_SECONDS" default:"300"` // 5 minutes.
+}
+
+type WebAuthnConfig struct {
+	RPID                 string `env:"CONFIG__WEBAUTHN_CONFIG__RP_ID" default:"weeb.vip"`
+	RPDisplayName        string `env:"CONFIG__WEBAUTHN_CONFIG__RP_DISPLAY_NAME" default:"WEEB VIP"`
+	RPOrigins            string `env:"CONFIG__WEBAUTHN_CONFIG__RP_ORIGINS" default:"https://weeb.vip"` // comma separated.
+	CeremonyTTLInSeconds int    `env:"CONFIG__WEBAUTHN_CONFIG__CEREMONY_TTL_IN_SECONDS" default:"300"` // 5 minutes.
+}
+
+type TokenHashConfig struct {
+	Secret string `env:"CONFIG__TOKEN_HASH_CONFIG__SECRET" required:"true"` // keys the HMAC of refresh and reset tokens.
+}
+
+type PasswordResetConfig struct {
+	TokenTTLInSeconds      int `env:"CONFIG__PASSWORD_RESET_CONFIG__TOKEN_TTL_IN_SECONDS" default:"3600"`      // 1 hour.
+	MaxRequests            int `env:"CONFIG__PASSWORD_RESET_CONFIG__MAX_REQUESTS" default:"3"`                 // per account and window.
+	RequestWindowInSeconds int `env:"CONFIG__PASSWORD_RESET_CONFIG__REQUEST_WINDOW_IN_SECONDS" default:"3600"` // 1 hour.
+}
+
+type PasswordPolicyConfig struct {
+	MinLength        int  `env:"CONFIG__PASSWORD_POLICY_CONFIG__MIN_LENGTH" default:"8"`   // in characters.
+	MaxLength        int  `env:"CONFIG__PASSWORD_POLICY_CONFIG__MAX_LENGTH" default:"128"` // in bytes, at most 72 with bcrypt.
+	RequireLowercase bool `env:"CONFIG__PASSWORD_POLICY_CONFIG__REQUIRE_LOWERCASE" default:"false"`
+	RequireUppercase bool `env:"CONFIG__PASSWORD_POLICY_CONFIG__REQUIRE_UPPERCASE" default:"false"`
+	RequireDigit     bool `env:"CONFIG__PASSWORD_POLICY_CONFIG__REQUIRE_DIGIT" default:"false"`
+	RequireSymbol    bool `env:"CONFIG__PASSWORD_POLICY_CONFIG__REQUIRE_SYMBOL" default:"false"`
+	DisallowUsername bool `env:"CONFIG__PASSWORD_POLICY_CONFIG__DISALLOW_USERNAME" default:"true"`
+	DisallowCommon   bool `env:"CONFIG__PASSWORD_POLICY_CONFIG__DISALLOW_COMMON" default:"true"`
+	MinScore         int  `env:"CONFIG__PASSWORD_POLICY_CONFIG__MIN_SCORE" default:"2"` // 0 (guessable) to 4 (very strong).
+	// BreachedPasswordsFile is a filter built by "auth passwords ingest", no breach check is done when empty.
+	BreachedPasswordsFile string `env:"CONFIG__PASSWORD_POLICY_CONFIG__BREACHED_PASSWORDS_FILE" default:""`
+}
+
+// PasswordHashConfig picks the algorithm for new password hashes, hashes made with other settings are
+// still verified and replaced on the next sign in. The argon2id defaults follow the OWASP recommendation.
+type PasswordHashConfig struct {
+	Algorithm         string `env:"CONFIG__PASSWORD_HASH_CONFIG__ALGORITHM" default:"argon2id"`  // argon2id or bcrypt.
+	Argon2Memory      uint32 `env:"CONFIG__PASSWORD_HASH_CONFIG__ARGON2_MEMORY" default:"19456"` // in KiB.
+	Argon2Iterations  uint32 `env:"CONFIG__PASSWORD_HASH_CONFIG__ARGON2_ITERATIONS" default:"2"`
+	Argon2Parallelism uint8  `env:"CONFIG__PASSWORD_HASH_CONFIG__ARGON2_PARALLELISM" default:"1"`
+	BcryptCost        int    `env:"CONFIG__PASSWORD_HASH_CONFIG__BCRYPT_COST" default:"12"`
+}
+
+type LockoutConfig struct {
+	MaxUsernameFailures     int `env:"CONFIG__LOCKOUT_CONFIG__MAX_USERNAME_FAILURES" default:"5"`
+	MaxIPFailures           int `env:"CONFIG__LOCKOUT_CONFIG__MAX_IP_FAILURES" default:"50"`
+	MaxMFAFailures          int `env:"CONFIG__LOCKOUT_CONFIG__MAX_MFA_FAILURES" default:"5"`               // wrong second factor codes of a user.
+	FailureWindowInSeconds  int `env:"CONFIG__LOCKOUT_CONFIG__FAILURE_WINDOW_IN_SECONDS" default:"900"`    // 15 minutes.
+	BaseLockoutInSeconds    int `env:"CONFIG__LOCKOUT_CONFIG__BASE_LOCKOUT_IN_SECONDS" default:"60"`       // doubled per further failure.
+	MaxLockoutInSeconds     int `env:"CONFIG__LOCKOUT_CONFIG__MAX_LOCKOUT_IN_SECONDS" default:"3600"`      // 1 hour.
+	UnlockTokenTTLInSeconds int `env:"CONFIG__LOCKOUT_CONFIG__UNLOCK_TOKEN_TTL_IN_SECONDS" default:"3600"` // 1 hour.
+	UnlockResendInSeconds   int `env:"CONFIG__LOCKOUT_CONFIG__UNLOCK_RESEND_IN_SECONDS" default:"300"`     // 5 minutes between unlock emails.
+}
+
+// RateLimitConfig rules are "Operation:key=limit/window" entries separated by semicolons, where key is ip,
+// username or user_id and window is a Go duration, e.g. "CreateSession:ip=30/1m,username=10/1m".
+type RateLimitConfig struct {
+	Disabled  bool   `env:"CONFIG__RATE_LIMIT_CONFIG__DISABLED" default:"false"`
+	Algorithm string `env:"CONFIG__RATE_LIMIT_CONFIG__ALGORITHM" default:"sliding_window"` // or token_bucket.
+	Rules     string `env:"CONFIG__RATE_LIMIT_CONFIG__RULES" default:"CreateSession:ip=30/1m,username=10/1m;Register:ip=10/1h;RequestPasswordReset:ip=10/1h,username=3/1h;ResendVerificationEmail:ip=10/1h,username=3/1h;CompleteMFAChallenge:ip=30/1m;ResetPassword:ip=10/1h,username=5/1h;RequestAccountUnlock:ip=10/1h,username=3/1h;UnlockAccount:ip=10/1h;BeginPasskeyLogin:ip=30/1m;FinishPasskeyLogin:ip=30/1m"`
+}
+
+// SigningKeyConfig keeps JWT signing keys in the database by default, encrypted with MasterKey (or the contents
+// of MasterKeyFile), so every replica signs and verifies with the same keys. Changing Algorithm rotates in a key
+// of the new algorithm on startup; tokens signed with the old key keep verifying until it is past retention.
+type SigningKeyConfig struct {
+	Store                        string `env:"CONFIG__SIGNING_KEY_CONFIG__STORE" default:"database"`  // or memory, for a key per process.
+	Algorithm                    string `env:"CONFIG__SIGNING_KEY_CONFIG__ALGORITHM" default:"RS256"` // or PS256, ES256, EdDSA.
+	MasterKey                    string `env:"CONFIG__SIGNING_KEY_CONFIG__MASTER_KEY"`
+	MasterKeyFile                string `env:"CONFIG__SIGNING_KEY_CONFIG__MASTER_KEY_FILE"`
+	LockName                     string `env:"CONFIG__SIGNING_KEY_CONFIG__LOCK_NAME" default:"auth_signing_key_rotation"`
+	LockTimeoutInSeconds         int    `env:"CONFIG__SIGNING_KEY_CONFIG__LOCK_TIMEOUT_IN_SECONDS" default:"10"`
+	SyncIntervalInSeconds        int    `env:"CONFIG__SIGNING_KEY_CONFIG__SYNC_INTERVAL_IN_SECONDS" default:"60"`
+	MaxKeyAgeInMinutes           int    `env:"CONFIG__SIGNING_KEY_CONFIG__MAX_KEY_AGE_IN_MINUTES"`          // 0 for twice the rolling duration.
+	Publisher                    string `env:"CONFIG__SIGNING_KEY_CONFIG__PUBLISHER" default:"key_service"` // or file.
+	PublishDirectory             string `env:"CONFIG__SIGNING_KEY_CONFIG__PUBLISH_DIRECTORY"`
+	PublishTimeoutInSeconds      int    `env:"CONFIG__SIGNING_KEY_CONFIG__PUBLISH_TIMEOUT_IN_SECONDS" default:"10"`
+	PublishMaxAttempts           int    `env:"CONFIG__SIGNING_KEY_CONFIG__PUBLISH_MAX_ATTEMPTS" default:"3"`
+	PublishBackoffInMilliseconds int    `env:"CONFIG__SIGNING_KEY_CONFIG__PUBLISH_BACKOFF_IN_MILLISECONDS" default:"500"`
+}
+
+// OAuthConfig clients are "client_id:secret" entries separated by commas, for the resource servers allowed to
+// call the token introspection and revocation endpoints. Both endpoints reject every request when it is empty.
+type OAuthConfig struct {
+	Clients string `env:"CONFIG__OAUTH_CONFIG__CLIENTS"`
+}
+
+// DenylistConfig keeps revoked access tokens in the database by default, behind a cache of CacheSize tokens.
+// A token found not revoked is cached for CacheTTLInSeconds, which is how long a revocation on another
+// replica can take to be seen.
+type DenylistConfig struct {
+	Store             string `env:"CONFIG__DENYLIST_CONFIG__STORE" default:"database"` // or memory, for a denylist per process.
+	CacheSize         int    `env:"CONFIG__DENYLIST_CONFIG__CACHE_SIZE" default:"10000"`
+	CacheTTLInSeconds int    `env:"CONFIG__DENYLIST_CONFIG__CACHE_TTL_IN_SECONDS" default:"5"`
+}
+
+// SessionConfig ends a session that was not refreshed for IdleTimeoutInHours, and any session
+// AbsoluteLifetimeInHours after sign in. Signing in with MaxConcurrentSessions already open ends the oldest.
+// 0 turns a limit off.
+type SessionConfig struct {
+	IdleTimeoutInHours      int `env:"CONFIG__SESSION_CONFIG__IDLE_TIMEOUT_IN_HOURS" default:"720"`       // 30 days.
+	AbsoluteLifetimeInHours int `env:"CONFIG__SESSION_CONFIG__ABSOLUTE_LIFETIME_IN_HOURS" default:"4380"` // 6 months, as long as a refresh token.
+	MaxConcurrentSessions   int `env:"CONFIG__SESSION_CONFIG__MAX_CONCURRENT_SESSIONS" default:"10"`
+}
+
+// JanitorConfig removes expired and stale rows every IntervalInMinutes, on one replica at a time through a
+// database lock. Password resets are kept PasswordResetRetentionInHours, at least the password reset request
+// window so rate limiting still sees them, and credentials never activated InactiveCredentialRetentionInHours.
+// A retention of 0 keeps those rows.
+type JanitorConfig struct {
+	Disabled                           bool   `env:"CONFIG__JANITOR_CONFIG__DISABLED"`
+	IntervalInMinutes                  int    `env:"CONFIG__JANITOR_CONFIG__INTERVAL_IN_MINUTES" default:"60"`
+	BatchSize                          int    `env:"CONFIG__JANITOR_CONFIG__BATCH_SIZE" default:"1000"`
+	LockName                           string `env:"CONFIG__JANITOR_CONFIG__LOCK_NAME" default:"auth_janitor"`
+	PasswordResetRetentionInHours      int    `env:"CONFIG__JANITOR_CONFIG__PASSWORD_RESET_RETENTION_IN_HOURS" default:"168"`      // 7 days.
+	InactiveCredentialRetentionInHours int    `env:"CONFIG__JANITOR_CONFIG__INACTIVE_CREDENTIAL_RETENTION_IN_HOURS" default:"720"` // 30 days.
+	LoginFailureRetentionInHours       int    `env:"CONFIG__JANITOR_CONFIG__LOGIN_FAILURE_RETENTION_IN_HOURS" default:"24"`        // longer than a failure window and lock.
+}
+
 func LoadConfig() (*Config, error) {
 	var config Config
 	err := configor.
@@ -4,9 +4,13 @@ import (
 	"context"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/denylist"
 	"github.com/weeb-vip/auth/internal/jwt"
 	"github.com/weeb-vip/auth/internal/services/credential"
+	"github.com/weeb-vip/auth/internal/services/lockout"
 	"github.com/weeb-vip/auth/internal/services/mail"
+	"github.com/weeb-vip/auth/internal/services/mfa"
+	"github.com/weeb-vip/auth/internal/services/passkey"
 	"github.com/weeb-vip/auth/internal/services/passwordreset"
 	"github.com/weeb-vip/auth/internal/services/refresh_token"
 	"github.com/weeb-vip/auth/internal/services/session"
@@ -27,4 +31,8 @@ type Resolver struct {
 	ValidationToken      validation_token.ValidationToken
 	MailService          mail.MailService
 	UserProducer         func(ctx context.Context, message *kafka.Message) error
+	MFAService           mfa.MFA
+	PasskeyService       passkey.Passkey
+	LockoutService       lockout.Lockout
+	Denylist             denylist.Denylist
 }
@@ -19,22 +19,37 @@ func (r *mutationResolver) Register(ctx context.Context, input model.RegisterInp
 
 // CreateSession is the resolver for the CreateSession field.
 func (r *mutationResolver) CreateSession(ctx context.Context, input *model.LoginInput) (*model.SigninResult, error) {
-	return resolvers.CreateSession(ctx, r.CredentialService, r.SessionService, r.RefreshTokenService, r.JwtTokenizer, &r.Config, input)
+	return resolvers.CreateSession(ctx, r.CredentialService, r.MFAService, r.SessionService, r.RefreshTokenService, r.JwtTokenizer, r.Denylist, &r.Config, input)
 }
 
 // RequestPasswordReset is the resolver for the RequestPasswordReset field.
 func (r *mutationResolver) RequestPasswordReset(ctx context.Context, input model.RequestPasswordResetInput) (bool, error) {
-	return resolvers.RequestPasswordReset(ctx, r.CredentialService, r.PasswordResetService, r.MailService, &r.Config, input.Username, input.Email)
+	return resolvers.RequestPasswordReset(ctx, r.CredentialService, r.PasswordResetService, r.MailService, &r.Config, input.Username)
 }
 
 // ResetPassword is the resolver for the ResetPassword field.
 func (r *mutationResolver) ResetPassword(ctx context.Context, input model.ResetPasswordInput) (bool, error) {
-	return resolvers.ResetPassword(ctx, r.CredentialService, r.PasswordResetService, input.Token, input.Username, input.NewPassword)
+	return resolvers.ResetPassword(ctx, r.CredentialService, r.PasswordResetService, r.SessionService, r.Denylist, r.MailService, r.UserProducer, input.Token, input.Username, input.NewPassword)
+}
+
+// ChangePassword is the resolver for the ChangePassword field.
+func (r *mutationResolver) ChangePassword(ctx context.Context, input model.ChangePasswordInput) (bool, error) {
+	return resolvers.ChangePassword(ctx, r.CredentialService, r.SessionService, r.Denylist, r.RefreshTokenService, r.MailService, r.JwtTokenizer, r.UserProducer, input)
+}
+
+// RequestAccountUnlock is the resolver for the RequestAccountUnlock field.
+func (r *mutationResolver) RequestAccountUnlock(ctx context.Context, username string) (bool, error) {
+	return resolvers.RequestAccountUnlock(ctx, r.CredentialService, r.LockoutService, r.MailService, &r.Config, username)
+}
+
+// UnlockAccount is the resolver for the UnlockAccount field.
+func (r *mutationResolver) UnlockAccount(ctx context.Context, token string) (bool, error) {
+	return resolvers.UnlockAccount(ctx, r.LockoutService, token)
 }
 
 // RefreshToken is the resolver for the RefreshToken field.
 func (r *mutationResolver) RefreshToken(ctx context.Context, token string) (*model.SigninResult, error) {
-	return resolvers.RefreshToken(ctx, r.SessionService, r.RefreshTokenService, r.JwtTokenizer, &r.Config, token)
+	return resolvers.RefreshToken(ctx, r.SessionService, r.RefreshTokenService, r.JwtTokenizer, r.UserProducer, &r.Config, token)
 }
 
 // VerifyEmail is the resolver for the VerifyEmail field.
@@ -48,8 +63,63 @@ func (r *mutationResolver) ResendVerificationEmail(ctx context.Context, username
 }
 
 // Logout is the resolver for the Logout field.
-func (r *mutationResolver) Logout(ctx context.Context) (bool, error) {
-	return resolvers.Logout(ctx, &r.Config)
+func (r *mutationResolver) Logout(ctx context.Context, refreshToken *string, allDevices *bool) (bool, error) {
+	return resolvers.Logout(ctx, &r.Config, r.SessionService, r.RefreshTokenService, r.JwtTokenizer, r.Denylist, r.UserProducer, refreshToken, allDevices)
+}
+
+// RevokeSession is the resolver for the RevokeSession field.
+func (r *mutationResolver) RevokeSession(ctx context.Context, id string) (bool, error) {
+	return resolvers.RevokeSession(ctx, &r.Config, r.SessionService, r.RefreshTokenService, r.JwtTokenizer, r.Denylist, r.UserProducer, id)
+}
+
+// RevokeOtherSessions is the resolver for the RevokeOtherSessions field.
+func (r *mutationResolver) RevokeOtherSessions(ctx context.Context) (bool, error) {
+	return resolvers.RevokeOtherSessions(ctx, r.SessionService, r.RefreshTokenService, r.JwtTokenizer, r.Denylist, r.UserProducer)
+}
+
+// EnrollMfa is the resolver for the EnrollMFA field.
+func (r *mutationResolver) EnrollMfa(ctx context.Context) (*model.MFAEnrollment, error) {
+	return resolvers.EnrollMFA(ctx, r.CredentialService, r.MFAService)
+}
+
+// ConfirmMFAEnrollment is the resolver for the ConfirmMFAEnrollment field.
+func (r *mutationResolver) ConfirmMFAEnrollment(ctx context.Context, code string) ([]string, error) {
+	return resolvers.ConfirmMFAEnrollment(ctx, r.MFAService, code)
+}
+
+// DisableMfa is the resolver for the DisableMFA field.
+func (r *mutationResolver) DisableMfa(ctx context.Context, code string) (bool, error) {
+	return resolvers.DisableMFA(ctx, r.MFAService, r.LockoutService, r.UserProducer, code)
+}
+
+// CompleteMFAChallenge is the resolver for the CompleteMFAChallenge field.
+func (r *mutationResolver) CompleteMFAChallenge(ctx context.Context, input model.CompleteMFAChallengeInput) (*model.SigninResult, error) {
+	return resolvers.CompleteMFAChallenge(ctx, r.CredentialService, r.MFAService, r.LockoutService, r.SessionService, r.RefreshTokenService, r.JwtTokenizer, r.Denylist, r.UserProducer, &r.Config, input)
+}
+
+// RegenerateRecoveryCodes is the resolver for the RegenerateRecoveryCodes field.
+func (r *mutationResolver) RegenerateRecoveryCodes(ctx context.Context, code string) ([]string, error) {
+	return resolvers.RegenerateRecoveryCodes(ctx, r.MFAService, r.LockoutService, r.UserProducer, code)
+}
+
+// BeginPasskeyRegistration is the resolver for the BeginPasskeyRegistration field.
+func (r *mutationResolver) BeginPasskeyRegistration(ctx context.Context) (*model.WebAuthnCeremony, error) {
+	return resolvers.BeginPasskeyRegistration(ctx, r.CredentialService, r.PasskeyService)
+}
+
+// FinishPasskeyRegistration is the resolver for the FinishPasskeyRegistration field.
+func (r *mutationResolver) FinishPasskeyRegistration(ctx context.Context, input model.FinishPasskeyRegistrationInput) (bool, error) {
+	return resolvers.FinishPasskeyRegistration(ctx, r.PasskeyService, input)
+}
+
+// BeginPasskeyLogin is the resolver for the BeginPasskeyLogin field.
+func (r *mutationResolver) BeginPasskeyLogin(ctx context.Context) (*model.WebAuthnCeremony, error) {
+	return resolvers.BeginPasskeyLogin(ctx, r.PasskeyService)
+}
+
+// FinishPasskeyLogin is the resolver for the FinishPasskeyLogin field.
+func (r *mutationResolver) FinishPasskeyLogin(ctx context.Context, input model.FinishPasskeyLoginInput) (*model.SigninResult, error) {
+	return resolvers.FinishPasskeyLogin(ctx, r.CredentialService, r.PasskeyService, r.SessionService, r.RefreshTokenService, r.JwtTokenizer, r.Denylist, &r.Config, input)
 }
 
 // AvailabilityByUsername is the resolver for the availabilityByUsername field.
@@ -58,6 +128,11 @@ func (r *queryResolver) AvailabilityByUsername(ctx context.Context, username str
 	return resolvers.AvailabilityByUsername(ctx, r.CredentialService, username)
 }
 
+// RecoveryCodesRemaining is the resolver for the recoveryCodesRemaining field.
+func (r *queryResolver) RecoveryCodesRemaining(ctx context.Context) (int, error) {
+	return resolvers.RecoveryCodesRemaining(ctx, r.MFAService)
+}
+
 // Mutation returns generated.MutationResolver implementation.
 func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
 
@@ -9,13 +9,13 @@ import (
 
 	"github.com/weeb-vip/auth/graph/generated"
 	"github.com/weeb-vip/auth/graph/model"
+	"github.com/weeb-vip/auth/http/handlers/loaders"
+	"github.com/weeb-vip/auth/internal/resolvers"
 )
 
 // ActiveSessions is the resolver for the active_sessions field.
 func (r *userResolver) ActiveSessions(ctx context.Context, obj *model.User) ([]*model.SessionDetails, error) {
-	// while actually implementing this, a dataloader will be a must-have.
-	// when we have a Repository which can give session details based on user, we use that.
-	return []*model.SessionDetails{}, nil
+	return resolvers.ActiveSessions(ctx, loaders.FromContext(ctx).UserSessions, r.JwtTokenizer, r.RefreshTokenService, obj.ID)
 }
 
 // User returns generated.UserResolver implementation.
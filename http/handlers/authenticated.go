@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/weeb-vip/auth/http/handlers/requestinfo"
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/jwt"
+	logger2 "github.com/weeb-vip/auth/internal/logger"
+)
+
+// authenticated is the @Authenticated directive. The gateway only checks the signature and expiry of the access
+// token it forwards, so a token revoked on logout, password change or session eviction is rejected here.
+func authenticated(tokenizer jwt.Tokenizer, tokenDenylist denylist.Denylist) func(ctx context.Context, obj interface{}, next graphql.Resolver) (interface{}, error) {
+	return func(ctx context.Context, obj interface{}, next graphql.Resolver) (interface{}, error) {
+		req := requestinfo.FromContext(ctx)
+
+		if req.UserID == nil || req.RawToken == nil {
+			// unauthorized
+			return nil, fmt.Errorf("Access denied")
+		}
+
+		claims, err := tokenizer.GetClaims(*req.RawToken)
+		if err != nil {
+			return nil, fmt.Errorf("Access denied")
+		}
+
+		denied, err := tokenDenylist.IsDenied(ctx, denylist.FromClaims(claims))
+		if err != nil {
+			log := logger2.FromCtx(ctx)
+			log.Error().Err(err).Str("user_id", *req.UserID).Msg("Failed to check the access token denylist")
+
+			return nil, fmt.Errorf("Access denied")
+		}
+
+		if denied {
+			return nil, fmt.Errorf("Access denied")
+		}
+
+		return next(ctx)
+	}
+}
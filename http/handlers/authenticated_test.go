@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/http/handlers/requestinfo"
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/jwt"
+	"github.com/weeb-vip/auth/internal/keypair"
+)
+
+func newTokenizer(t *testing.T) jwt.Tokenizer {
+	t.Helper()
+
+	count := 0
+	rotator, err := keypair.NewSigningKeyRotator(func(publicKey string) (string, error) {
+		count++
+
+		return fmt.Sprintf("key_%d", count), nil
+	}, keypair.WithAlgorithm(keypair.EdDSA))
+	assert.NoError(t, err)
+
+	return jwt.New(rotator)
+}
+
+// newGatewayContext returns the context of a request the gateway forwarded with the given headers.
+func newGatewayContext(headers map[string]string) context.Context {
+	request := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+
+	var ctx context.Context
+	requestinfo.Handler()(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ctx = request.Context()
+	})).ServeHTTP(httptest.NewRecorder(), request)
+
+	return ctx
+}
+
+func TestAuthenticated(t *testing.T) {
+	tokenizer := newTokenizer(t)
+	subject := "user_123"
+	sessionID := "session_123"
+	accessToken, err := tokenizer.Tokenize(jwt.Claims{Subject: &subject, SessionID: &sessionID})
+	assert.NoError(t, err)
+
+	next := func(ctx context.Context) (interface{}, error) {
+		return true, nil
+	}
+
+	t.Run("lets a signed in user through", func(t *testing.T) {
+		directive := authenticated(tokenizer, denylist.NewMemoryDenylist())
+
+		result, err := directive(newGatewayContext(map[string]string{"x-user-id": subject, "x-raw-token": accessToken}), nil, next)
+		assert.NoError(t, err)
+		assert.Equal(t, true, result)
+	})
+
+	t.Run("denies an anonymous request", func(t *testing.T) {
+		directive := authenticated(tokenizer, denylist.NewMemoryDenylist())
+
+		result, err := directive(newGatewayContext(nil), nil, next)
+		assert.EqualError(t, err, "Access denied")
+		assert.Nil(t, result)
+	})
+
+	t.Run("denies a revoked access token", func(t *testing.T) {
+		tokenDenylist := denylist.NewMemoryDenylist()
+		assert.NoError(t, tokenDenylist.DenySession(context.Background(), sessionID, time.Now().Add(jwt.DefaultTTL)))
+		directive := authenticated(tokenizer, tokenDenylist)
+
+		result, err := directive(newGatewayContext(map[string]string{"x-user-id": subject, "x-raw-token": accessToken}), nil, next)
+		assert.EqualError(t, err, "Access denied")
+		assert.Nil(t, result)
+	})
+
+	t.Run("denies a token it cannot read", func(t *testing.T) {
+		directive := authenticated(tokenizer, denylist.NewMemoryDenylist())
+
+		result, err := directive(newGatewayContext(map[string]string{"x-user-id": subject, "x-raw-token": "not-a-token"}), nil, next)
+		assert.EqualError(t, err, "Access denied")
+		assert.Nil(t, result)
+	})
+}
@@ -0,0 +1,52 @@
+package jwks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/weeb-vip/auth/internal/jwks"
+	"github.com/weeb-vip/auth/internal/keypair"
+	"github.com/weeb-vip/auth/internal/logger"
+)
+
+// Handler serves the public keys of signingKey as a JSON Web Key Set. Clients may cache it for maxAge,
+// so rotated out keys must be retained for at least maxAge on top of the token lifetime.
+func Handler(signingKey keypair.RotatingSigningKey, maxAge time.Duration) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		log := logger.FromCtx(request.Context())
+
+		set, err := jwks.NewSet(signingKey.GetPublicKeys())
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to build JSON Web Key Set")
+			http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+
+		body, err := json.Marshal(set)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to encode JSON Web Key Set")
+			http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+
+		digest := sha256.Sum256(body)
+		etag := `"` + hex.EncodeToString(digest[:16]) + `"`
+
+		writer.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+		writer.Header().Set("ETag", etag)
+		if request.Header.Get("If-None-Match") == etag {
+			writer.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		writer.Write(body) // nolint
+	})
+}
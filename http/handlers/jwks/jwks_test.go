@@ -0,0 +1,78 @@
+package jwks_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/http/handlers/jwks"
+	internaljwks "github.com/weeb-vip/auth/internal/jwks"
+	"github.com/weeb-vip/auth/internal/keypair"
+)
+
+func newRotator(t *testing.T) keypair.RotatingSigningKey {
+	t.Helper()
+
+	count := 0
+	rotator, err := keypair.NewSigningKeyRotator(func(publicKey string) (string, error) {
+		count++
+
+		return fmt.Sprintf("key_%d", count), nil
+	})
+	assert.NoError(t, err)
+
+	return rotator
+}
+
+func TestHandler(t *testing.T) {
+	rotator := newRotator(t)
+	rotator.Rotate()
+	handler := jwks.Handler(rotator, 5*time.Minute)
+
+	t.Run("serves the current and retained keys", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, "public, max-age=300", recorder.Header().Get("Cache-Control"))
+		assert.NotEmpty(t, recorder.Header().Get("ETag"))
+
+		var set internaljwks.Set
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &set))
+		if assert.Len(t, set.Keys, 2) {
+			assert.Equal(t, "key_2", set.Keys[0].Kid)
+			assert.Equal(t, "key_1", set.Keys[1].Kid)
+			assert.NotEmpty(t, set.Keys[0].N)
+		}
+	})
+
+	t.Run("answers a matching If-None-Match with not modified", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+		request := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+		request.Header.Set("If-None-Match", recorder.Header().Get("ETag"))
+		revalidated := httptest.NewRecorder()
+		handler.ServeHTTP(revalidated, request)
+
+		assert.Equal(t, http.StatusNotModified, revalidated.Code)
+		assert.Empty(t, revalidated.Body.Bytes())
+	})
+
+	t.Run("changes the ETag when keys rotate", func(t *testing.T) {
+		before := httptest.NewRecorder()
+		handler.ServeHTTP(before, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+		rotator.Rotate()
+		after := httptest.NewRecorder()
+		handler.ServeHTTP(after, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+		assert.NotEqual(t, before.Header().Get("ETag"), after.Header().Get("ETag"))
+	})
+}
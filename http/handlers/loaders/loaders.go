@@ -0,0 +1,49 @@
+package loaders
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/weeb-vip/auth/internal/dataloader"
+	"github.com/weeb-vip/auth/internal/services/session"
+	SessionModels "github.com/weeb-vip/auth/internal/services/session/models"
+)
+
+const (
+	batchWait    = 2 * time.Millisecond
+	maxBatchSize = 100
+)
+
+type ctxKey struct{}
+
+// Loaders batches the lookups of a request, a federated query resolving many users loads their sessions
+// together.
+type Loaders struct {
+	UserSessions *dataloader.Loader[string, []*SessionModels.Session]
+}
+
+func NewLoaders(sessionService session.Session) *Loaders {
+	return &Loaders{
+		UserSessions: dataloader.NewLoader(sessionService.ListUserSessions, batchWait, maxBatchSize),
+	}
+}
+
+func FromContext(ctx context.Context) *Loaders {
+	loaders, found := ctx.Value(&ctxKey{}).(*Loaders)
+	if !found {
+		panic("handlers not set correctly")
+	}
+
+	return loaders
+}
+
+// Handler gives every request its own loaders, so nothing loaded is shared between requests.
+func Handler(sessionService session.Session) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			ctx := context.WithValue(request.Context(), &ctxKey{}, NewLoaders(sessionService))
+			handler.ServeHTTP(writer, request.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Clients maps the ID of each client allowed to introspect and revoke tokens to its secret.
+type Clients map[string]string
+
+// ParseClients reads "client_id:secret" entries separated by commas.
+func ParseClients(spec string) (Clients, error) {
+	clients := Clients{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, secret, found := strings.Cut(entry, ":")
+		if !found || id == "" || secret == "" {
+			return nil, fmt.Errorf("oauth client %q is not client_id:secret", id)
+		}
+
+		clients[id] = secret
+	}
+
+	return clients, nil
+}
+
+// authenticate accepts client credentials through HTTP basic authentication or the client_id and
+// client_secret form parameters, as RFC 6749 section 2.3.1 allows, returning the client ID.
+func (c Clients) authenticate(request *http.Request) (string, bool) {
+	id, secret, found := request.BasicAuth()
+	if !found {
+		id, secret = request.PostFormValue("client_id"), request.PostFormValue("client_secret")
+	}
+
+	expected, found := c[id]
+	if !found || secret == "" {
+		return "", false
+	}
+
+	// Compare digests so the comparison takes as long whatever the length of the secrets.
+	expectedDigest, secretDigest := sha256.Sum256([]byte(expected)), sha256.Sum256([]byte(secret))
+
+	return id, subtle.ConstantTimeCompare(expectedDigest[:], secretDigest[:]) == 1
+}
@@ -0,0 +1,242 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/jwt"
+	"github.com/weeb-vip/auth/internal/logger"
+	"github.com/weeb-vip/auth/internal/services/refresh_token"
+	"github.com/weeb-vip/auth/internal/services/session"
+)
+
+const (
+	TokenTypeAccessToken  = "access_token"
+	TokenTypeRefreshToken = "refresh_token"
+)
+
+// introspection is the RFC 7662 response, an inactive token reports nothing but active.
+type introspection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type Handlers struct {
+	clients       Clients
+	tokenizer     jwt.Tokenizer
+	refreshTokens refresh_token.RefreshToken
+	sessions      session.Session
+	denylist      denylist.Denylist
+	now           func() time.Time
+}
+
+func NewHandlers(
+	clients Clients,
+	tokenizer jwt.Tokenizer,
+	refreshTokens refresh_token.RefreshToken,
+	sessions session.Session,
+	denylist denylist.Denylist,
+) *Handlers {
+	return &Handlers{
+		clients:       clients,
+		tokenizer:     tokenizer,
+		refreshTokens: refreshTokens,
+		sessions:      sessions,
+		denylist:      denylist,
+		now:           time.Now,
+	}
+}
+
+// Introspect implements RFC 7662 token introspection for access and refresh tokens. The purpose of a
+// purpose-bound access token is reported as its scope. Revoked access tokens are reported inactive, so a
+// gateway verifying tokens locally can call it to check the denylist.
+func (h *Handlers) Introspect() http.Handler {
+	return h.tokenEndpoint(func(writer http.ResponseWriter, request *http.Request, clientID string, token string, hint string) {
+		log := logger.FromCtx(request.Context())
+
+		inspect := []func(ctx context.Context, token string) (*introspection, error){h.introspectAccessToken, h.introspectRefreshToken}
+		if hint == TokenTypeRefreshToken {
+			inspect[0], inspect[1] = inspect[1], inspect[0]
+		}
+
+		result := &introspection{Active: false}
+		for _, fn := range inspect {
+			found, err := fn(request.Context(), token)
+			if err != nil {
+				log.Error().Err(err).Str("client_id", clientID).Msg("Failed to introspect token")
+				writeJSON(writer, http.StatusInternalServerError, errorResponse{Error: "server_error"})
+
+				return
+			}
+
+			if found != nil {
+				result = found
+
+				break
+			}
+		}
+
+		writeJSON(writer, http.StatusOK, result)
+	})
+}
+
+// Revoke implements RFC 7009 token revocation. A refresh token ends its session together with the rest of its
+// token family, as logging out does, and access tokens are denylisted until they expire; like the RFC asks,
+// unknown and already invalid tokens are not reported as errors.
+func (h *Handlers) Revoke() http.Handler {
+	return h.tokenEndpoint(func(writer http.ResponseWriter, request *http.Request, clientID string, token string, hint string) {
+		log := logger.FromCtx(request.Context())
+
+		tokenType, err := h.revoke(request.Context(), token)
+		if err != nil {
+			log.Error().Err(err).Str("client_id", clientID).Msg("Failed to revoke token")
+			writeJSON(writer, http.StatusServiceUnavailable, errorResponse{Error: "server_error"})
+
+			return
+		}
+
+		if tokenType != "" {
+			log.Info().Str("client_id", clientID).Str("token_type", tokenType).Msg("Token revoked")
+		}
+		writer.WriteHeader(http.StatusOK)
+	})
+}
+
+// revoke returns the type of the revoked token, or an empty string when there was nothing to revoke.
+func (h *Handlers) revoke(ctx context.Context, token string) (string, error) {
+	claims, err := h.tokenizer.GetClaims(token)
+	if err == nil {
+		// Tokens issued before tokens had an ID cannot be denied on their own, they expire soon enough.
+		if claims.ID == nil || claims.ExpiresAt == nil {
+			return "", nil
+		}
+
+		return TokenTypeAccessToken, h.denylist.Deny(ctx, *claims.ID, *claims.ExpiresAt)
+	}
+
+	refreshToken, err := h.refreshTokens.GetToken(token)
+	if err != nil || refreshToken == nil {
+		return "", err
+	}
+
+	// Tokens issued before refresh token families have no session to end.
+	if refreshToken.FamilyID != "" {
+		_, err = h.sessions.RevokeSession(ctx, refreshToken.UserID, refreshToken.FamilyID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return TokenTypeRefreshToken, h.refreshTokens.DeleteToken(token)
+}
+
+func (h *Handlers) introspectAccessToken(ctx context.Context, token string) (*introspection, error) {
+	claims, err := h.tokenizer.GetClaims(token)
+	if err != nil {
+		return nil, nil // nolint
+	}
+
+	denied, err := h.denylist.IsDenied(ctx, denylist.FromClaims(claims))
+	if err != nil || denied {
+		return nil, err
+	}
+
+	result := &introspection{Active: true, TokenType: TokenTypeAccessToken}
+	if claims.Subject != nil {
+		result.Subject = *claims.Subject
+	}
+	if claims.Purpose != nil {
+		result.Scope = *claims.Purpose
+	}
+	if claims.ExpiresAt != nil {
+		result.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		result.IssuedAt = claims.IssuedAt.Unix()
+	}
+
+	return result, nil
+}
+
+func (h *Handlers) introspectRefreshToken(ctx context.Context, token string) (*introspection, error) {
+	refreshToken, err := h.refreshTokens.GetToken(token)
+	if err != nil || refreshToken == nil {
+		return nil, err
+	}
+
+	if refreshToken.UsedAt != nil || refreshToken.Expiry < h.now().Unix() {
+		return nil, nil
+	}
+
+	return &introspection{
+		Active:    true,
+		TokenType: TokenTypeRefreshToken,
+		Subject:   refreshToken.UserID,
+		ExpiresAt: refreshToken.Expiry,
+		IssuedAt:  refreshToken.CreatedAt.Unix(),
+	}, nil
+}
+
+type tokenHandler func(writer http.ResponseWriter, request *http.Request, clientID string, token string, hint string)
+
+// tokenEndpoint checks what introspection and revocation requests have in common: a form POST by an
+// authenticated client naming the token and, optionally, its type.
+func (h *Handlers) tokenEndpoint(next tokenHandler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Cache-Control", "no-store")
+
+		if request.Method != http.MethodPost {
+			writer.Header().Set("Allow", http.MethodPost)
+			writeJSON(writer, http.StatusMethodNotAllowed, errorResponse{Error: "invalid_request"})
+
+			return
+		}
+
+		if err := request.ParseForm(); err != nil {
+			writeJSON(writer, http.StatusBadRequest, errorResponse{Error: "invalid_request"})
+
+			return
+		}
+
+		clientID, ok := h.clients.authenticate(request)
+		if !ok {
+			writer.Header().Set("WWW-Authenticate", `Basic realm="auth"`)
+			writeJSON(writer, http.StatusUnauthorized, errorResponse{Error: "invalid_client"})
+
+			return
+		}
+
+		token := request.PostFormValue("token")
+		if token == "" {
+			writeJSON(writer, http.StatusBadRequest, errorResponse{Error: "invalid_request"})
+
+			return
+		}
+
+		hint := request.PostFormValue("token_type_hint")
+		if hint != "" && hint != TokenTypeAccessToken && hint != TokenTypeRefreshToken {
+			writeJSON(writer, http.StatusBadRequest, errorResponse{Error: "unsupported_token_type"})
+
+			return
+		}
+
+		next(writer, request, clientID, token, hint)
+	})
+}
+
+func writeJSON(writer http.ResponseWriter, status int, body any) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(body) // nolint
+}
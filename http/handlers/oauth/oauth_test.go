@@ -0,0 +1,238 @@
+package oauth_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/http/handlers/oauth"
+	"github.com/weeb-vip/auth/internal/db"
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/jwt"
+	"github.com/weeb-vip/auth/internal/keypair"
+	"github.com/weeb-vip/auth/internal/services/refresh_token/models"
+	"github.com/weeb-vip/auth/internal/services/session"
+)
+
+// fakeRefreshTokens only implements what the endpoints use.
+type fakeRefreshTokens struct {
+	tokens map[string]*models.RefreshToken
+}
+
+func (f *fakeRefreshTokens) GetToken(token string) (*models.RefreshToken, error) {
+	return f.tokens[token], nil
+}
+
+func (f *fakeRefreshTokens) GetTokenByUserID(userID string) (*models.RefreshToken, error) {
+	return nil, nil
+}
+
+func (f *fakeRefreshTokens) CreateToken(userID string, sessionID string) (*models.RefreshToken, error) {
+	return nil, nil
+}
+
+func (f *fakeRefreshTokens) RotateToken(current *models.RefreshToken) (*models.RefreshToken, error) {
+	return nil, nil
+}
+
+func (f *fakeRefreshTokens) DeleteToken(token string) error {
+	delete(f.tokens, token)
+
+	return nil
+}
+
+func (f *fakeRefreshTokens) ValidateToken(token string) (bool, error) {
+	return f.tokens[token] != nil, nil
+}
+
+// fakeSessions only implements ending a session, which drops the refresh tokens of its family.
+type fakeSessions struct {
+	session.Session
+	refreshTokens *fakeRefreshTokens
+	revoked       []string
+}
+
+func (f *fakeSessions) RevokeSession(ctx context.Context, userID string, sessionID string) (bool, error) {
+	f.revoked = append(f.revoked, userID+"/"+sessionID)
+
+	for token, refreshToken := range f.refreshTokens.tokens {
+		if refreshToken.UserID == userID && refreshToken.FamilyID == sessionID {
+			delete(f.refreshTokens.tokens, token)
+		}
+	}
+
+	return true, nil
+}
+
+func newTokenizer(t *testing.T) jwt.Tokenizer {
+	t.Helper()
+
+	count := 0
+	rotator, err := keypair.NewSigningKeyRotator(func(publicKey string) (string, error) {
+		count++
+
+		return fmt.Sprintf("key_%d", count), nil
+	}, keypair.WithAlgorithm(keypair.EdDSA))
+	assert.NoError(t, err)
+
+	return jwt.New(rotator)
+}
+
+func post(handler http.Handler, form url.Values, clientID string, secret string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/oauth", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if clientID != "" {
+		request.SetBasicAuth(clientID, secret)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func introspect(t *testing.T, handler http.Handler, token string) map[string]any {
+	t.Helper()
+
+	recorder := post(handler, url.Values{"token": {token}}, "gateway", "gateway-secret")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+
+	return body
+}
+
+func TestHandlers(t *testing.T) {
+	tokenizer := newTokenizer(t)
+
+	subject := "user_123"
+	accessToken, err := tokenizer.Tokenize(jwt.Claims{Subject: &subject})
+	assert.NoError(t, err)
+
+	purpose := "mfa_challenge"
+	challengeToken, err := tokenizer.Tokenize(jwt.Claims{Subject: &subject, Purpose: &purpose})
+	assert.NoError(t, err)
+
+	clients, err := oauth.ParseClients("gateway:gateway-secret, billing:billing-secret")
+	assert.NoError(t, err)
+
+	refreshTokens := &fakeRefreshTokens{tokens: map[string]*models.RefreshToken{
+		"refresh_token_active": {
+			BaseModel: db.BaseModel{CreatedAt: time.Now()},
+			UserID:    "user_123",
+			FamilyID:  "session_123",
+			Expiry:    time.Now().Add(time.Hour).Unix(),
+		},
+		"refresh_token_rotated": {
+			UserID:   "user_123",
+			FamilyID: "session_123",
+			Expiry:   time.Now().Add(time.Hour).Unix(),
+		},
+		"refresh_token_legacy": {
+			UserID: "user_123",
+			Expiry: time.Now().Add(time.Hour).Unix(),
+		},
+		"refresh_token_expired": {
+			UserID: "user_123",
+			Expiry: time.Now().Add(-time.Hour).Unix(),
+		},
+	}}
+	sessions := &fakeSessions{refreshTokens: refreshTokens}
+	handlers := oauth.NewHandlers(clients, tokenizer, refreshTokens, sessions, denylist.NewMemoryDenylist())
+
+	t.Run("introspects an access token", func(t *testing.T) {
+		body := introspect(t, handlers.Introspect(), accessToken)
+		assert.Equal(t, true, body["active"])
+		assert.Equal(t, "access_token", body["token_type"])
+		assert.Equal(t, "user_123", body["sub"])
+		assert.NotZero(t, body["exp"])
+		assert.NotContains(t, body, "scope")
+	})
+
+	t.Run("reports the purpose of an access token as its scope", func(t *testing.T) {
+		body := introspect(t, handlers.Introspect(), challengeToken)
+		assert.Equal(t, true, body["active"])
+		assert.Equal(t, "mfa_challenge", body["scope"])
+	})
+
+	t.Run("introspects a refresh token", func(t *testing.T) {
+		body := introspect(t, handlers.Introspect(), "refresh_token_active")
+		assert.Equal(t, true, body["active"])
+		assert.Equal(t, "refresh_token", body["token_type"])
+		assert.Equal(t, "user_123", body["sub"])
+	})
+
+	t.Run("reports expired and unknown tokens as inactive only", func(t *testing.T) {
+		for _, token := range []string{"refresh_token_expired", "refresh_token_unknown", accessToken + "x"} {
+			assert.Equal(t, map[string]any{"active": false}, introspect(t, handlers.Introspect(), token))
+		}
+	})
+
+	t.Run("rejects unauthenticated clients", func(t *testing.T) {
+		for _, secret := range []string{"", "wrong-secret", "billing-secret"} {
+			recorder := post(handlers.Introspect(), url.Values{"token": {accessToken}}, "gateway", secret)
+			assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+			assert.JSONEq(t, `{"error":"invalid_client"}`, recorder.Body.String())
+		}
+
+		recorder := post(handlers.Introspect(), url.Values{
+			"token":         {accessToken},
+			"client_id":     {"billing"},
+			"client_secret": {"billing-secret"},
+		}, "", "")
+		assert.Equal(t, http.StatusOK, recorder.Code, "credentials may be sent in the form")
+	})
+
+	t.Run("rejects malformed requests", func(t *testing.T) {
+		recorder := post(handlers.Introspect(), url.Values{}, "gateway", "gateway-secret")
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+		recorder = post(handlers.Revoke(), url.Values{"token": {accessToken}, "token_type_hint": {"id_token"}}, "gateway", "gateway-secret")
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.JSONEq(t, `{"error":"unsupported_token_type"}`, recorder.Body.String())
+
+		recorder = httptest.NewRecorder()
+		handlers.Revoke().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/oauth/revoke", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	})
+
+	t.Run("revokes an access token", func(t *testing.T) {
+		recorder := post(handlers.Revoke(), url.Values{"token": {accessToken}}, "gateway", "gateway-secret")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		assert.Equal(t, map[string]any{"active": false}, introspect(t, handlers.Introspect(), accessToken))
+	})
+
+	t.Run("revokes a refresh token with its session and token family", func(t *testing.T) {
+		recorder := post(handlers.Revoke(), url.Values{"token": {"refresh_token_active"}, "token_type_hint": {"refresh_token"}}, "gateway", "gateway-secret")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		assert.Equal(t, []string{"user_123/session_123"}, sessions.revoked)
+		assert.NotContains(t, refreshTokens.tokens, "refresh_token_active")
+		assert.NotContains(t, refreshTokens.tokens, "refresh_token_rotated")
+	})
+
+	t.Run("revokes a refresh token without a session", func(t *testing.T) {
+		sessions.revoked = nil
+
+		recorder := post(handlers.Revoke(), url.Values{"token": {"refresh_token_legacy"}}, "gateway", "gateway-secret")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		assert.Empty(t, sessions.revoked)
+		assert.NotContains(t, refreshTokens.tokens, "refresh_token_legacy")
+	})
+
+	t.Run("accepts revoking an unknown token", func(t *testing.T) {
+		recorder := post(handlers.Revoke(), url.Values{"token": {"refresh_token_unknown"}}, "gateway", "gateway-secret")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+}
@@ -17,6 +17,56 @@ func FromContext(ctx context.Context) RequestInfo {
 	return details
 }
 
+// RemoteIP returns the client IP forwarded by the gateway, or an empty string when it is unknown.
+func RemoteIP(ctx context.Context) string {
+	details, found := ctx.Value(&ctxKey{}).(RequestInfo)
+	if !found || details.RemoteIP == nil {
+		return ""
+	}
+
+	return *details.RemoteIP
+}
+
+// UserAgent returns the client user agent forwarded by the gateway, or an empty string when it is unknown.
+func UserAgent(ctx context.Context) string {
+	details, found := ctx.Value(&ctxKey{}).(RequestInfo)
+	if !found || details.UserAgent == nil {
+		return ""
+	}
+
+	return *details.UserAgent
+}
+
+// UserID returns the authenticated user ID forwarded by the gateway, or an empty string for anonymous requests.
+func UserID(ctx context.Context) string {
+	details, found := ctx.Value(&ctxKey{}).(RequestInfo)
+	if !found || details.UserID == nil {
+		return ""
+	}
+
+	return *details.UserID
+}
+
+// RawToken returns the access token the gateway verified for the request, or an empty string when there is none.
+func RawToken(ctx context.Context) string {
+	details, found := ctx.Value(&ctxKey{}).(RequestInfo)
+	if !found || details.RawToken == nil {
+		return ""
+	}
+
+	return *details.RawToken
+}
+
+// RefreshToken returns the refresh token cookie of the request, or an empty string when there is none.
+func RefreshToken(ctx context.Context) string {
+	details, found := ctx.Value(&ctxKey{}).(RequestInfo)
+	if !found || details.RefreshToken == nil {
+		return ""
+	}
+
+	return *details.RefreshToken
+}
+
 func Handler() func(http.Handler) http.Handler {
 	return getHandler
 }
@@ -30,12 +80,13 @@ func getHandler(handler http.Handler) http.Handler {
 
 func getRequestInfoFromRequest(request *http.Request) RequestInfo {
 	return RequestInfo{
-		UserID:    getNillableHeaderValue(request, "x-user-id"),
-		Purpose:   getNillableHeaderValue(request, "x-token-purpose"),
-		RawToken:  getNillableHeaderValue(request, "x-raw-token"),
-		UserType:  getUserType(getNillableHeaderValue(request, "x-user-id")),
-		RemoteIP:  getNillableHeaderValue(request, "x-remote-ip"),
-		UserAgent: getNillableHeaderValue(request, "x-user-agent"),
+		UserID:       getNillableHeaderValue(request, "x-user-id"),
+		Purpose:      getNillableHeaderValue(request, "x-token-purpose"),
+		RawToken:     getNillableHeaderValue(request, "x-raw-token"),
+		UserType:     getUserType(getNillableHeaderValue(request, "x-user-id")),
+		RemoteIP:     getNillableHeaderValue(request, "x-remote-ip"),
+		UserAgent:    getNillableHeaderValue(request, "x-user-agent"),
+		RefreshToken: getNillableCookieValue(request, "refresh_token"),
 	}
 }
 func getUserType(userID *string) *UserType {
@@ -66,6 +117,15 @@ func getNillableHeaderValue(request *http.Request, headerKey string) *string {
 	return &value
 }
 
+func getNillableCookieValue(request *http.Request, name string) *string {
+	cookie, err := request.Cookie(name)
+	if err != nil || cookie.Value == "" {
+		return nil
+	}
+
+	return &cookie.Value
+}
+
 func addRequestInfoToContext(ctx context.Context, details RequestInfo) context.Context {
 	return context.WithValue(ctx, &ctxKey{}, details)
 }
@@ -28,6 +28,7 @@ func TestHandler(t *testing.T) {
 		req.Header.Add("x-user-id", "user_something")
 		req.Header.Add("x-token-purpose", "purpose")
 		req.Header.Add("x-raw-token", "raw-token")
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh-token"})
 
 		f := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 			info := requestinfo.FromContext(request.Context())
@@ -37,6 +38,7 @@ func TestHandler(t *testing.T) {
 			assert.Equal(t, "user_something", *info.UserID)
 			assert.Equal(t, "purpose", *info.Purpose)
 			assert.Equal(t, "raw-token", *info.RawToken)
+			assert.Equal(t, "refresh-token", *info.RefreshToken)
 			assert.Equal(t, requestinfo.UserTypeUser, *info.UserType)
 		})
 		requestinfo.Handler()(f).ServeHTTP(httptest.NewRecorder(), req)
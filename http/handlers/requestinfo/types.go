@@ -12,6 +12,8 @@ type RequestInfo struct {
 	UserType  *UserType
 	RemoteIP  *string
 	UserAgent *string
+	// RefreshToken is read from the refresh_token cookie the service sets on sign in.
+	RefreshToken *string
 }
 
 type UserType string
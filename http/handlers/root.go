@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net/http"
 
-	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/apollotracing"
 	"github.com/ThatCatDev/ep/v2/drivers"
@@ -16,28 +15,34 @@ import (
 	"github.com/weeb-vip/auth/config"
 	"github.com/weeb-vip/auth/graph"
 	"github.com/weeb-vip/auth/graph/generated"
+	"github.com/weeb-vip/auth/http/handlers/loaders"
 	"github.com/weeb-vip/auth/http/handlers/logger"
 	"github.com/weeb-vip/auth/http/handlers/metrics"
 	"github.com/weeb-vip/auth/http/handlers/requestinfo"
 	"github.com/weeb-vip/auth/http/handlers/responsecontext"
+	"github.com/weeb-vip/auth/internal/denylist"
 	"github.com/weeb-vip/auth/internal/jwt"
 	logger2 "github.com/weeb-vip/auth/internal/logger"
 	"github.com/weeb-vip/auth/internal/measurements"
 	observabilityMiddleware "github.com/weeb-vip/auth/internal/middleware"
+	"github.com/weeb-vip/auth/internal/ratelimit"
 	"github.com/weeb-vip/auth/internal/services/credential"
+	"github.com/weeb-vip/auth/internal/services/lockout"
 	"github.com/weeb-vip/auth/internal/services/mail"
+	"github.com/weeb-vip/auth/internal/services/mfa"
 	"github.com/weeb-vip/auth/internal/services/mjml"
+	"github.com/weeb-vip/auth/internal/services/passkey"
 	"github.com/weeb-vip/auth/internal/services/passwordreset"
 	"github.com/weeb-vip/auth/internal/services/refresh_token"
 	"github.com/weeb-vip/auth/internal/services/session"
 	"github.com/weeb-vip/auth/internal/services/validation_token"
 )
 
-func BuildRootHandler(tokenizer jwt.Tokenizer) http.Handler { // nolint
-	return BuildRootHandlerWithContext(context.Background(), tokenizer)
+func BuildRootHandler(tokenizer jwt.Tokenizer, tokenDenylist denylist.Denylist) http.Handler { // nolint
+	return BuildRootHandlerWithContext(context.Background(), tokenizer, tokenDenylist)
 }
 
-func BuildRootHandlerWithContext(ctx context.Context, tokenizer jwt.Tokenizer) http.Handler { // nolint
+func BuildRootHandlerWithContext(ctx context.Context, tokenizer jwt.Tokenizer, tokenDenylist denylist.Denylist) http.Handler { // nolint
 	logrus.SetFormatter(&logrus.TextFormatter{})
 	log := logger2.FromCtx(ctx)
 
@@ -69,13 +74,16 @@ func BuildRootHandlerWithContext(ctx context.Context, tokenizer jwt.Tokenizer) h
 		}
 	}(driver)
 
-	authenticationService := credential.NewCredentialService()
-	passwordResetService := passwordreset.NewPasswordResetService()
-	sessionService := session.NewSessionService()
-	refreshTokenService := refresh_token.NewRefreshTokenService(conf.RefreshTokenConfig)
+	lockoutService := lockout.NewLockoutService(conf.LockoutConfig, conf.TokenHashConfig)
+	authenticationService := credential.NewCredentialService(conf.PasswordPolicyConfig, conf.PasswordHashConfig, lockoutService)
+	passwordResetService := passwordreset.NewPasswordResetService(conf.PasswordResetConfig, conf.TokenHashConfig)
+	sessionService := session.NewSessionService(conf.SessionConfig)
+	refreshTokenService := refresh_token.NewRefreshTokenService(conf.RefreshTokenConfig, conf.TokenHashConfig)
 	validationTokenService := validation_token.NewValidationTokenService(tokenizer)
 	mjmlService := mjml.NewMJMLService()
 	mailService := mail.NewMailService(conf.EmailConfig, mjmlService)
+	mfaService := mfa.NewMFAService(conf.MFAConfig)
+	passkeyService := passkey.NewPasskeyService(conf.WebAuthnConfig)
 	resolvers := &graph.Resolver{
 		CredentialService:    authenticationService,
 		PasswordResetService: passwordResetService,
@@ -86,21 +94,19 @@ func BuildRootHandlerWithContext(ctx context.Context, tokenizer jwt.Tokenizer) h
 		ValidationToken:      validationTokenService,
 		MailService:          mailService,
 		UserProducer:         kafkaProducer(context.Background(), driver, conf.KafkaConfig.ProducerTopic),
+		MFAService:           mfaService,
+		PasskeyService:       passkeyService,
+		LockoutService:       lockoutService,
+		Denylist:             tokenDenylist,
 	}
 	cfg := generated.Config{Resolvers: resolvers}
-	cfg.Directives.Authenticated = func(ctx context.Context, obj interface{}, next graphql.Resolver) (res interface{}, err error) {
-		req := requestinfo.FromContext(ctx)
-
-		if req.UserID == nil {
-			// unauthorized
-			return nil, fmt.Errorf("Access denied")
-		}
-
-		return next(ctx)
-	}
+	cfg.Directives.Authenticated = authenticated(tokenizer, tokenDenylist)
 	srv := handler.NewDefaultServer(generated.NewExecutableSchema(cfg))
 	srv.Use(apollotracing.Tracer{})
 	srv.Use(observabilityMiddleware.GraphQLTracingExtension{})
+	if !conf.RateLimitConfig.Disabled {
+		srv.Use(rateLimitExtension(conf.RateLimitConfig))
+	}
 
 	client := measurements.New()
 
@@ -112,7 +118,23 @@ func BuildRootHandlerWithContext(ctx context.Context, tokenizer jwt.Tokenizer) h
 		})
 	}
 
-	return requestinfo.Handler()(logger.Handler()(metrics.Handler(client)(responseContextHandler(srv))))
+	return requestinfo.Handler()(logger.Handler()(metrics.Handler(client)(responseContextHandler(loaders.Handler(sessionService)(srv)))))
+}
+
+// rateLimitExtension keeps limiter state in memory, so each instance enforces the limits on its own share
+// of the traffic; use ratelimit.NewRedisStore instead to share them across instances.
+func rateLimitExtension(cfg config.RateLimitConfig) *observabilityMiddleware.RateLimitExtension {
+	algorithm, err := ratelimit.ParseAlgorithm(cfg.Algorithm)
+	if err != nil {
+		panic(fmt.Errorf("failed to create rate limiter: %w", err))
+	}
+
+	rules, err := observabilityMiddleware.ParseRateLimitRules(cfg.Rules, algorithm)
+	if err != nil {
+		panic(fmt.Errorf("failed to create rate limiter: %w", err))
+	}
+
+	return observabilityMiddleware.NewRateLimitExtension(ratelimit.NewLimiter(ratelimit.NewMemoryStore()), rules)
 }
 
 func kafkaProducer(ctx context.Context, driver drivers.Driver[*kafka.Message], topic string) func(ctx context.Context, message *kafka.Message) error {
@@ -0,0 +1,158 @@
+package breachedpasswords
+
+import (
+	"bufio"
+	"crypto/sha1" // nolint:gosec // the breached password corpus is published as SHA-1 hashes.
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// The file is a header followed by the bit array, so it can be memory mapped and queried without decoding.
+const (
+	magic      = "AUTHBPF1"
+	headerSize = len(magic) + 8 + 8 + 8 // bits, hash functions and entries, all little endian uint64.
+)
+
+var ErrInvalidFile = errors.New("not a breached password filter file")
+
+// Filter is a bloom filter of SHA-1 password hashes, a miss is certain while a hit is wrong
+// with at most the false positive rate it was sized for.
+type Filter struct {
+	bits      []byte
+	size      uint64
+	hashes    uint64
+	entries   uint64
+	unmapData func() error
+}
+
+// NewFilter sizes an empty filter for the expected number of entries and the target false positive rate.
+func NewFilter(expectedEntries uint64, falsePositiveRate float64) (*Filter, error) {
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		return nil, fmt.Errorf("false positive rate must be between 0 and 1, got %v", falsePositiveRate)
+	}
+
+	if expectedEntries == 0 {
+		expectedEntries = 1
+	}
+
+	size := uint64(math.Ceil(-float64(expectedEntries) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	size = (size + 7) / 8 * 8
+	hashes := uint64(math.Max(1, math.Round(float64(size)/float64(expectedEntries)*math.Ln2)))
+
+	return &Filter{
+		bits:   make([]byte, size/8),
+		size:   size,
+		hashes: hashes,
+	}, nil
+}
+
+// Decode reads a filter from the contents of a file written by WriteTo, the data is used as is and not copied.
+func Decode(data []byte) (*Filter, error) {
+	if len(data) < headerSize || string(data[:len(magic)]) != magic {
+		return nil, ErrInvalidFile
+	}
+
+	header := data[len(magic):headerSize]
+	filter := &Filter{
+		size:    binary.LittleEndian.Uint64(header[0:8]),
+		hashes:  binary.LittleEndian.Uint64(header[8:16]),
+		entries: binary.LittleEndian.Uint64(header[16:24]),
+	}
+
+	if filter.size == 0 || filter.size%8 != 0 || filter.hashes == 0 || uint64(len(data)-headerSize) != filter.size/8 {
+		return nil, ErrInvalidFile
+	}
+
+	filter.bits = data[headerSize:]
+
+	return filter, nil
+}
+
+func (f *Filter) WriteTo(w io.Writer) (int64, error) {
+	header := make([]byte, headerSize)
+	copy(header, magic)
+	binary.LittleEndian.PutUint64(header[len(magic):], f.size)
+	binary.LittleEndian.PutUint64(header[len(magic)+8:], f.hashes)
+	binary.LittleEndian.PutUint64(header[len(magic)+16:], f.entries)
+
+	buffered := bufio.NewWriter(w)
+
+	written, err := buffered.Write(header)
+	if err != nil {
+		return int64(written), err
+	}
+
+	n, err := buffered.Write(f.bits)
+	written += n
+
+	if err != nil {
+		return int64(written), err
+	}
+
+	return int64(written), buffered.Flush()
+}
+
+// Add records a SHA-1 hash, the filter must not be memory mapped.
+func (f *Filter) Add(hash [sha1.Size]byte) {
+	first, second := f.locations(hash)
+
+	for i := uint64(0); i < f.hashes; i++ {
+		bit := (first + i*second) % f.size
+		f.bits[bit/8] |= 1 << (bit % 8)
+	}
+
+	f.entries++
+}
+
+func (f *Filter) Contains(hash [sha1.Size]byte) bool {
+	first, second := f.locations(hash)
+
+	for i := uint64(0); i < f.hashes; i++ {
+		bit := (first + i*second) % f.size
+		if f.bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsBreached reports whether the password is, or collides with, one in the corpus.
+func (f *Filter) IsBreached(password string) bool {
+	return f.Contains(sha1.Sum([]byte(password))) // nolint:gosec
+}
+
+func (f *Filter) Entries() uint64 {
+	return f.entries
+}
+
+// FalsePositiveRate estimates the chance that a password outside the corpus is reported as breached.
+func (f *Filter) FalsePositiveRate() float64 {
+	k := float64(f.hashes)
+
+	return math.Pow(1-math.Exp(-k*float64(f.entries)/float64(f.size)), k)
+}
+
+// Close releases the memory mapping of a filter returned by Open.
+func (f *Filter) Close() error {
+	if f.unmapData == nil {
+		return nil
+	}
+
+	err := f.unmapData()
+	f.bits = nil
+	f.unmapData = nil
+
+	return err
+}
+
+// locations derives the hash functions by double hashing, SHA-1 output is uniform enough to slice.
+func (f *Filter) locations(hash [sha1.Size]byte) (uint64, uint64) {
+	first := binary.LittleEndian.Uint64(hash[0:8])
+	second := binary.LittleEndian.Uint64(hash[8:16]) | 1
+
+	return first, second
+}
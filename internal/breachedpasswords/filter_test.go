@@ -0,0 +1,114 @@
+package breachedpasswords_test
+
+import (
+	"crypto/sha1" // nolint:gosec
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/internal/breachedpasswords"
+)
+
+func hashList(passwords ...string) string {
+	var lines []string
+	for i, password := range passwords {
+		lines = append(lines, fmt.Sprintf("%X:%d", sha1.Sum([]byte(password)), i+1)) // nolint:gosec
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func TestFilter(t *testing.T) {
+	t.Run("finds every added password", func(t *testing.T) {
+		filter, err := breachedpasswords.NewFilter(3, 0.001)
+		assert.NoError(t, err)
+
+		err = breachedpasswords.ForEachHash(strings.NewReader(hashList("hunter2", "letmein", "p@ssw0rd")), 1, filter.Add)
+		assert.NoError(t, err)
+
+		assert.True(t, filter.IsBreached("hunter2"))
+		assert.True(t, filter.IsBreached("letmein"))
+		assert.True(t, filter.IsBreached("p@ssw0rd"))
+		assert.False(t, filter.IsBreached("violet-Harbor-92"))
+		assert.EqualValues(t, 3, filter.Entries())
+	})
+
+	t.Run("stays near the false positive rate it was sized for", func(t *testing.T) {
+		filter, _ := breachedpasswords.NewFilter(10000, 0.01)
+		for i := 0; i < 10000; i++ {
+			filter.Add(sha1.Sum([]byte(fmt.Sprintf("breached-%d", i)))) // nolint:gosec
+		}
+
+		falsePositives := 0
+		for i := 0; i < 10000; i++ {
+			if filter.IsBreached(fmt.Sprintf("clean-%d", i)) {
+				falsePositives++
+			}
+		}
+
+		assert.InDelta(t, 0.01, filter.FalsePositiveRate(), 0.002)
+		assert.Less(t, falsePositives, 200)
+	})
+
+	t.Run("rejects an impossible false positive rate", func(t *testing.T) {
+		_, err := breachedpasswords.NewFilter(10, 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestOpen(t *testing.T) {
+	t.Run("maps a written filter", func(t *testing.T) {
+		filter, _ := breachedpasswords.NewFilter(2, 0.001)
+		_ = breachedpasswords.ForEachHash(strings.NewReader(hashList("hunter2", "letmein")), 1, filter.Add)
+
+		path := filepath.Join(t.TempDir(), "breached.bpf")
+		file, err := os.Create(path)
+		assert.NoError(t, err)
+		_, err = filter.WriteTo(file)
+		assert.NoError(t, err)
+		assert.NoError(t, file.Close())
+
+		opened, err := breachedpasswords.Open(path)
+		assert.NoError(t, err)
+		defer opened.Close()
+
+		assert.True(t, opened.IsBreached("hunter2"))
+		assert.False(t, opened.IsBreached("violet-Harbor-92"))
+		assert.EqualValues(t, 2, opened.Entries())
+		assert.Equal(t, filter.FalsePositiveRate(), opened.FalsePositiveRate())
+	})
+
+	t.Run("rejects other files", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "passwords.txt")
+		assert.NoError(t, os.WriteFile(path, []byte(hashList("hunter2")), 0o600))
+
+		_, err := breachedpasswords.Open(path)
+		assert.ErrorIs(t, err, breachedpasswords.ErrInvalidFile)
+	})
+}
+
+func TestForEachHash(t *testing.T) {
+	t.Run("skips hashes seen less often than the minimum", func(t *testing.T) {
+		var count int
+		err := breachedpasswords.ForEachHash(strings.NewReader(hashList("a", "b", "c")), 2, func([sha1.Size]byte) { count++ })
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("accepts lowercase hashes without counts", func(t *testing.T) {
+		var count int
+		list := fmt.Sprintf("%x\n\n", sha1.Sum([]byte("hunter2"))) // nolint:gosec
+		err := breachedpasswords.ForEachHash(strings.NewReader(list), 2, func([sha1.Size]byte) { count++ })
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("reports malformed lines", func(t *testing.T) {
+		err := breachedpasswords.ForEachHash(strings.NewReader("not-a-hash:3"), 1, func([sha1.Size]byte) {})
+		assert.EqualError(t, err, `line 1: invalid SHA-1 hash "not-a-hash"`)
+	})
+}
@@ -0,0 +1,52 @@
+package breachedpasswords
+
+import (
+	"bufio"
+	"crypto/sha1" // nolint:gosec
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ForEachHash streams a HIBP style list, one upper or lowercase hex SHA-1 per line optionally followed by
+// ":<count>", and calls fn for every hash seen at least minCount times. Lines without a count always match.
+func ForEachHash(r io.Reader, minCount uint64, fn func(hash [sha1.Size]byte)) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		hexHash, countText, hasCount := strings.Cut(text, ":")
+
+		if hasCount && minCount > 1 {
+			count, err := strconv.ParseUint(countText, 10, 64)
+			if err != nil {
+				return fmt.Errorf("line %d: invalid count %q", line, countText)
+			}
+
+			if count < minCount {
+				continue
+			}
+		}
+
+		var hash [sha1.Size]byte
+
+		decoded, err := hex.DecodeString(hexHash)
+		if err != nil || len(decoded) != sha1.Size {
+			return fmt.Errorf("line %d: invalid SHA-1 hash %q", line, hexHash)
+		}
+
+		copy(hash[:], decoded)
+		fn(hash)
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package breachedpasswords
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile falls back to reading the whole file where mmap is not available.
+func mapFile(file *os.File, size int) ([]byte, func() error, error) {
+	data := make([]byte, size)
+
+	_, err := io.ReadFull(file, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, func() error { return nil }, nil
+}
@@ -0,0 +1,39 @@
+package breachedpasswords
+
+import (
+	"fmt"
+	"os"
+)
+
+// Open memory maps a filter file, so the operating system pages the bit array in as it is queried.
+func Open(path string) (*Filter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Size() < int64(headerSize) {
+		return nil, ErrInvalidFile
+	}
+
+	data, unmapData, err := mapFile(file, int(info.Size()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to map %s: %w", path, err)
+	}
+
+	filter, err := Decode(data)
+	if err != nil {
+		_ = unmapData()
+		return nil, err
+	}
+
+	filter.unmapData = unmapData
+
+	return filter, nil
+}
@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/janitor"
+	janitorRepositories "github.com/weeb-vip/auth/internal/janitor/repositories"
+)
+
+func configureCleanupCommand(dbCommand *cobra.Command) {
+	cleanupCmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "remove expired tokens, orphaned sessions and stale rows",
+		Long: "Runs the janitor the server runs on a schedule once: expired refresh tokens and denylist entries, " +
+			"sessions left without refresh tokens, old password resets and credentials never activated.",
+		RunE: cleanupDB,
+	}
+	cleanupCmd.Flags().Bool("dry-run", false, "only count the rows that would be removed")
+	cleanupCmd.Flags().Int("batch-size", 0, "rows removed per statement, defaults to the janitor config")
+
+	dbCommand.AddCommand(cleanupCmd)
+}
+
+func cleanupDB(cmd *cobra.Command, _ []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+	if batchSize <= 0 {
+		batchSize = cfg.JanitorConfig.BatchSize
+	}
+
+	results, err := janitor.New(janitorRepositories.NewJanitorRepository(cfg.JanitorConfig), batchSize).Run(context.Background(), dryRun)
+
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+
+	for _, result := range results {
+		cmd.Printf("%s %d rows: %s\n", verb, result.Rows, result.Task)
+	}
+
+	return err
+}
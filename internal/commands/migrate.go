@@ -24,6 +24,7 @@ func configureMigrateCommand(rootCmd *cobra.Command) {
 		RunE:  migrateDB,
 	}
 	dbCommand.AddCommand(migrateCmd)
+	configureCleanupCommand(dbCommand)
 
 	rootCmd.AddCommand(dbCommand)
 }
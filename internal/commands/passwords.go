@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"crypto/sha1" // nolint:gosec
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/weeb-vip/auth/internal/breachedpasswords"
+)
+
+func configurePasswordsCommand(rootCmd *cobra.Command) {
+	passwordsCommand := &cobra.Command{
+		Use:   "passwords",
+		Short: "manage password datasets",
+	}
+
+	ingestCmd := &cobra.Command{
+		Use:   "ingest",
+		Short: "build the breached password filter from a SHA-1 hash list",
+		Long: "Reads a HIBP style list of SHA-1 hashes, one \"HASH:COUNT\" per line, and writes a bloom filter " +
+			"to be set as the password policy breached passwords file. The list is read twice, first to size the filter.",
+		RunE: ingestPasswords,
+	}
+	ingestCmd.Flags().String("input", "", "SHA-1 hash list to read")
+	ingestCmd.Flags().String("output", "", "filter file to write")
+	ingestCmd.Flags().Float64("false-positive-rate", 0.001, "target false positive rate")
+	ingestCmd.Flags().Uint64("min-count", 1, "skip hashes seen fewer times than this")
+	_ = ingestCmd.MarkFlagRequired("input")
+	_ = ingestCmd.MarkFlagRequired("output")
+
+	passwordsCommand.AddCommand(ingestCmd)
+	rootCmd.AddCommand(passwordsCommand)
+}
+
+func ingestPasswords(cmd *cobra.Command, _ []string) error {
+	input, _ := cmd.Flags().GetString("input")
+	output, _ := cmd.Flags().GetString("output")
+	falsePositiveRate, _ := cmd.Flags().GetFloat64("false-positive-rate")
+	minCount, _ := cmd.Flags().GetUint64("min-count")
+
+	var expected uint64
+
+	err := forEachHashInFile(input, minCount, func([sha1.Size]byte) { expected++ })
+	if err != nil {
+		return err
+	}
+
+	filter, err := breachedpasswords.NewFilter(expected, falsePositiveRate)
+	if err != nil {
+		return err
+	}
+
+	err = forEachHashInFile(input, minCount, filter.Add)
+	if err != nil {
+		return err
+	}
+
+	// Written next to the output and renamed, so a running server never maps a half written filter.
+	temporary := output + ".tmp"
+
+	file, err := os.Create(temporary)
+	if err != nil {
+		return err
+	}
+
+	size, err := filter.WriteTo(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(temporary)
+		return err
+	}
+
+	err = os.Rename(temporary, output)
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf(
+		"Wrote %d hashes to %s (%d bytes, estimated false positive rate %.6f)\n",
+		filter.Entries(), output, size, filter.FalsePositiveRate(),
+	)
+
+	return nil
+}
+
+func forEachHashInFile(path string, minCount uint64, fn func(hash [sha1.Size]byte)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return breachedpasswords.ForEachHash(file, minCount, fn)
+}
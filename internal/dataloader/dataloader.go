@@ -0,0 +1,105 @@
+package dataloader
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FetchFunc loads the values of a batch of keys. Keys missing from the returned map load the zero value.
+type FetchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type batch[K comparable, V any] struct {
+	keys    []K
+	results []*result[V]
+}
+
+// Loader collects the keys loaded within wait of the first one into a single call of fetch, so resolving
+// a field on many entities costs one query instead of one per entity. A batch is fetched with the context of
+// its first load, and early once it holds maxBatch keys. Results, errors included, are kept for the lifetime
+// of the loader, which is meant to be one request.
+type Loader[K comparable, V any] struct {
+	fetch    FetchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+	mu       sync.Mutex
+	cache    map[K]*result[V]
+	batch    *batch[K, V]
+}
+
+func NewLoader[K comparable, V any](fetch FetchFunc[K, V], wait time.Duration, maxBatch int) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:    fetch,
+		wait:     wait,
+		maxBatch: maxBatch,
+		cache:    make(map[K]*result[V]),
+	}
+}
+
+// Load returns the value of key, waiting for the batch it joined to be fetched.
+func (loader *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	loader.mu.Lock()
+
+	res, found := loader.cache[key]
+	if !found {
+		res = &result[V]{done: make(chan struct{})}
+		loader.cache[key] = res
+		loader.enqueue(ctx, key, res)
+	}
+
+	loader.mu.Unlock()
+
+	select {
+	case <-res.done:
+		return res.value, res.err
+	case <-ctx.Done():
+		var zero V
+
+		return zero, ctx.Err()
+	}
+}
+
+// enqueue adds the key to the pending batch, starting one when there is none. It must hold mu.
+func (loader *Loader[K, V]) enqueue(ctx context.Context, key K, res *result[V]) {
+	if loader.batch == nil {
+		pending := &batch[K, V]{}
+		loader.batch = pending
+
+		time.AfterFunc(loader.wait, func() {
+			loader.dispatch(ctx, pending)
+		})
+	}
+
+	loader.batch.keys = append(loader.batch.keys, key)
+	loader.batch.results = append(loader.batch.results, res)
+
+	if loader.maxBatch > 0 && len(loader.batch.keys) >= loader.maxBatch {
+		go loader.dispatch(ctx, loader.batch)
+	}
+}
+
+// dispatch fetches the batch unless it already was, the timer and a full batch can both trigger it.
+func (loader *Loader[K, V]) dispatch(ctx context.Context, pending *batch[K, V]) {
+	loader.mu.Lock()
+	if loader.batch != pending {
+		loader.mu.Unlock()
+
+		return
+	}
+	loader.batch = nil
+	loader.mu.Unlock()
+
+	values, err := loader.fetch(ctx, pending.keys)
+
+	for i, key := range pending.keys {
+		res := pending.results[i]
+		res.value, res.err = values[key], err
+		close(res.done)
+	}
+}
@@ -0,0 +1,122 @@
+package dataloader_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/internal/dataloader"
+)
+
+type recordingFetcher struct {
+	mu      sync.Mutex
+	batches [][]string
+	err     error
+}
+
+func (f *recordingFetcher) fetch(ctx context.Context, keys []string) (map[string]int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	batch := append([]string{}, keys...)
+	sort.Strings(batch)
+	f.batches = append(f.batches, batch)
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	values := make(map[string]int, len(keys))
+	for _, key := range keys {
+		if key != "missing" {
+			values[key] = len(key)
+		}
+	}
+
+	return values, nil
+}
+
+func loadAll(loader *dataloader.Loader[string, int], keys ...string) ([]int, []error) {
+	values := make([]int, len(keys))
+	errs := make([]error, len(keys))
+
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func(i int, key string) {
+			defer wg.Done()
+			values[i], errs[i] = loader.Load(context.Background(), key)
+		}(i, key)
+	}
+	wg.Wait()
+
+	return values, errs
+}
+
+func TestLoader(t *testing.T) {
+	t.Run("loads keys requested together in one fetch", func(t *testing.T) {
+		fetcher := &recordingFetcher{}
+		loader := dataloader.NewLoader(fetcher.fetch, 50*time.Millisecond, 100)
+
+		values, errs := loadAll(loader, "a", "bb", "ccc", "bb")
+
+		assert.Equal(t, []int{1, 2, 3, 2}, values)
+		assert.Equal(t, []error{nil, nil, nil, nil}, errs)
+		assert.Equal(t, [][]string{{"a", "bb", "ccc"}}, fetcher.batches)
+	})
+
+	t.Run("loads the zero value for keys the fetch did not return", func(t *testing.T) {
+		loader := dataloader.NewLoader((&recordingFetcher{}).fetch, time.Millisecond, 100)
+
+		value, err := loader.Load(context.Background(), "missing")
+		assert.NoError(t, err)
+		assert.Zero(t, value)
+	})
+
+	t.Run("does not fetch a key twice", func(t *testing.T) {
+		fetcher := &recordingFetcher{}
+		loader := dataloader.NewLoader(fetcher.fetch, time.Millisecond, 100)
+
+		loadAll(loader, "a", "bb")
+		values, _ := loadAll(loader, "bb", "ccc")
+
+		assert.Equal(t, []int{2, 3}, values)
+		assert.Equal(t, [][]string{{"a", "bb"}, {"ccc"}}, fetcher.batches)
+	})
+
+	t.Run("fetches a full batch without waiting", func(t *testing.T) {
+		fetcher := &recordingFetcher{}
+		loader := dataloader.NewLoader(fetcher.fetch, time.Hour, 2)
+
+		values, _ := loadAll(loader, "a", "bb")
+
+		assert.Equal(t, []int{1, 2}, values)
+		assert.Len(t, fetcher.batches, 1)
+	})
+
+	t.Run("fails every load of a failed batch", func(t *testing.T) {
+		fetcher := &recordingFetcher{err: errors.New("connection refused")}
+		loader := dataloader.NewLoader(fetcher.fetch, 50*time.Millisecond, 100)
+
+		_, errs := loadAll(loader, "a", "bb")
+
+		assert.EqualError(t, errs[0], "connection refused")
+		assert.EqualError(t, errs[1], "connection refused")
+		assert.Len(t, fetcher.batches, 1)
+	})
+
+	t.Run("stops waiting when the context is done", func(t *testing.T) {
+		loader := dataloader.NewLoader((&recordingFetcher{}).fetch, time.Hour, 100)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := loader.Load(ctx, "a")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
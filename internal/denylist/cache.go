@@ -0,0 +1,156 @@
+package denylist
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	tokenID   string
+	subject   string
+	sessionID string
+	denied    bool
+	until     time.Time
+}
+
+// CachedDenylist answers from a least recently used cache of size tokens before asking backend. A revoked
+// token stays revoked, so that answer is kept until the token expires, but a token found not revoked is
+// only trusted for ttl: that is how long a revocation on another instance can take to be seen here.
+type CachedDenylist struct {
+	backend Denylist
+	size    int
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	now     func() time.Time
+}
+
+func NewCachedDenylist(backend Denylist, size int, ttl time.Duration) *CachedDenylist {
+	return &CachedDenylist{
+		backend: backend,
+		size:    max(size, 1),
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+func (d *CachedDenylist) Deny(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	if err := d.backend.Deny(ctx, tokenID, expiresAt); err != nil {
+		return err
+	}
+
+	if tokenID != "" {
+		d.put(cacheEntry{tokenID: tokenID, denied: true, until: expiresAt})
+	}
+
+	return nil
+}
+
+func (d *CachedDenylist) DenySubject(ctx context.Context, subject string, issuedBefore time.Time, expiresAt time.Time) error {
+	if err := d.backend.DenySubject(ctx, subject, issuedBefore, expiresAt); err != nil {
+		return err
+	}
+
+	d.forget(func(entry cacheEntry) bool {
+		return entry.subject == subject
+	})
+
+	return nil
+}
+
+func (d *CachedDenylist) DenySession(ctx context.Context, sessionID string, expiresAt time.Time) error {
+	if err := d.backend.DenySession(ctx, sessionID, expiresAt); err != nil {
+		return err
+	}
+
+	d.forget(func(entry cacheEntry) bool {
+		return sessionID != "" && entry.sessionID == sessionID
+	})
+
+	return nil
+}
+
+func (d *CachedDenylist) IsDenied(ctx context.Context, token Token) (bool, error) {
+	// Tokens without an ID cannot be cached, there are few of them left once they have all expired.
+	if token.ID == "" {
+		return d.backend.IsDenied(ctx, token)
+	}
+
+	if entry, found := d.get(token.ID); found {
+		return entry.denied, nil
+	}
+
+	denied, err := d.backend.IsDenied(ctx, token)
+	if err != nil {
+		return false, err
+	}
+
+	until := d.now().Add(d.ttl)
+	if denied {
+		until = token.ExpiresAt
+	}
+	d.put(cacheEntry{tokenID: token.ID, subject: token.Subject, sessionID: token.SessionID, denied: denied, until: until})
+
+	return denied, nil
+}
+
+// forget drops the cached answers for the tokens matches selects that were found not revoked, once they are.
+func (d *CachedDenylist) forget(matches func(entry cacheEntry) bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for element := d.order.Front(); element != nil; {
+		next := element.Next()
+		if entry := element.Value.(cacheEntry); matches(entry) && !entry.denied { // nolint
+			d.order.Remove(element)
+			delete(d.entries, entry.tokenID)
+		}
+		element = next
+	}
+}
+
+func (d *CachedDenylist) get(tokenID string) (cacheEntry, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	element, found := d.entries[tokenID]
+	if !found {
+		return cacheEntry{}, false
+	}
+
+	entry := element.Value.(cacheEntry) // nolint
+	if !d.now().Before(entry.until) {
+		d.order.Remove(element)
+		delete(d.entries, tokenID)
+
+		return cacheEntry{}, false
+	}
+
+	d.order.MoveToFront(element)
+
+	return entry, true
+}
+
+func (d *CachedDenylist) put(entry cacheEntry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if element, found := d.entries[entry.tokenID]; found {
+		element.Value = entry
+		d.order.MoveToFront(element)
+
+		return
+	}
+
+	d.entries[entry.tokenID] = d.order.PushFront(entry)
+	for d.order.Len() > d.size {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.entries, oldest.Value.(cacheEntry).tokenID) // nolint
+	}
+}
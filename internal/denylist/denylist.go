@@ -0,0 +1,57 @@
+package denylist
+
+import (
+	"context"
+	"time"
+
+	"github.com/weeb-vip/auth/internal/jwt"
+)
+
+// Token is what the denylist needs to know about an access token to tell whether it was revoked.
+type Token struct {
+	ID        string // the jti claim, empty for tokens issued before tokens had one.
+	Subject   string
+	SessionID string // the sid claim, empty for tokens issued before tokens named their session.
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// Denylist holds revoked access tokens until they expire on their own, after which a token is rejected
+// anyway and its entry can be dropped.
+type Denylist interface {
+	// Deny revokes the token with the given ID.
+	Deny(ctx context.Context, tokenID string, expiresAt time.Time) error
+	// DenySubject revokes every token of subject issued before issuedBefore. expiresAt is when the last of
+	// them expires.
+	DenySubject(ctx context.Context, subject string, issuedBefore time.Time, expiresAt time.Time) error
+	// DenySession revokes every token issued for the session. expiresAt is when the last of them expires.
+	DenySession(ctx context.Context, sessionID string, expiresAt time.Time) error
+	IsDenied(ctx context.Context, token Token) (bool, error)
+}
+
+func FromClaims(claims *jwt.Claims) Token {
+	var token Token
+	if claims.ID != nil {
+		token.ID = *claims.ID
+	}
+	if claims.Subject != nil {
+		token.Subject = *claims.Subject
+	}
+	if claims.SessionID != nil {
+		token.SessionID = *claims.SessionID
+	}
+	if claims.IssuedAt != nil {
+		token.IssuedAt = *claims.IssuedAt
+	}
+	if claims.ExpiresAt != nil {
+		token.ExpiresAt = *claims.ExpiresAt
+	}
+
+	return token
+}
+
+// issuedBefore tells whether a token issued at issuedAt is older than cutoff. iat only has a resolution of
+// seconds, so a token issued within the same second as the cutoff is treated as issued after it.
+func issuedBefore(issuedAt time.Time, cutoff time.Time) bool {
+	return issuedAt.Unix() < cutoff.Unix()
+}
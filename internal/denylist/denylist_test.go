@@ -0,0 +1,163 @@
+package denylist
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryDenylist(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	denylist := NewMemoryDenylist()
+	denylist.now = func() time.Time { return now }
+
+	t.Run("denies a token until it expires", func(t *testing.T) {
+		assert.NoError(t, denylist.Deny(ctx, "revoked", now.Add(time.Minute)))
+		assert.NoError(t, denylist.Deny(ctx, "expired", now.Add(-time.Minute)))
+
+		denied, err := denylist.IsDenied(ctx, Token{ID: "revoked"})
+		assert.NoError(t, err)
+		assert.True(t, denied)
+
+		denied, err = denylist.IsDenied(ctx, Token{ID: "expired"})
+		assert.NoError(t, err)
+		assert.False(t, denied, "a token past its expiry is rejected anyway")
+
+		denied, err = denylist.IsDenied(ctx, Token{ID: "active"})
+		assert.NoError(t, err)
+		assert.False(t, denied)
+	})
+
+	t.Run("denies the tokens of a subject issued before the cutoff", func(t *testing.T) {
+		assert.NoError(t, denylist.DenySubject(ctx, "user_123", now, now.Add(time.Minute)))
+
+		denied, err := denylist.IsDenied(ctx, Token{ID: "older", Subject: "user_123", IssuedAt: now.Add(-time.Minute)})
+		assert.NoError(t, err)
+		assert.True(t, denied)
+
+		denied, err = denylist.IsDenied(ctx, Token{ID: "newer", Subject: "user_123", IssuedAt: now})
+		assert.NoError(t, err)
+		assert.False(t, denied)
+
+		denied, err = denylist.IsDenied(ctx, Token{ID: "other", Subject: "user_456", IssuedAt: now.Add(-time.Minute)})
+		assert.NoError(t, err)
+		assert.False(t, denied)
+	})
+
+	t.Run("denies the tokens of a session", func(t *testing.T) {
+		assert.NoError(t, denylist.DenySession(ctx, "session_123", now.Add(time.Minute)))
+
+		denied, err := denylist.IsDenied(ctx, Token{ID: "evicted", Subject: "user_456", SessionID: "session_123", IssuedAt: now})
+		assert.NoError(t, err)
+		assert.True(t, denied)
+
+		denied, err = denylist.IsDenied(ctx, Token{ID: "kept", Subject: "user_456", SessionID: "session_456", IssuedAt: now})
+		assert.NoError(t, err)
+		assert.False(t, denied)
+	})
+
+	t.Run("drops entries once they expire", func(t *testing.T) {
+		now = now.Add(2 * time.Minute)
+
+		denied, err := denylist.IsDenied(ctx, Token{ID: "revoked"})
+		assert.NoError(t, err)
+		assert.False(t, denied)
+
+		assert.NoError(t, denylist.Deny(ctx, "other", now.Add(time.Minute)))
+		assert.Len(t, denylist.tokens, 1)
+		assert.Empty(t, denylist.subjects)
+		assert.Empty(t, denylist.sessions)
+	})
+}
+
+// countingDenylist counts the lookups that get past the cache.
+type countingDenylist struct {
+	*MemoryDenylist
+	lookups int
+}
+
+func (d *countingDenylist) IsDenied(ctx context.Context, token Token) (bool, error) {
+	d.lookups++
+
+	return d.MemoryDenylist.IsDenied(ctx, token)
+}
+
+func TestCachedDenylist(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	token := Token{ID: "token_1", Subject: "user_123", IssuedAt: now.Add(-time.Minute), ExpiresAt: now.Add(time.Hour)}
+
+	newCachedDenylist := func(size int) (*CachedDenylist, *countingDenylist) {
+		backend := &countingDenylist{MemoryDenylist: NewMemoryDenylist()}
+		cached := NewCachedDenylist(backend, size, 5*time.Second)
+		cached.now = func() time.Time { return now }
+
+		return cached, backend
+	}
+
+	t.Run("trusts a token found not revoked for the cache ttl", func(t *testing.T) {
+		cached, backend := newCachedDenylist(10)
+
+		for range 3 {
+			denied, err := cached.IsDenied(ctx, token)
+			assert.NoError(t, err)
+			assert.False(t, denied)
+		}
+		assert.Equal(t, 1, backend.lookups)
+
+		// Revoked on another instance, which this one only sees once the cached answer is stale.
+		assert.NoError(t, backend.Deny(ctx, token.ID, token.ExpiresAt))
+		denied, _ := cached.IsDenied(ctx, token)
+		assert.False(t, denied)
+
+		now = now.Add(6 * time.Second)
+		denied, _ = cached.IsDenied(ctx, token)
+		assert.True(t, denied)
+		assert.Equal(t, 2, backend.lookups)
+	})
+
+	t.Run("sees its own revocations at once", func(t *testing.T) {
+		cached, backend := newCachedDenylist(10)
+
+		denied, _ := cached.IsDenied(ctx, token)
+		assert.False(t, denied)
+
+		assert.NoError(t, cached.Deny(ctx, token.ID, token.ExpiresAt))
+		denied, _ = cached.IsDenied(ctx, token)
+		assert.True(t, denied)
+
+		other := Token{ID: "token_2", Subject: "user_123", IssuedAt: token.IssuedAt, ExpiresAt: token.ExpiresAt}
+		denied, _ = cached.IsDenied(ctx, other)
+		assert.False(t, denied)
+
+		assert.NoError(t, cached.DenySubject(ctx, "user_123", now, now.Add(time.Hour)))
+		denied, _ = cached.IsDenied(ctx, other)
+		assert.True(t, denied)
+		assert.Equal(t, 3, backend.lookups, "the cached answer for the subject was dropped")
+
+		session := Token{ID: "token_3", Subject: "user_456", SessionID: "session_456", IssuedAt: now, ExpiresAt: token.ExpiresAt}
+		denied, _ = cached.IsDenied(ctx, session)
+		assert.False(t, denied)
+
+		assert.NoError(t, cached.DenySession(ctx, "session_456", now.Add(time.Hour)))
+		denied, _ = cached.IsDenied(ctx, session)
+		assert.True(t, denied)
+		assert.Equal(t, 5, backend.lookups, "the cached answer for the session was dropped")
+	})
+
+	t.Run("evicts the least recently used tokens", func(t *testing.T) {
+		cached, backend := newCachedDenylist(2)
+
+		for _, id := range []string{"token_1", "token_2", "token_1", "token_3", "token_1", "token_2"} {
+			_, err := cached.IsDenied(ctx, Token{ID: id, ExpiresAt: now.Add(time.Hour)})
+			assert.NoError(t, err)
+		}
+
+		assert.Equal(t, 4, backend.lookups, "token_2 was evicted by token_3")
+		assert.Len(t, cached.entries, 2)
+	})
+}
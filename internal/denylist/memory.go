@@ -0,0 +1,112 @@
+package denylist
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type subjectEntry struct {
+	issuedBefore time.Time
+	expiresAt    time.Time
+}
+
+// MemoryDenylist keeps revoked tokens in process, so a token revoked on one instance is only rejected by it.
+type MemoryDenylist struct {
+	mu       sync.Mutex
+	tokens   map[string]time.Time
+	subjects map[string]subjectEntry
+	sessions map[string]time.Time
+	now      func() time.Time
+}
+
+func NewMemoryDenylist() *MemoryDenylist {
+	return &MemoryDenylist{
+		tokens:   make(map[string]time.Time),
+		subjects: make(map[string]subjectEntry),
+		sessions: make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+func (d *MemoryDenylist) Deny(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	d.sweep(now)
+
+	if tokenID != "" && now.Before(expiresAt) {
+		d.tokens[tokenID] = expiresAt
+	}
+
+	return nil
+}
+
+func (d *MemoryDenylist) DenySubject(ctx context.Context, subject string, issuedBefore time.Time, expiresAt time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	d.sweep(now)
+
+	if now.Before(expiresAt) {
+		d.subjects[subject] = subjectEntry{issuedBefore: issuedBefore, expiresAt: expiresAt}
+	}
+
+	return nil
+}
+
+func (d *MemoryDenylist) DenySession(ctx context.Context, sessionID string, expiresAt time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	d.sweep(now)
+
+	if sessionID != "" && now.Before(expiresAt) {
+		d.sessions[sessionID] = expiresAt
+	}
+
+	return nil
+}
+
+func (d *MemoryDenylist) IsDenied(ctx context.Context, token Token) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+
+	if expiresAt, found := d.tokens[token.ID]; found && token.ID != "" && now.Before(expiresAt) {
+		return true, nil
+	}
+
+	if expiresAt, found := d.sessions[token.SessionID]; found && token.SessionID != "" && now.Before(expiresAt) {
+		return true, nil
+	}
+
+	entry, found := d.subjects[token.Subject]
+
+	return found && now.Before(entry.expiresAt) && issuedBefore(token.IssuedAt, entry.issuedBefore), nil
+}
+
+// sweep drops expired entries, revocations are rare enough to do it on each of them.
+func (d *MemoryDenylist) sweep(now time.Time) {
+	for id, expiresAt := range d.tokens {
+		if !now.Before(expiresAt) {
+			delete(d.tokens, id)
+		}
+	}
+
+	for subject, entry := range d.subjects {
+		if !now.Before(entry.expiresAt) {
+			delete(d.subjects, subject)
+		}
+	}
+
+	for sessionID, expiresAt := range d.sessions {
+		if !now.Before(expiresAt) {
+			delete(d.sessions, sessionID)
+		}
+	}
+}
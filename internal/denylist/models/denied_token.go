@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weeb-vip/auth/internal/db"
+)
+
+// DeniedToken is a revoked access token, the ID is the jti of the token.
+type DeniedToken struct {
+	db.BaseModel
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// DeniedSession revokes every access token issued for a session, the ID is the session ID.
+type DeniedSession struct {
+	db.BaseModel
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// DeniedSubject revokes every access token of a user issued before IssuedBefore, the ID is the user ID.
+type DeniedSubject struct {
+	db.BaseModel
+	IssuedBefore time.Time `json:"issuedBefore"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/weeb-vip/auth/internal/db"
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/denylist/models"
+)
+
+type deniedTokensRepository struct {
+	DBService db.DB
+}
+
+// NewDeniedTokensRepository keeps the denylist in the database, so a token revoked on one replica is
+// rejected by all of them.
+func NewDeniedTokensRepository() denylist.Denylist {
+	dbService := db.GetDBService()
+
+	return &deniedTokensRepository{
+		DBService: dbService,
+	}
+}
+
+func (repository *deniedTokensRepository) Deny(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	if tokenID == "" {
+		return nil
+	}
+
+	database := repository.DBService.GetDB()
+
+	deniedToken := &models.DeniedToken{ExpiresAt: expiresAt}
+	deniedToken.ID = tokenID
+
+	return database.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(deniedToken).Error
+}
+
+func (repository *deniedTokensRepository) DenySubject(
+	ctx context.Context,
+	subject string,
+	issuedBefore time.Time,
+	expiresAt time.Time,
+) error {
+	database := repository.DBService.GetDB()
+
+	// MySQL rounds fractional seconds, which could push the cutoff past tokens issued within the same second.
+	deniedSubject := &models.DeniedSubject{IssuedBefore: issuedBefore.Truncate(time.Second), ExpiresAt: expiresAt}
+	deniedSubject.ID = subject
+
+	return database.WithContext(ctx).
+		Clauses(clause.OnConflict{DoUpdates: clause.AssignmentColumns([]string{"issued_before", "expires_at", "updated_at"})}).
+		Create(deniedSubject).Error
+}
+
+func (repository *deniedTokensRepository) DenySession(ctx context.Context, sessionID string, expiresAt time.Time) error {
+	if sessionID == "" {
+		return nil
+	}
+
+	database := repository.DBService.GetDB()
+
+	deniedSession := &models.DeniedSession{ExpiresAt: expiresAt}
+	deniedSession.ID = sessionID
+
+	return database.WithContext(ctx).
+		Clauses(clause.OnConflict{DoUpdates: clause.AssignmentColumns([]string{"expires_at", "updated_at"})}).
+		Create(deniedSession).Error
+}
+
+func (repository *deniedTokensRepository) IsDenied(ctx context.Context, token denylist.Token) (bool, error) {
+	database := repository.DBService.GetDB().WithContext(ctx)
+	now := time.Now()
+
+	if token.ID != "" {
+		var deniedToken models.DeniedToken
+
+		err := database.Where("id = ? AND expires_at > ?", token.ID, now).First(&deniedToken).Error
+		if err == nil {
+			return true, nil
+		}
+
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, err
+		}
+	}
+
+	if token.SessionID != "" {
+		var deniedSession models.DeniedSession
+
+		err := database.Where("id = ? AND expires_at > ?", token.SessionID, now).First(&deniedSession).Error
+		if err == nil {
+			return true, nil
+		}
+
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, err
+		}
+	}
+
+	var deniedSubject models.DeniedSubject
+
+	// iat only has a resolution of seconds, so compare against the start of the second of the cutoff.
+	err := database.
+		Where("id = ? AND issued_before > ? AND expires_at > ?", token.Subject, token.IssuedAt.Truncate(time.Second), now).
+		First(&deniedSubject).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
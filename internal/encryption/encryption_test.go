@@ -0,0 +1,48 @@
+package encryption_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/internal/encryption"
+)
+
+func TestCipher(t *testing.T) {
+	t.Run("round trips a secret", func(t *testing.T) {
+		c, err := encryption.New("master-key")
+		assert.NoError(t, err)
+
+		ciphertext, err := c.Encrypt("my secret")
+		assert.NoError(t, err)
+		assert.NotContains(t, ciphertext, "my secret")
+
+		plaintext, err := c.Decrypt(ciphertext)
+		assert.NoError(t, err)
+		assert.Equal(t, "my secret", plaintext)
+	})
+
+	t.Run("uses a fresh nonce for every encryption", func(t *testing.T) {
+		c, _ := encryption.New("master-key")
+		first, _ := c.Encrypt("my secret")
+		second, _ := c.Encrypt("my secret")
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("fails to decrypt with a different key", func(t *testing.T) {
+		c, _ := encryption.New("master-key")
+		other, _ := encryption.New("other-key")
+
+		ciphertext, _ := c.Encrypt("my secret")
+		_, err := other.Decrypt(ciphertext)
+		assert.ErrorIs(t, err, encryption.ErrInvalidCiphertext)
+	})
+
+	t.Run("fails to decrypt garbage", func(t *testing.T) {
+		c, _ := encryption.New("master-key")
+		_, err := c.Decrypt("not-base64!")
+		assert.ErrorIs(t, err, encryption.ErrInvalidCiphertext)
+		_, err = c.Decrypt("YQ==")
+		assert.ErrorIs(t, err, encryption.ErrInvalidCiphertext)
+	})
+}
@@ -0,0 +1,164 @@
+package janitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/weeb-vip/auth/internal/logger"
+	"github.com/weeb-vip/auth/internal/metrics"
+)
+
+// The tasks of the janitor, named after the rows they remove.
+const (
+	ExpiredRefreshTokens        = "expired_refresh_tokens"
+	OrphanedSessions            = "orphaned_sessions"
+	StalePasswordResets         = "stale_password_resets"
+	InactiveCredentials         = "inactive_credentials"
+	OrphanedTOTPSecrets         = "orphaned_totp_secrets"
+	OrphanedRecoveryCodes       = "orphaned_recovery_codes"
+	OrphanedWebAuthnCredentials = "orphaned_webauthn_credentials"
+	ExpiredWebAuthnCeremonies   = "expired_webauthn_ceremonies"
+	StaleLoginFailures          = "stale_login_failures"
+	ExpiredDeniedTokens         = "expired_denied_tokens"
+	ExpiredDeniedSubjects       = "expired_denied_subjects"
+	ExpiredDeniedSessions       = "expired_denied_sessions"
+)
+
+// Store finds the rows of each task that are stale at now. Tasks lists the tasks it runs, in order.
+type Store interface {
+	Tasks() []string
+	Count(ctx context.Context, task string, now time.Time) (int64, error)
+	// Delete removes up to limit rows of the task and returns how many it removed.
+	Delete(ctx context.Context, task string, now time.Time, limit int) (int64, error)
+	// WithLock runs fn unless another replica holds the janitor lock, returning whether it ran.
+	WithLock(ctx context.Context, fn func() error) (bool, error)
+}
+
+// Result is the number of rows a task removed, or would remove on a dry run.
+type Result struct {
+	Task string
+	Rows int64
+}
+
+// Janitor removes expired and stale rows in batches of batchSize, so no statement holds locks on a large part
+// of a table for long.
+type Janitor struct {
+	store     Store
+	batchSize int
+	now       func() time.Time
+}
+
+func New(store Store, batchSize int) *Janitor {
+	return &Janitor{
+		store:     store,
+		batchSize: batchSize,
+		now:       time.Now,
+	}
+}
+
+// Run runs every task once, or only counts their rows with dryRun. A failing task does not stop the others,
+// their errors are returned together.
+func (janitor *Janitor) Run(ctx context.Context, dryRun bool) ([]Result, error) {
+	now := janitor.now()
+
+	var errs []error
+
+	tasks := janitor.store.Tasks()
+	results := make([]Result, 0, len(tasks))
+
+	for _, task := range tasks {
+		var rows int64
+		var err error
+
+		if dryRun {
+			rows, err = janitor.store.Count(ctx, task, now)
+		} else {
+			rows, err = janitor.delete(ctx, task, now)
+		}
+
+		results = append(results, Result{Task: task, Rows: rows})
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", task, err))
+		}
+	}
+
+	return results, errors.Join(errs...)
+}
+
+func (janitor *Janitor) delete(ctx context.Context, task string, now time.Time) (int64, error) {
+	var removed int64
+
+	for {
+		rows, err := janitor.store.Delete(ctx, task, now, janitor.batchSize)
+		if rows > 0 {
+			removed += rows
+			metrics.GetAppMetrics().JanitorRowsRemovedMetric(task, rows)
+		}
+
+		if err != nil {
+			return removed, err
+		}
+
+		if rows < int64(janitor.batchSize) {
+			return removed, nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+	}
+}
+
+// RunInBackground runs the janitor every interval on whichever replica gets the lock, until ctx is done.
+func (janitor *Janitor) RunInBackground(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				janitor.runLocked(ctx)
+			}
+		}
+	}()
+}
+
+func (janitor *Janitor) runLocked(ctx context.Context) {
+	log := logger.FromCtx(ctx)
+
+	var results []Result
+	acquired, err := janitor.store.WithLock(ctx, func() error {
+		var err error
+		results, err = janitor.Run(ctx, false)
+
+		return err
+	})
+
+	if !acquired && err == nil {
+		log.Debug().Msg("Janitor skipped, another replica holds the lock")
+
+		return
+	}
+
+	for _, result := range results {
+		log.Info().
+			Str("task", result.Task).
+			Int64("rows", result.Rows).
+			Msg("Janitor removed rows")
+	}
+
+	if err != nil {
+		metrics.GetAppMetrics().JanitorRunMetric(metrics.Error)
+		log.Error().Err(err).Msg("Janitor run failed")
+
+		return
+	}
+
+	metrics.GetAppMetrics().JanitorRunMetric(metrics.Success)
+}
@@ -0,0 +1,156 @@
+package janitor_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/internal/janitor"
+)
+
+// fakeStore holds a number of stale rows per task.
+type fakeStore struct {
+	mu        sync.Mutex
+	rows      map[string]int64
+	failing   map[string]error
+	deletes   map[string]int
+	locked    bool
+	lockCalls int
+}
+
+func newFakeStore(rows map[string]int64) *fakeStore {
+	return &fakeStore{rows: rows, failing: map[string]error{}, deletes: map[string]int{}}
+}
+
+func (s *fakeStore) Tasks() []string {
+	return []string{janitor.ExpiredRefreshTokens, janitor.OrphanedSessions, janitor.StalePasswordResets}
+}
+
+func (s *fakeStore) Count(ctx context.Context, task string, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rows[task], s.failing[task]
+}
+
+func (s *fakeStore) Delete(ctx context.Context, task string, now time.Time, limit int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failing[task] != nil {
+		return 0, s.failing[task]
+	}
+
+	s.deletes[task]++
+
+	removed := s.rows[task]
+	if removed > int64(limit) {
+		removed = int64(limit)
+	}
+	s.rows[task] -= removed
+
+	return removed, nil
+}
+
+func (s *fakeStore) WithLock(ctx context.Context, fn func() error) (bool, error) {
+	s.mu.Lock()
+	s.lockCalls++
+	locked := s.locked
+	s.mu.Unlock()
+
+	if locked {
+		return false, nil
+	}
+
+	return true, fn()
+}
+
+func (s *fakeStore) remaining(task string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rows[task]
+}
+
+func TestJanitor_Run(t *testing.T) {
+	t.Run("removes the rows of every task in batches", func(t *testing.T) {
+		store := newFakeStore(map[string]int64{
+			janitor.ExpiredRefreshTokens: 25,
+			janitor.OrphanedSessions:     10,
+		})
+
+		results, err := janitor.New(store, 10).Run(context.Background(), false)
+		assert.NoError(t, err)
+		assert.Equal(t, []janitor.Result{
+			{Task: janitor.ExpiredRefreshTokens, Rows: 25},
+			{Task: janitor.OrphanedSessions, Rows: 10},
+			{Task: janitor.StalePasswordResets, Rows: 0},
+		}, results)
+
+		assert.Equal(t, 3, store.deletes[janitor.ExpiredRefreshTokens])
+		assert.Equal(t, 2, store.deletes[janitor.OrphanedSessions], "a full batch is followed by another")
+		assert.Equal(t, 1, store.deletes[janitor.StalePasswordResets])
+		assert.Zero(t, store.remaining(janitor.ExpiredRefreshTokens))
+	})
+
+	t.Run("only counts the rows on a dry run", func(t *testing.T) {
+		store := newFakeStore(map[string]int64{janitor.ExpiredRefreshTokens: 25})
+
+		results, err := janitor.New(store, 10).Run(context.Background(), true)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(25), results[0].Rows)
+		assert.Equal(t, int64(25), store.remaining(janitor.ExpiredRefreshTokens))
+		assert.Empty(t, store.deletes)
+	})
+
+	t.Run("runs the other tasks when one fails", func(t *testing.T) {
+		store := newFakeStore(map[string]int64{
+			janitor.ExpiredRefreshTokens: 5,
+			janitor.StalePasswordResets:  5,
+		})
+		store.failing[janitor.OrphanedSessions] = errors.New("lock wait timeout exceeded")
+
+		results, err := janitor.New(store, 10).Run(context.Background(), false)
+		assert.EqualError(t, err, "orphaned_sessions: lock wait timeout exceeded")
+		assert.Len(t, results, 3)
+		assert.Zero(t, store.remaining(janitor.ExpiredRefreshTokens))
+		assert.Zero(t, store.remaining(janitor.StalePasswordResets))
+	})
+}
+
+func TestJanitor_RunInBackground(t *testing.T) {
+	t.Run("runs on the replica holding the lock", func(t *testing.T) {
+		store := newFakeStore(map[string]int64{janitor.ExpiredRefreshTokens: 5})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		janitor.New(store, 10).RunInBackground(ctx, 10*time.Millisecond)
+
+		assert.Eventually(t, func() bool {
+			return store.remaining(janitor.ExpiredRefreshTokens) == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("leaves the rows to the replica holding the lock", func(t *testing.T) {
+		store := newFakeStore(map[string]int64{janitor.ExpiredRefreshTokens: 5})
+		store.locked = true
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		janitor.New(store, 10).RunInBackground(ctx, 10*time.Millisecond)
+
+		assert.Eventually(t, func() bool {
+			store.mu.Lock()
+			defer store.mu.Unlock()
+
+			return store.lockCalls >= 2
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, int64(5), store.remaining(janitor.ExpiredRefreshTokens))
+	})
+}
@@ -0,0 +1,203 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/db"
+	"github.com/weeb-vip/auth/internal/janitor"
+)
+
+// orphanedSessionGrace leaves alone sessions young enough to still be getting their first refresh token.
+const orphanedSessionGrace = time.Hour
+
+// rule selects the stale rows of a task in one table.
+type rule struct {
+	table     string
+	condition string
+	args      func(now time.Time) []interface{}
+}
+
+type janitorRepository struct {
+	DBService db.DB
+	lockName  string
+	tasks     []string
+	rules     map[string]rule
+}
+
+// NewJanitorRepository finds stale rows with plain SQL on the tables of the other services, and elects the
+// replica that runs the janitor through a MySQL named lock that it does not wait for.
+func NewJanitorRepository(cfg config.JanitorConfig) janitor.Store {
+	dbService := db.GetDBService()
+
+	return newJanitorRepository(dbService, cfg)
+}
+
+func newJanitorRepository(dbService db.DB, cfg config.JanitorConfig) *janitorRepository {
+	repository := &janitorRepository{
+		DBService: dbService,
+		lockName:  cfg.LockName,
+		rules:     make(map[string]rule),
+	}
+
+	repository.add(janitor.ExpiredRefreshTokens, rule{
+		table:     "refresh_tokens",
+		condition: "expiry < ?",
+		args: func(now time.Time) []interface{} {
+			return []interface{}{now.Unix()}
+		},
+	})
+
+	// After the refresh tokens, a session whose last refresh token expired is orphaned on the same run.
+	repository.add(janitor.OrphanedSessions, rule{
+		table:     "sessions",
+		condition: "created_at < ? AND NOT EXISTS (SELECT 1 FROM refresh_tokens WHERE refresh_tokens.family_id = sessions.id)",
+		args: func(now time.Time) []interface{} {
+			return []interface{}{now.Add(-orphanedSessionGrace)}
+		},
+	})
+
+	if cfg.PasswordResetRetentionInHours > 0 {
+		retention := time.Hour * time.Duration(cfg.PasswordResetRetentionInHours)
+		repository.add(janitor.StalePasswordResets, rule{
+			table:     "password_resets",
+			condition: "created_at < ?",
+			args: func(now time.Time) []interface{} {
+				return []interface{}{now.Add(-retention)}
+			},
+		})
+	}
+
+	if cfg.InactiveCredentialRetentionInHours > 0 {
+		retention := time.Hour * time.Duration(cfg.InactiveCredentialRetentionInHours)
+		repository.add(janitor.InactiveCredentials, rule{
+			table:     "credentials",
+			condition: "active = FALSE AND created_at < ?",
+			args: func(now time.Time) []interface{} {
+				return []interface{}{now.Add(-retention)}
+			},
+		})
+	}
+
+	// After the inactive credentials, the second factors of users left without a credential go on the same run.
+	repository.add(janitor.OrphanedTOTPSecrets, orphanedRule("totp_secrets"))
+	repository.add(janitor.OrphanedRecoveryCodes, orphanedRule("recovery_codes"))
+	repository.add(janitor.OrphanedWebAuthnCredentials, orphanedRule("webauthn_credentials"))
+
+	expired := func(now time.Time) []interface{} {
+		return []interface{}{now}
+	}
+	repository.add(janitor.ExpiredWebAuthnCeremonies, rule{table: "webauthn_ceremonies", condition: "expires_at < ?", args: expired})
+
+	// Every failure and unlock request updates the row, so one untouched for longer than a failure window and
+	// the lock it may have caused counts nothing anymore.
+	if cfg.LoginFailureRetentionInHours > 0 {
+		retention := time.Hour * time.Duration(cfg.LoginFailureRetentionInHours)
+		repository.add(janitor.StaleLoginFailures, rule{
+			table:     "login_failures",
+			condition: "updated_at < ?",
+			args: func(now time.Time) []interface{} {
+				return []interface{}{now.Add(-retention)}
+			},
+		})
+	}
+
+	repository.add(janitor.ExpiredDeniedTokens, rule{table: "denied_tokens", condition: "expires_at < ?", args: expired})
+	repository.add(janitor.ExpiredDeniedSubjects, rule{table: "denied_subjects", condition: "expires_at < ?", args: expired})
+	repository.add(janitor.ExpiredDeniedSessions, rule{table: "denied_sessions", condition: "expires_at < ?", args: expired})
+
+	return repository
+}
+
+// orphanedRule selects the rows of a table keyed by user ID whose user has no credential left.
+func orphanedRule(table string) rule {
+	return rule{
+		table:     table,
+		condition: fmt.Sprintf("NOT EXISTS (SELECT 1 FROM credentials WHERE credentials.user_id = %s.user_id)", table),
+		args: func(now time.Time) []interface{} {
+			return nil
+		},
+	}
+}
+
+func (repository *janitorRepository) add(task string, taskRule rule) {
+	repository.tasks = append(repository.tasks, task)
+	repository.rules[task] = taskRule
+}
+
+func (repository *janitorRepository) Tasks() []string {
+	return repository.tasks
+}
+
+func (repository *janitorRepository) Count(ctx context.Context, task string, now time.Time) (int64, error) {
+	taskRule, err := repository.rule(task)
+	if err != nil {
+		return 0, err
+	}
+
+	database := repository.DBService.GetDB()
+
+	var count int64
+
+	err = database.WithContext(ctx).
+		Raw(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", taskRule.table, taskRule.condition), taskRule.args(now)...).
+		Scan(&count).Error
+
+	return count, err
+}
+
+func (repository *janitorRepository) Delete(ctx context.Context, task string, now time.Time, limit int) (int64, error) {
+	taskRule, err := repository.rule(task)
+	if err != nil {
+		return 0, err
+	}
+
+	database := repository.DBService.GetDB()
+
+	result := database.WithContext(ctx).
+		Exec(fmt.Sprintf("DELETE FROM %s WHERE %s LIMIT ?", taskRule.table, taskRule.condition), append(taskRule.args(now), limit)...)
+
+	return result.RowsAffected, result.Error
+}
+
+// WithLock holds the named lock on a single connection for as long as fn runs, since MySQL ties named locks
+// to the session that took them.
+func (repository *janitorRepository) WithLock(ctx context.Context, fn func() error) (bool, error) {
+	database := repository.DBService.GetDB()
+
+	acquired := false
+	err := database.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		var result sql.NullInt64
+		err := conn.Raw("SELECT GET_LOCK(?, 0)", repository.lockName).
+			Row().
+			Scan(&result)
+		if err != nil {
+			return err
+		}
+
+		if !result.Valid || result.Int64 != 1 {
+			return nil
+		}
+
+		acquired = true
+		defer conn.Exec("DO RELEASE_LOCK(?)", repository.lockName)
+
+		return fn()
+	})
+
+	return acquired, err
+}
+
+func (repository *janitorRepository) rule(task string) (rule, error) {
+	taskRule, found := repository.rules[task]
+	if !found {
+		return rule{}, fmt.Errorf("unknown janitor task %q", task)
+	}
+
+	return taskRule, nil
+}
@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/janitor"
+)
+
+// recordingDB never connects, it records the SQL of every statement gorm would send to the database.
+type recordingDB struct {
+	logger.Interface
+	db         *gorm.DB
+	statements []string
+}
+
+func newRecordingDB(t *testing.T) *recordingDB {
+	t.Helper()
+
+	recorder := &recordingDB{Interface: logger.Discard}
+
+	database, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:password@tcp(localhost:3306)/auth?parseTime=True",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true, Logger: recorder})
+	assert.NoError(t, err)
+
+	recorder.db = database
+
+	return recorder
+}
+
+func (r *recordingDB) GetDB() *gorm.DB {
+	return r.db
+}
+
+func (r *recordingDB) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+func TestJanitorRepositoryTasks(t *testing.T) {
+	t.Run("runs every task in order", func(t *testing.T) {
+		repository := newJanitorRepository(newRecordingDB(t), config.JanitorConfig{
+			PasswordResetRetentionInHours:      168,
+			InactiveCredentialRetentionInHours: 720,
+			LoginFailureRetentionInHours:       24,
+		})
+
+		assert.Equal(t, []string{
+			janitor.ExpiredRefreshTokens,
+			janitor.OrphanedSessions,
+			janitor.StalePasswordResets,
+			janitor.InactiveCredentials,
+			janitor.OrphanedTOTPSecrets,
+			janitor.OrphanedRecoveryCodes,
+			janitor.OrphanedWebAuthnCredentials,
+			janitor.ExpiredWebAuthnCeremonies,
+			janitor.StaleLoginFailures,
+			janitor.ExpiredDeniedTokens,
+			janitor.ExpiredDeniedSubjects,
+			janitor.ExpiredDeniedSessions,
+		}, repository.Tasks())
+	})
+
+	t.Run("keeps rows without a retention", func(t *testing.T) {
+		repository := newJanitorRepository(newRecordingDB(t), config.JanitorConfig{})
+
+		assert.NotContains(t, repository.Tasks(), janitor.StalePasswordResets)
+		assert.NotContains(t, repository.Tasks(), janitor.InactiveCredentials)
+		assert.NotContains(t, repository.Tasks(), janitor.StaleLoginFailures)
+	})
+}
+
+func TestJanitorRepositoryDelete(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	t.Run("removes a limited batch of expired refresh tokens", func(t *testing.T) {
+		recorder := newRecordingDB(t)
+		repository := newJanitorRepository(recorder, config.JanitorConfig{})
+
+		_, err := repository.Delete(context.Background(), janitor.ExpiredRefreshTokens, now, 500)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"DELETE FROM refresh_tokens WHERE expiry < 1792324800 LIMIT 500"}, recorder.statements)
+	})
+
+	t.Run("only removes sessions without a refresh token past the grace period", func(t *testing.T) {
+		recorder := newRecordingDB(t)
+		repository := newJanitorRepository(recorder, config.JanitorConfig{})
+
+		_, err := repository.Delete(context.Background(), janitor.OrphanedSessions, now, 500)
+		assert.NoError(t, err)
+		assert.Len(t, recorder.statements, 1)
+		assert.Contains(t, recorder.statements[0], "DELETE FROM sessions WHERE created_at < '2026-10-18 11:00:00'")
+		assert.Contains(t, recorder.statements[0], "NOT EXISTS (SELECT 1 FROM refresh_tokens WHERE refresh_tokens.family_id = sessions.id)")
+	})
+
+	t.Run("removes the second factors of users without a credential", func(t *testing.T) {
+		recorder := newRecordingDB(t)
+		repository := newJanitorRepository(recorder, config.JanitorConfig{})
+
+		_, err := repository.Delete(context.Background(), janitor.OrphanedWebAuthnCredentials, now, 500)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"DELETE FROM webauthn_credentials WHERE NOT EXISTS (SELECT 1 FROM credentials WHERE credentials.user_id = webauthn_credentials.user_id) LIMIT 500",
+		}, recorder.statements)
+	})
+
+	t.Run("removes expired passkey ceremonies and login failures past their retention", func(t *testing.T) {
+		recorder := newRecordingDB(t)
+		repository := newJanitorRepository(recorder, config.JanitorConfig{LoginFailureRetentionInHours: 24})
+
+		_, err := repository.Delete(context.Background(), janitor.ExpiredWebAuthnCeremonies, now, 500)
+		assert.NoError(t, err)
+		_, err = repository.Delete(context.Background(), janitor.StaleLoginFailures, now, 500)
+		assert.NoError(t, err)
+
+		assert.Len(t, recorder.statements, 2)
+		assert.Contains(t, recorder.statements[0], "DELETE FROM webauthn_ceremonies WHERE expires_at < '2026-10-18 12:00:00'")
+		assert.Contains(t, recorder.statements[1], "DELETE FROM login_failures WHERE updated_at < '2026-10-17 12:00:00'")
+	})
+
+	t.Run("rejects an unknown task", func(t *testing.T) {
+		recorder := newRecordingDB(t)
+		repository := newJanitorRepository(recorder, config.JanitorConfig{})
+
+		_, err := repository.Delete(context.Background(), janitor.StalePasswordResets, now, 500)
+		assert.EqualError(t, err, `unknown janitor task "stale_password_resets"`)
+		assert.Empty(t, recorder.statements)
+	})
+}
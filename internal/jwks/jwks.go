@@ -0,0 +1,106 @@
+package jwks
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/weeb-vip/auth/internal/keypair"
+)
+
+var ErrUnsupportedKey = errors.New("unsupported public key")
+
+// Key is a public key in the JSON Web Key format (RFC 7517).
+type Key struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type Set struct {
+	Keys []Key `json:"keys"`
+}
+
+// NewSet converts PEM encoded public keys into a JSON Web Key Set, keeping their order.
+func NewSet(publicKeys []keypair.PublicKey) (*Set, error) {
+	set := &Set{Keys: make([]Key, 0, len(publicKeys))}
+	for _, publicKey := range publicKeys {
+		key, err := NewKey(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		set.Keys = append(set.Keys, key)
+	}
+
+	return set, nil
+}
+
+func NewKey(publicKey keypair.PublicKey) (Key, error) {
+	block, _ := pem.Decode([]byte(publicKey.Key))
+	if block == nil {
+		return Key{}, fmt.Errorf("%w: key %s is not PEM encoded", ErrUnsupportedKey, publicKey.ID)
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return Key{}, fmt.Errorf("%w: key %s: %w", ErrUnsupportedKey, publicKey.ID, err)
+	}
+
+	algorithm := publicKey.Algorithm
+	if algorithm == "" {
+		algorithm = keypair.RS256
+	}
+
+	switch parsed := parsed.(type) {
+	case *rsa.PublicKey:
+		return Key{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: string(algorithm),
+			Kid: publicKey.ID,
+			N:   base64.RawURLEncoding.EncodeToString(parsed.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(parsed.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		if parsed.Curve != elliptic.P256() {
+			return Key{}, fmt.Errorf("%w: key %s is on curve %s", ErrUnsupportedKey, publicKey.ID, parsed.Curve.Params().Name)
+		}
+
+		// Coordinates are padded to the size of the curve (RFC 7518, section 6.2.1.2).
+		size := (parsed.Curve.Params().BitSize + 7) / 8
+
+		return Key{
+			Kty: "EC",
+			Use: "sig",
+			Alg: string(algorithm),
+			Kid: publicKey.ID,
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(parsed.X.FillBytes(make([]byte, size))),
+			Y:   base64.RawURLEncoding.EncodeToString(parsed.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case ed25519.PublicKey:
+		return Key{
+			Kty: "OKP",
+			Use: "sig",
+			Alg: string(algorithm),
+			Kid: publicKey.ID,
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(parsed),
+		}, nil
+	default:
+		return Key{}, fmt.Errorf("%w: key %s is a %T", ErrUnsupportedKey, publicKey.ID, parsed)
+	}
+}
@@ -0,0 +1,83 @@
+package jwks_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/internal/jwks"
+	"github.com/weeb-vip/auth/internal/keypair"
+)
+
+func TestNewKey(t *testing.T) {
+	t.Run("converts an RSA public key", func(t *testing.T) {
+		generated, err := keypair.GenerateKeyPair()
+		assert.NoError(t, err)
+
+		key, err := jwks.NewKey(keypair.PublicKey{Key: generated.PublicKey, ID: "key_1"})
+		assert.NoError(t, err)
+		assert.Equal(t, "RSA", key.Kty)
+		assert.Equal(t, "RS256", key.Alg)
+		assert.Equal(t, "sig", key.Use)
+		assert.Equal(t, "key_1", key.Kid)
+		assert.Equal(t, "AQAB", key.E)
+
+		block, _ := pem.Decode([]byte(generated.PublicKey))
+		expected, err := x509.ParsePKIXPublicKey(block.Bytes)
+		assert.NoError(t, err)
+
+		modulus, err := base64.RawURLEncoding.DecodeString(key.N)
+		assert.NoError(t, err)
+		assert.Equal(t, expected.(*rsa.PublicKey).N, new(big.Int).SetBytes(modulus))
+	})
+
+	t.Run("converts an ES256 public key", func(t *testing.T) {
+		generated, err := keypair.GenerateKeyPairFor(keypair.ES256)
+		assert.NoError(t, err)
+
+		key, err := jwks.NewKey(keypair.PublicKey{Key: generated.PublicKey, ID: "key_1", Algorithm: keypair.ES256})
+		assert.NoError(t, err)
+		assert.Equal(t, "EC", key.Kty)
+		assert.Equal(t, "ES256", key.Alg)
+		assert.Equal(t, "P-256", key.Crv)
+
+		block, _ := pem.Decode([]byte(generated.PublicKey))
+		expected, err := x509.ParsePKIXPublicKey(block.Bytes)
+		assert.NoError(t, err)
+
+		x, err := base64.RawURLEncoding.DecodeString(key.X)
+		assert.NoError(t, err)
+		y, err := base64.RawURLEncoding.DecodeString(key.Y)
+		assert.NoError(t, err)
+		assert.Len(t, x, 32)
+		assert.Len(t, y, 32)
+		assert.Equal(t, expected.(*ecdsa.PublicKey).X, new(big.Int).SetBytes(x))
+		assert.Equal(t, expected.(*ecdsa.PublicKey).Y, new(big.Int).SetBytes(y))
+	})
+
+	t.Run("converts an EdDSA public key", func(t *testing.T) {
+		generated, err := keypair.GenerateKeyPairFor(keypair.EdDSA)
+		assert.NoError(t, err)
+
+		key, err := jwks.NewKey(keypair.PublicKey{Key: generated.PublicKey, ID: "key_1", Algorithm: keypair.EdDSA})
+		assert.NoError(t, err)
+		assert.Equal(t, "OKP", key.Kty)
+		assert.Equal(t, "EdDSA", key.Alg)
+		assert.Equal(t, "Ed25519", key.Crv)
+
+		x, err := base64.RawURLEncoding.DecodeString(key.X)
+		assert.NoError(t, err)
+		assert.Len(t, x, 32)
+	})
+
+	t.Run("rejects a key that is not PEM encoded", func(t *testing.T) {
+		_, err := jwks.NewKey(keypair.PublicKey{Key: "not a key", ID: "key_1"})
+		assert.ErrorIs(t, err, jwks.ErrUnsupportedKey)
+	})
+}
@@ -0,0 +1,16 @@
+package jwt
+
+import (
+	"errors"
+)
+
+// GetClaims wraps these so callers can tell with errors.Is why a token was rejected.
+var (
+	ErrTokenMalformed        = errors.New("token is malformed")
+	ErrUnknownKey            = errors.New("token is signed with an unknown key")
+	ErrTokenSignatureInvalid = errors.New("token signature is invalid")
+	ErrTokenExpired          = errors.New("token is expired")
+	ErrTokenNotValidYet      = errors.New("token is not valid yet")
+	ErrTokenInvalidIssuer    = errors.New("token has an invalid issuer")
+	ErrTokenInvalidAudience  = errors.New("token has an invalid audience")
+)
@@ -1,22 +1,39 @@
 package jwt
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/weeb-vip/auth/internal/keypair"
+	"github.com/weeb-vip/auth/internal/ulid"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
-const minJWTTokenValidityMinutes = 15
+const (
+	minJWTTokenValidityMinutes = 15
+	issuer                     = "weeb-vip"
+	audience                   = "weebusers"
+	// clockSkew is how far the clocks of the instances signing and verifying a token may drift apart.
+	clockSkew = 30 * time.Second
+)
+
+// DefaultTTL is how long a token is valid for unless its claims ask otherwise.
+const DefaultTTL = time.Minute * time.Duration(minJWTTokenValidityMinutes)
 
 func (t tokenizer) Tokenize(claims Claims) (string, error) {
 	signingKey := t.signingKey.GetLatest()
 
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, buildClaims(claims))
+	method, err := signingMethod(signingKey.Algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(method, buildClaims(claims))
 	token.Header["kid"] = signingKey.ID
 
-	signKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(signingKey.Key))
+	signKey, err := parsePrivateKey(signingKey.Key)
 	if err != nil {
 		return "", err
 	}
@@ -44,17 +61,20 @@ func getDefault(duration *time.Duration, defaultDuration time.Duration) time.Dur
 func buildClaims(srcClaims Claims) jwt.MapClaims {
 	mapClaims := jwt.MapClaims{
 		"nbf": time.Now().Unix(),
-		"iss": "weeb-vip",
-		"aud": "weebusers",
+		"iss": issuer,
+		"aud": audience,
 		"iat": time.Now().Unix(),
+		// jti identifies the token on the denylist.
+		"jti": ulid.New("token"),
 	}
 
 	mapClaims = addIfNotNil(mapClaims, srcClaims.Subject, "sub")
 	mapClaims = addIfNotNil(mapClaims, srcClaims.Purpose, "purpose")
 	mapClaims = addIfNotNil(mapClaims, srcClaims.RefreshToken, "refresh_token")
+	mapClaims = addIfNotNil(mapClaims, srcClaims.SessionID, "sid")
 	mapClaims["exp"] = time.
 		Now().
-		Add(getDefault(srcClaims.TTL, time.Minute*time.Duration(minJWTTokenValidityMinutes))).
+		Add(getDefault(srcClaims.TTL, DefaultTTL)).
 		Unix()
 
 	return mapClaims
@@ -69,31 +89,99 @@ func addIfNotNil[T any](claims jwt.MapClaims, value *T, key string) jwt.MapClaim
 }
 
 func (t tokenizer) GetClaims(token string) (*Claims, error) {
-	signingKey := t.signingKey.GetLatest()
-	parsedToken, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, jwt.ErrSignatureInvalid
+	parser := jwt.NewParser(jwt.WithValidMethods(validMethods()), jwt.WithoutClaimsValidation())
+	parsedToken, err := parser.Parse(token, func(parsed *jwt.Token) (interface{}, error) {
+		keyID, _ := parsed.Header["kid"].(string)
+		publicKey, found := t.signingKey.GetPublicKey(keyID)
+		if !found {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownKey, keyID)
 		}
 
-		publicKey := signingKey
+		// Only accept the algorithm the key was generated for, so a token cannot pick how its key is used.
+		method, err := signingMethod(publicKey.Algorithm)
+		if err != nil || method.Alg() != parsed.Method.Alg() {
+			return nil, ErrTokenSignatureInvalid
+		}
 
-		return jwt.ParseRSAPublicKeyFromPEM([]byte(publicKey.Key))
+		return parsePublicKey(publicKey.Key)
 	})
 	if err != nil {
-		return nil, err
-	}
-
-	if !parsedToken.Valid {
-		return nil, jwt.ErrTokenInvalidClaims
+		return nil, parseError(err)
 	}
 
 	mapClaims, ok := parsedToken.Claims.(jwt.MapClaims)
 	if !ok {
-		return nil, jwt.ErrTokenInvalidClaims
+		return nil, ErrTokenMalformed
+	}
+
+	if err := validateClaims(mapClaims, time.Now()); err != nil {
+		return nil, err
 	}
 
 	return &Claims{
-		Subject: mapClaims["sub"].(*string),
-		Purpose: mapClaims["purpose"].(*string),
+		ID:           getStringClaim(mapClaims, "jti"),
+		Subject:      getStringClaim(mapClaims, "sub"),
+		Purpose:      getStringClaim(mapClaims, "purpose"),
+		RefreshToken: getStringClaim(mapClaims, "refresh_token"),
+		SessionID:    getStringClaim(mapClaims, "sid"),
+		IssuedAt:     getTimeClaim(mapClaims, "iat"),
+		ExpiresAt:    getTimeClaim(mapClaims, "exp"),
 	}, nil
 }
+
+func parseError(err error) error {
+	var validationErr *jwt.ValidationError
+	if !errors.As(err, &validationErr) {
+		return fmt.Errorf("%w: %w", ErrTokenMalformed, err)
+	}
+
+	switch {
+	case errors.Is(validationErr.Inner, ErrUnknownKey), errors.Is(validationErr.Inner, ErrTokenSignatureInvalid):
+		return validationErr.Inner
+	case validationErr.Errors&jwt.ValidationErrorSignatureInvalid != 0:
+		return ErrTokenSignatureInvalid
+	default:
+		return fmt.Errorf("%w: %w", ErrTokenMalformed, err)
+	}
+}
+
+// validateClaims checks the registered claims buildClaims sets, allowing for clock skew between instances.
+func validateClaims(claims jwt.MapClaims, now time.Time) error {
+	if !claims.VerifyIssuer(issuer, true) {
+		return ErrTokenInvalidIssuer
+	}
+
+	if !claims.VerifyAudience(audience, true) {
+		return ErrTokenInvalidAudience
+	}
+
+	if !claims.VerifyExpiresAt(now.Add(-clockSkew).Unix(), true) {
+		return ErrTokenExpired
+	}
+
+	if !claims.VerifyNotBefore(now.Add(clockSkew).Unix(), false) {
+		return ErrTokenNotValidYet
+	}
+
+	return nil
+}
+
+func getStringClaim(claims jwt.MapClaims, key string) *string {
+	value, ok := claims[key].(string)
+	if !ok {
+		return nil
+	}
+
+	return &value
+}
+
+func getTimeClaim(claims jwt.MapClaims, key string) *time.Time {
+	value, ok := claims[key].(float64)
+	if !ok {
+		return nil
+	}
+
+	parsed := time.Unix(int64(value), 0)
+
+	return &parsed
+}
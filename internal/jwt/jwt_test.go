@@ -3,17 +3,20 @@ package jwt_test
 import (
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
 
+	jwtlib "github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/weeb-vip/auth/internal/jwt"
 	"github.com/weeb-vip/auth/internal/keypair"
 )
 
 type mockSigningKeyStruct struct {
-	key keypair.SigningKey
+	key       keypair.SigningKey
+	publicKey string
 }
 
 func (m mockSigningKeyStruct) Rotate() {}
@@ -24,11 +27,23 @@ func (m mockSigningKeyStruct) GetLatest() keypair.SigningKey {
 	return m.key
 }
 
+func (m mockSigningKeyStruct) GetPublicKeys() []keypair.PublicKey {
+	return []keypair.PublicKey{{Key: m.publicKey, ID: m.key.ID}}
+}
+
+func (m mockSigningKeyStruct) GetPublicKey(id string) (keypair.PublicKey, bool) {
+	if id != m.key.ID {
+		return keypair.PublicKey{}, false
+	}
+
+	return keypair.PublicKey{Key: m.publicKey, ID: m.key.ID}, true
+}
+
 func TestNew(t *testing.T) {
 	keyPair, keyGenerateError := keypair.GenerateKeyPair()
 	assert.NoError(t, keyGenerateError)
 	t.Run("signed JWT", func(t *testing.T) {
-		tokenizer := jwt.New(mockSigningKeyStruct{key: keypair.SigningKey{Key: keyPair.PrivateKey, ID: "key_id"}})
+		tokenizer := jwt.New(mockSigningKeyStruct{key: keypair.SigningKey{Key: keyPair.PrivateKey, ID: "key_id"}, publicKey: keyPair.PublicKey})
 		token, err := tokenizer.Tokenize(jwt.Claims{
 			Subject: getPointer("user_1"),
 			TTL:     getPointer(time.Second * 15),
@@ -75,6 +90,228 @@ func TestNew(t *testing.T) {
 	})
 }
 
+func TestTokenizer_GetClaims(t *testing.T) {
+	keyPair, keyGenerateError := keypair.GenerateKeyPair()
+	assert.NoError(t, keyGenerateError)
+	tokenizer := jwt.New(mockSigningKeyStruct{key: keypair.SigningKey{Key: keyPair.PrivateKey, ID: "key_id"}, publicKey: keyPair.PublicKey})
+
+	t.Run("returns the claims of a token it signed", func(t *testing.T) {
+		token, err := tokenizer.Tokenize(jwt.Claims{
+			Subject: getPointer("user_1"),
+			Purpose: getPointer("MFA_CHALLENGE"),
+		})
+		assert.NoError(t, err)
+
+		claims, err := tokenizer.GetClaims(token)
+		assert.NoError(t, err)
+		assert.Equal(t, "user_1", *claims.Subject)
+		assert.Equal(t, "MFA_CHALLENGE", *claims.Purpose)
+	})
+
+	t.Run("leaves missing claims nil", func(t *testing.T) {
+		token, _ := tokenizer.Tokenize(jwt.Claims{Subject: getPointer("user_1")})
+
+		claims, err := tokenizer.GetClaims(token)
+		assert.NoError(t, err)
+		assert.Nil(t, claims.Purpose)
+		assert.Nil(t, claims.RefreshToken)
+		assert.Nil(t, claims.SessionID)
+	})
+
+	t.Run("returns the refresh token and session of a session token", func(t *testing.T) {
+		token, _ := tokenizer.Tokenize(jwt.Claims{
+			Subject:      getPointer("user_1"),
+			RefreshToken: getPointer("refresh_token_1"),
+			SessionID:    getPointer("session_1"),
+		})
+
+		claims, err := tokenizer.GetClaims(token)
+		assert.NoError(t, err)
+		assert.Equal(t, "refresh_token_1", *claims.RefreshToken)
+		assert.Equal(t, "session_1", *claims.SessionID)
+	})
+
+	t.Run("rejects a token signed by another key", func(t *testing.T) {
+		otherKeyPair, _ := keypair.GenerateKeyPair()
+		otherTokenizer := jwt.New(mockSigningKeyStruct{key: keypair.SigningKey{Key: otherKeyPair.PrivateKey, ID: "key_id"}, publicKey: otherKeyPair.PublicKey})
+		token, _ := otherTokenizer.Tokenize(jwt.Claims{Subject: getPointer("user_1")})
+
+		_, err := tokenizer.GetClaims(token)
+		assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+	})
+
+	t.Run("rejects an expired token", func(t *testing.T) {
+		token, _ := tokenizer.Tokenize(jwt.Claims{Subject: getPointer("user_1"), TTL: getPointer(-time.Minute)})
+
+		_, err := tokenizer.GetClaims(token)
+		assert.ErrorIs(t, err, jwt.ErrTokenExpired)
+	})
+
+	t.Run("returns when the token was issued and expires", func(t *testing.T) {
+		token, _ := tokenizer.Tokenize(jwt.Claims{Subject: getPointer("user_1"), TTL: getPointer(time.Hour)})
+
+		claims, err := tokenizer.GetClaims(token)
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), *claims.IssuedAt, 2*time.Second)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *claims.ExpiresAt, 2*time.Second)
+	})
+
+	t.Run("gives every token its own ID", func(t *testing.T) {
+		first, _ := tokenizer.Tokenize(jwt.Claims{Subject: getPointer("user_1")})
+		second, _ := tokenizer.Tokenize(jwt.Claims{Subject: getPointer("user_1")})
+
+		firstClaims, err := tokenizer.GetClaims(first)
+		assert.NoError(t, err)
+		secondClaims, err := tokenizer.GetClaims(second)
+		assert.NoError(t, err)
+
+		assert.NotEmpty(t, *firstClaims.ID)
+		assert.NotEqual(t, *firstClaims.ID, *secondClaims.ID)
+	})
+
+	t.Run("rejects a token with an unknown kid", func(t *testing.T) {
+		token := signClaims(t, keyPair.PrivateKey, "other_key_id", jwtlib.MapClaims{"sub": "user_1"})
+
+		_, err := tokenizer.GetClaims(token)
+		assert.ErrorIs(t, err, jwt.ErrUnknownKey)
+	})
+
+	t.Run("rejects a token not signed with RS256", func(t *testing.T) {
+		token := jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, validClaims(jwtlib.MapClaims{"sub": "user_1"}))
+		token.Header["kid"] = "key_id"
+		signed, err := token.SignedString([]byte(keyPair.PublicKey))
+		assert.NoError(t, err)
+
+		_, err = tokenizer.GetClaims(signed)
+		assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+	})
+
+	t.Run("rejects a malformed token", func(t *testing.T) {
+		_, err := tokenizer.GetClaims("not.a.token")
+		assert.ErrorIs(t, err, jwt.ErrTokenMalformed)
+	})
+
+	for name, testCase := range map[string]struct {
+		claims jwtlib.MapClaims
+		err    error
+	}{
+		"another issuer":                 {claims: jwtlib.MapClaims{"iss": "someone-else"}, err: jwt.ErrTokenInvalidIssuer},
+		"another audience":               {claims: jwtlib.MapClaims{"aud": "admins"}, err: jwt.ErrTokenInvalidAudience},
+		"no expiry":                      {claims: jwtlib.MapClaims{"exp": nil}, err: jwt.ErrTokenExpired},
+		"a not before in the future":     {claims: jwtlib.MapClaims{"nbf": time.Now().Add(time.Hour).Unix()}, err: jwt.ErrTokenNotValidYet},
+		"an expiry within clock skew":    {claims: jwtlib.MapClaims{"exp": time.Now().Add(-10 * time.Second).Unix()}},
+		"a not before within clock skew": {claims: jwtlib.MapClaims{"nbf": time.Now().Add(10 * time.Second).Unix()}},
+	} {
+		t.Run("validates a token with "+name, func(t *testing.T) {
+			token := signClaims(t, keyPair.PrivateKey, "key_id", testCase.claims)
+
+			_, err := tokenizer.GetClaims(token)
+			if testCase.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, testCase.err)
+			}
+		})
+	}
+}
+
+func TestTokenizer_GetClaimsAfterRotation(t *testing.T) {
+	count := 0
+	rotator, err := keypair.NewSigningKeyRotator(func(publicKey string) (string, error) {
+		count++
+
+		return fmt.Sprintf("key_%d", count), nil
+	})
+	assert.NoError(t, err)
+	tokenizer := jwt.New(rotator)
+
+	token, err := tokenizer.Tokenize(jwt.Claims{Subject: getPointer("user_1")})
+	assert.NoError(t, err)
+
+	rotator.Rotate()
+
+	claims, err := tokenizer.GetClaims(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "user_1", *claims.Subject)
+}
+
+func TestTokenizer_Algorithms(t *testing.T) {
+	for _, algorithm := range []keypair.Algorithm{keypair.RS256, keypair.PS256, keypair.ES256, keypair.EdDSA} {
+		t.Run("signs and verifies with "+string(algorithm), func(t *testing.T) {
+			rotator, err := keypair.NewSigningKeyRotator(func(publicKey string) (string, error) {
+				return "key_id", nil
+			}, keypair.WithAlgorithm(algorithm))
+			assert.NoError(t, err)
+			tokenizer := jwt.New(rotator)
+
+			token, err := tokenizer.Tokenize(jwt.Claims{Subject: getPointer("user_1")})
+			assert.NoError(t, err)
+
+			parsed, _, err := new(jwtlib.Parser).ParseUnverified(token, jwtlib.MapClaims{})
+			assert.NoError(t, err)
+			assert.Equal(t, string(algorithm), parsed.Header["alg"])
+
+			claims, err := tokenizer.GetClaims(token)
+			assert.NoError(t, err)
+			assert.Equal(t, "user_1", *claims.Subject)
+		})
+	}
+
+	t.Run("rejects a token using another algorithm than its key", func(t *testing.T) {
+		keyPair, err := keypair.GenerateKeyPairFor(keypair.RS256)
+		assert.NoError(t, err)
+		tokenizer := jwt.New(mockSigningKeyStruct{
+			key:       keypair.SigningKey{Key: keyPair.PrivateKey, ID: "key_id", Algorithm: keypair.RS256},
+			publicKey: keyPair.PublicKey,
+		})
+
+		privateKey, err := jwtlib.ParseRSAPrivateKeyFromPEM([]byte(keyPair.PrivateKey))
+		assert.NoError(t, err)
+		token := jwtlib.NewWithClaims(jwtlib.SigningMethodPS256, validClaims(jwtlib.MapClaims{"sub": "user_1"}))
+		token.Header["kid"] = "key_id"
+		signed, err := token.SignedString(privateKey)
+		assert.NoError(t, err)
+
+		_, err = tokenizer.GetClaims(signed)
+		assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+	})
+}
+
+// validClaims fills in the registered claims Tokenize would set, letting overrides replace or remove them.
+func validClaims(overrides jwtlib.MapClaims) jwtlib.MapClaims {
+	claims := jwtlib.MapClaims{
+		"iss": "weeb-vip",
+		"aud": "weebusers",
+		"iat": time.Now().Unix(),
+		"nbf": time.Now().Unix(),
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}
+	for key, value := range overrides {
+		if value == nil {
+			delete(claims, key)
+
+			continue
+		}
+		claims[key] = value
+	}
+
+	return claims
+}
+
+func signClaims(t *testing.T, privateKey string, keyID string, overrides jwtlib.MapClaims) string {
+	t.Helper()
+
+	key, err := jwtlib.ParseRSAPrivateKeyFromPEM([]byte(privateKey))
+	assert.NoError(t, err)
+
+	token := jwtlib.NewWithClaims(jwtlib.SigningMethodRS256, validClaims(overrides))
+	token.Header["kid"] = keyID
+	signed, err := token.SignedString(key)
+	assert.NoError(t, err)
+
+	return signed
+}
+
 func getPointer[T any](val T) *T {
 	return &val
 }
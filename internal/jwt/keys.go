@@ -0,0 +1,60 @@
+package jwt
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/weeb-vip/auth/internal/keypair"
+)
+
+// signingMethods lists every algorithm tokens may be signed with; each key only verifies its own.
+var signingMethods = map[keypair.Algorithm]jwt.SigningMethod{
+	keypair.RS256: jwt.SigningMethodRS256,
+	keypair.PS256: jwt.SigningMethodPS256,
+	keypair.ES256: jwt.SigningMethodES256,
+	keypair.EdDSA: jwt.SigningMethodEdDSA,
+}
+
+func signingMethod(algorithm keypair.Algorithm) (jwt.SigningMethod, error) {
+	if algorithm == "" {
+		algorithm = keypair.RS256
+	}
+
+	method, found := signingMethods[algorithm]
+	if !found {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+
+	return method, nil
+}
+
+func validMethods() []string {
+	methods := make([]string, 0, len(signingMethods))
+	for algorithm := range signingMethods {
+		methods = append(methods, string(algorithm))
+	}
+
+	return methods
+}
+
+// parsePrivateKey reads the PKCS #8 PEM keys keypair generates, whatever their algorithm.
+func parsePrivateKey(encoded string) (interface{}, error) {
+	block, _ := pem.Decode([]byte(encoded))
+	if block == nil {
+		return nil, jwt.ErrKeyMustBePEMEncoded
+	}
+
+	return x509.ParsePKCS8PrivateKey(block.Bytes)
+}
+
+func parsePublicKey(encoded string) (interface{}, error) {
+	block, _ := pem.Decode([]byte(encoded))
+	if block == nil {
+		return nil, jwt.ErrKeyMustBePEMEncoded
+	}
+
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
@@ -15,6 +15,12 @@ type Claims struct {
 	TTL          *time.Duration
 	Purpose      *string
 	RefreshToken *string
+	// SessionID names the session an access token was issued for, so it can be revoked with the session.
+	SessionID *string
+	// ID, IssuedAt and ExpiresAt are only set on claims read from a token.
+	ID        *string
+	IssuedAt  *time.Time
+	ExpiresAt *time.Time
 }
 
 type Tokenizer interface {
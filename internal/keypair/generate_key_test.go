@@ -14,4 +14,18 @@ func TestGenerateKeyPair(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotNil(t, key)
 	})
+	for _, algorithm := range []keypair.Algorithm{keypair.RS256, keypair.PS256, keypair.ES256, keypair.EdDSA} {
+		t.Run("can generate a "+string(algorithm)+" key pair", func(t *testing.T) {
+			key, err := keypair.GenerateKeyPairFor(algorithm)
+			assert.NoError(t, err)
+			assert.Equal(t, algorithm, key.Algorithm)
+			assert.Contains(t, key.PrivateKey, "PRIVATE KEY")
+			assert.Contains(t, key.PublicKey, "PUBLIC KEY")
+		})
+	}
+
+	t.Run("rejects an unsupported algorithm", func(t *testing.T) {
+		_, err := keypair.GenerateKeyPairFor("HS256")
+		assert.Error(t, err)
+	})
 }
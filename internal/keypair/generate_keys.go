@@ -1,47 +1,95 @@
 package keypair
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"fmt"
 )
 
 const KeySize = 2048
 
+// Algorithm is the JWS algorithm a key signs with, as it appears in the alg header of tokens.
+type Algorithm string
+
+const (
+	RS256 Algorithm = "RS256"
+	PS256 Algorithm = "PS256"
+	ES256 Algorithm = "ES256"
+	EdDSA Algorithm = "EdDSA"
+)
+
+// ParseAlgorithm returns the Algorithm with the given name.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch Algorithm(name) {
+	case RS256, PS256, ES256, EdDSA:
+		return Algorithm(name), nil
+	default:
+		return "", fmt.Errorf("unsupported signing algorithm %q", name)
+	}
+}
+
+// GenerateKeyPair generates an RS256 key pair.
 func GenerateKeyPair() (*key, error) { // nolint
-	// There's no need to handle the error since rsa.GenerateKey with the given parameter can never produce error.
-	keyPair, _ := rsa.GenerateKey(rand.Reader, KeySize)
+	return GenerateKeyPairFor(RS256)
+}
 
-	privateKey, err := getEncodedPrivateKey(keyPair)
+// GenerateKeyPairFor generates a key pair of the type algorithm signs with: RSA-2048 for RS256 and PS256,
+// P-256 for ES256 and Ed25519 for EdDSA.
+func GenerateKeyPairFor(algorithm Algorithm) (*key, error) { // nolint
+	var privateKey crypto.Signer
+	var err error
+	privatePEMType, publicPEMType := "PRIVATE KEY", "PUBLIC KEY"
+
+	switch algorithm {
+	case RS256, PS256:
+		// There's no need to handle the error since rsa.GenerateKey with the given parameter can never produce error.
+		privateKey, _ = rsa.GenerateKey(rand.Reader, KeySize)
+		// RSA keys have always been encoded with these types, keep them so existing keys and consumers still match.
+		privatePEMType, publicPEMType = "RSA PRIVATE KEY", "RSA PUBLIC KEY"
+	case ES256:
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case EdDSA:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	publicKey, err := getEncodedPublicKey(keyPair)
+	encodedPrivateKey, err := getEncodedPrivateKey(privateKey, privatePEMType)
 	if err != nil {
 		return nil, err
 	}
 
-	return &key{PublicKey: publicKey, PrivateKey: privateKey}, nil
+	encodedPublicKey, err := getEncodedPublicKey(privateKey.Public(), publicPEMType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &key{PublicKey: encodedPublicKey, PrivateKey: encodedPrivateKey, Algorithm: algorithm}, nil
 }
 
-func getEncodedPrivateKey(keyPair *rsa.PrivateKey) (string, error) {
-	pkcs8Key, err := x509.MarshalPKCS8PrivateKey(keyPair)
+func getEncodedPrivateKey(privateKey crypto.PrivateKey, pemType string) (string, error) {
+	pkcs8Key, err := x509.MarshalPKCS8PrivateKey(privateKey)
 	if err != nil {
 		return "", err
 	}
 
-	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: pkcs8Key})), nil
+	return string(pem.EncodeToMemory(&pem.Block{Type: pemType, Bytes: pkcs8Key})), nil
 }
 
-func getEncodedPublicKey(keyPair *rsa.PrivateKey) (string, error) {
-	publicKey := keyPair.Public()
-
-	marshalledPublicKey, err := x509.MarshalPKIXPublicKey(publicKey.(*rsa.PublicKey))
+func getEncodedPublicKey(publicKey crypto.PublicKey, pemType string) (string, error) {
+	marshalledPublicKey, err := x509.MarshalPKIXPublicKey(publicKey)
 	if err != nil {
 		return "", err
 	}
 
-	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: marshalledPublicKey})), nil
+	return string(pem.EncodeToMemory(&pem.Block{Type: pemType, Bytes: marshalledPublicKey})), nil
 }
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weeb-vip/auth/internal/db"
+)
+
+// SigningKey is a JWT signing key shared by every replica. The ID is the kid tokens signed with it carry.
+type SigningKey struct {
+	db.BaseModel
+	PrivateKey string     `json:"-"` // encrypted with the signing key master key.
+	PublicKey  string     `json:"publicKey"`
+	Algorithm  string     `json:"algorithm"`
+	RetiredAt  *time.Time `json:"retiredAt"`
+}
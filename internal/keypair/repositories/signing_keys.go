@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/weeb-vip/auth/internal/db"
+	"github.com/weeb-vip/auth/internal/encryption"
+	"github.com/weeb-vip/auth/internal/keypair"
+	"github.com/weeb-vip/auth/internal/keypair/models"
+)
+
+type signingKeysRepository struct {
+	DBService   db.DB
+	cipher      encryption.Cipher
+	lockName    string
+	lockTimeout time.Duration
+}
+
+// NewSigningKeysRepository stores signing keys in the database with their private keys encrypted by cipher,
+// coordinating rotation through a MySQL named lock that it waits up to lockTimeout for.
+func NewSigningKeysRepository(cipher encryption.Cipher, lockName string, lockTimeout time.Duration) keypair.KeyStore {
+	dbService := db.GetDBService()
+
+	return &signingKeysRepository{
+		DBService:   dbService,
+		cipher:      cipher,
+		lockName:    lockName,
+		lockTimeout: lockTimeout,
+	}
+}
+
+func (repository *signingKeysRepository) LoadKeys(ctx context.Context, retiredSince time.Time) ([]keypair.StoredKey, error) {
+	db := repository.DBService.GetDB()
+
+	var signingKeys []models.SigningKey
+
+	err := db.WithContext(ctx).
+		Where("retired_at IS NULL OR retired_at > ?", retiredSince).
+		Order("created_at DESC").
+		Find(&signingKeys).Error
+	if err != nil {
+		return nil, err
+	}
+
+	storedKeys := make([]keypair.StoredKey, 0, len(signingKeys))
+	for _, signingKey := range signingKeys {
+		privateKey, err := repository.cipher.Decrypt(signingKey.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt signing key %s: %w", signingKey.ID, err)
+		}
+
+		storedKeys = append(storedKeys, keypair.StoredKey{
+			ID:         signingKey.ID,
+			PrivateKey: privateKey,
+			PublicKey:  signingKey.PublicKey,
+			Algorithm:  keypair.Algorithm(signingKey.Algorithm),
+			CreatedAt:  signingKey.CreatedAt,
+			RetiredAt:  signingKey.RetiredAt,
+		})
+	}
+
+	return storedKeys, nil
+}
+
+func (repository *signingKeysRepository) SaveKey(ctx context.Context, key keypair.StoredKey) error {
+	db := repository.DBService.GetDB()
+
+	privateKey, err := repository.cipher.Encrypt(key.PrivateKey)
+	if err != nil {
+		return err
+	}
+
+	signingKey := &models.SigningKey{PrivateKey: privateKey, PublicKey: key.PublicKey, Algorithm: string(key.Algorithm)}
+	signingKey.ID = key.ID
+	signingKey.CreatedAt = key.CreatedAt
+
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.SigningKey{}).
+			Where("retired_at IS NULL").
+			Update("retired_at", key.CreatedAt).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(signingKey).Error
+	})
+}
+
+// WithRotationLock holds the named lock on a single connection for as long as fn runs, since MySQL
+// ties named locks to the session that took them.
+func (repository *signingKeysRepository) WithRotationLock(ctx context.Context, fn func() error) (bool, error) {
+	db := repository.DBService.GetDB()
+
+	acquired := false
+	err := db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		var result sql.NullInt64
+		err := conn.Raw("SELECT GET_LOCK(?, ?)", repository.lockName, int(repository.lockTimeout.Seconds())).
+			Row().
+			Scan(&result)
+		if err != nil {
+			return err
+		}
+
+		if !result.Valid || result.Int64 != 1 {
+			return nil
+		}
+
+		acquired = true
+		defer conn.Exec("DO RELEASE_LOCK(?)", repository.lockName)
+
+		return fn()
+	})
+
+	return acquired, err
+}
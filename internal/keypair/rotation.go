@@ -1,69 +1,401 @@
 package keypair
 
 import (
+	"context"
+	"errors"
+	"math"
+	"sync"
 	"time"
 
 	"github.com/weeb-vip/auth/internal/container"
+	"github.com/weeb-vip/auth/internal/logger"
+	"github.com/weeb-vip/auth/internal/metrics"
 )
 
+const (
+	// DefaultRetention is how long a rotated out key stays available for verification unless WithRetention says otherwise.
+	DefaultRetention = time.Hour
+	// DefaultSyncInterval is how often a rotator backed by a KeyStore picks up keys rotated by other replicas.
+	DefaultSyncInterval = time.Minute
+	// maxReloadInterval bounds how often a token signed with an unknown key makes the rotator reload its store.
+	maxReloadInterval = 5 * time.Second
+)
+
+var ErrNoActiveKey = errors.New("key store has no active signing key")
+
 type PublicKeyIDGenerator func(publicKey string) (string, error)
 
+type RotatorOption func(*keyRotator)
+
+// WithRetention keeps rotated out keys for verification for the given duration, which should cover
+// the longest lived token signed with them.
+func WithRetention(retention time.Duration) RotatorOption {
+	return func(k *keyRotator) {
+		k.retention = retention
+	}
+}
+
+// WithAlgorithm generates keys for algorithm instead of RS256. Keys of another algorithm still verify
+// until they are rotated out and past retention.
+func WithAlgorithm(algorithm Algorithm) RotatorOption {
+	return func(k *keyRotator) {
+		k.algorithm = algorithm
+	}
+}
+
+// WithMaxAge reports the active key as stale, through a log and the signing_key_max_age_exceeded metric, once
+// it is older than maxAge. RotateInBackground defaults it to twice the rotation interval.
+func WithMaxAge(maxAge time.Duration) RotatorOption {
+	return func(k *keyRotator) {
+		k.maxAge = maxAge
+	}
+}
+
+// WithStore shares keys with other replicas through store instead of keeping them in memory, loading the
+// existing keys on startup and checking the store for keys rotated elsewhere every syncInterval.
+func WithStore(store KeyStore, syncInterval time.Duration) RotatorOption {
+	return func(k *keyRotator) {
+		k.store = store
+		k.syncInterval = syncInterval
+	}
+}
+
+type retiredKey struct {
+	key       *key
+	retiredAt time.Time
+}
+
+// keySet is replaced as a whole on rotation, so readers always see a consistent current and previous keys.
+type keySet struct {
+	current   *key
+	createdAt time.Time
+	previous  []retiredKey
+}
+
 type keyRotator struct {
-	keyContainer   container.Container[*key]
+	keyContainer   container.Container[*keySet]
 	keyIDGenerator PublicKeyIDGenerator
+	algorithm      Algorithm
+	retention      time.Duration
+	maxAge         time.Duration
+	store          KeyStore
+	syncInterval   time.Duration
+	reload         *reloadThrottle
+	now            func() time.Time
+}
+
+type reloadThrottle struct {
+	sync.Mutex
+	last time.Time
 }
 
 type RotatingSigningKey interface {
 	Rotate()
 	RotateInBackground(every time.Duration)
 	GetLatest() SigningKey
+	// GetPublicKeys returns the public keys of the current key followed by rotated out keys still within retention.
+	GetPublicKeys() []PublicKey
+	// GetPublicKey returns the public key with the given ID if it is current or still within retention.
+	GetPublicKey(id string) (PublicKey, bool)
 }
 
+// RotateInBackground checks every sync interval whether the active key is older than every, so a failed
+// rotation is retried on the next check rather than a whole rotation interval later.
 func (k keyRotator) RotateInBackground(every time.Duration) {
+	if k.maxAge == 0 {
+		k.maxAge = 2 * every
+	}
+
 	go func() {
 		for {
-			time.Sleep(every)
-			k.Rotate()
+			time.Sleep(k.syncInterval)
+
+			if k.store != nil {
+				// Every replica checks the store, and whichever gets the lock once the active key is due rotates it.
+				k.rotateStored(every)
+			} else if k.due(k.keyContainer.GetLatest(), every) {
+				k.Rotate()
+			}
+
+			k.checkAge()
 		}
 	}()
 }
 
 func (k keyRotator) Rotate() {
-	newKeyPair, err := generateNewKeyPairWithID(k.keyIDGenerator)
+	if k.store != nil {
+		k.rotateStored(0)
+
+		return
+	}
+
+	newKeyPair, err := generateNewKeyPairWithID(k.keyIDGenerator, k.algorithm)
 	if err != nil {
-		// Because it's okay to not rotate key for few times.
+		// Because it's okay to not rotate key for few times, as long as someone is told.
+		k.rotationFailed(err)
+
 		return
 	}
+	metrics.GetAppMetrics().SigningKeyRotationMetric(metrics.Success)
+
+	now := k.now()
+	keys := k.keyContainer.GetLatest()
+	previous := []retiredKey{{key: keys.current, retiredAt: now}}
+	for _, retired := range keys.previous {
+		if now.Sub(retired.retiredAt) < k.retention {
+			previous = append(previous, retired)
+		}
+	}
+
+	k.keyContainer.ReplaceWith(&keySet{current: newKeyPair, createdAt: now, previous: previous})
+}
+
+// rotateStored replaces the active key in the store once it is older than every or of another algorithm than
+// the configured one, then loads the keys of the store.
+func (k keyRotator) rotateStored(every time.Duration) {
+	ctx := context.Background()
+	log := logger.FromCtx(ctx)
+
+	if k.due(k.keyContainer.GetLatest(), every) {
+		_, err := k.store.WithRotationLock(ctx, func() error {
+			// Another replica may have rotated since the keys were last loaded.
+			keys, err := k.loadKeys(ctx)
+			if err != nil && !errors.Is(err, ErrNoActiveKey) {
+				return err
+			}
+			if keys != nil && !k.due(keys, every) {
+				return nil
+			}
+
+			if err := k.storeNewKey(ctx); err != nil {
+				return err
+			}
+			metrics.GetAppMetrics().SigningKeyRotationMetric(metrics.Success)
+
+			return nil
+		})
+		if err != nil {
+			// Because it's okay to not rotate key for few times, as long as someone is told.
+			k.rotationFailed(err)
+		}
+	}
+
+	if err := k.refresh(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to load signing keys")
+	}
+}
 
-	k.keyContainer.ReplaceWith(newKeyPair)
+func (k keyRotator) rotationFailed(err error) {
+	log := logger.FromCtx(context.Background())
+	log.Error().Err(err).Msg("Failed to rotate signing key")
+	metrics.GetAppMetrics().SigningKeyRotationMetric(metrics.Error)
+}
+
+func (k keyRotator) checkAge() {
+	keys := k.keyContainer.GetLatest()
+	age := k.now().Sub(keys.createdAt)
+	exceeded := k.maxAge > 0 && age > k.maxAge
+
+	metrics.GetAppMetrics().SigningKeyAgeMetric(age.Seconds(), exceeded)
+	if exceeded {
+		log := logger.FromCtx(context.Background())
+		log.Error().
+			Str("key_id", keys.current.ID).
+			Dur("age", age).
+			Dur("max_age", k.maxAge).
+			Msg("Active signing key is older than its max age, rotation has stalled")
+	}
+}
+
+func (k keyRotator) due(keys *keySet, every time.Duration) bool {
+	return k.now().Sub(keys.createdAt) >= every || keys.current.Algorithm != k.algorithm
 }
 
 func (k keyRotator) GetLatest() SigningKey {
-	currentKey := k.keyContainer.GetLatest()
+	currentKey := k.keyContainer.GetLatest().current
 
 	return SigningKey{
-		Key: currentKey.PrivateKey,
-		ID:  currentKey.ID,
+		Key:       currentKey.PrivateKey,
+		ID:        currentKey.ID,
+		Algorithm: currentKey.Algorithm,
 	}
 }
 
-func NewSigningKeyRotator(idGenerator PublicKeyIDGenerator) (RotatingSigningKey, error) {
-	// We start with generating a key and keeping it in container[key].
-	keyPair, err := generateNewKeyPairWithID(idGenerator)
+func (k keyRotator) GetPublicKeys() []PublicKey {
+	keys := k.keyContainer.GetLatest()
+	now := k.now()
+
+	publicKeys := []PublicKey{{Key: keys.current.PublicKey, ID: keys.current.ID, Algorithm: keys.current.Algorithm}}
+	for _, retired := range keys.previous {
+		if now.Sub(retired.retiredAt) < k.retention {
+			publicKeys = append(publicKeys, PublicKey{
+				Key:       retired.key.PublicKey,
+				ID:        retired.key.ID,
+				Algorithm: retired.key.Algorithm,
+			})
+		}
+	}
+
+	return publicKeys
+}
+
+func (k keyRotator) GetPublicKey(id string) (PublicKey, bool) {
+	if publicKey, found := k.findPublicKey(id); found || k.store == nil {
+		return publicKey, found
+	}
+
+	// The token may be signed with a key another replica rotated in after the keys were last loaded.
+	if !k.reloadAllowed() {
+		return PublicKey{}, false
+	}
+	if err := k.refresh(context.Background()); err != nil {
+		return PublicKey{}, false
+	}
+
+	return k.findPublicKey(id)
+}
+
+func (k keyRotator) findPublicKey(id string) (PublicKey, bool) {
+	for _, publicKey := range k.GetPublicKeys() {
+		if publicKey.ID == id {
+			return publicKey, true
+		}
+	}
+
+	return PublicKey{}, false
+}
+
+func (k keyRotator) reloadAllowed() bool {
+	k.reload.Lock()
+	defer k.reload.Unlock()
+
+	now := k.now()
+	if now.Sub(k.reload.last) < min(k.syncInterval, maxReloadInterval) {
+		return false
+	}
+	k.reload.last = now
+
+	return true
+}
+
+func (k keyRotator) refresh(ctx context.Context) error {
+	keys, err := k.loadKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	k.keyContainer.ReplaceWith(keys)
+
+	return nil
+}
+
+func (k keyRotator) loadKeys(ctx context.Context) (*keySet, error) {
+	storedKeys, err := k.store.LoadKeys(ctx, k.now().Add(-k.retention))
 	if err != nil {
 		return nil, err
 	}
 
-	keyContainer := container.New[*key](keyPair)
+	keys := &keySet{}
+	for _, storedKey := range storedKeys {
+		loaded := &key{
+			PrivateKey: storedKey.PrivateKey,
+			PublicKey:  storedKey.PublicKey,
+			ID:         storedKey.ID,
+			Algorithm:  storedKey.Algorithm,
+		}
+		if storedKey.RetiredAt == nil {
+			keys.current = loaded
+			keys.createdAt = storedKey.CreatedAt
+		} else {
+			keys.previous = append(keys.previous, retiredKey{key: loaded, retiredAt: *storedKey.RetiredAt})
+		}
+	}
+
+	if keys.current == nil {
+		return nil, ErrNoActiveKey
+	}
+
+	return keys, nil
+}
+
+func (k keyRotator) storeNewKey(ctx context.Context) error {
+	newKeyPair, err := generateNewKeyPairWithID(k.keyIDGenerator, k.algorithm)
+	if err != nil {
+		return err
+	}
+
+	return k.store.SaveKey(ctx, StoredKey{
+		ID:         newKeyPair.ID,
+		PrivateKey: newKeyPair.PrivateKey,
+		PublicKey:  newKeyPair.PublicKey,
+		Algorithm:  newKeyPair.Algorithm,
+		CreatedAt:  k.now(),
+	})
+}
+
+// loadOrCreateKeys loads the keys of the store on startup, creating the first key when no replica has yet.
+func (k keyRotator) loadOrCreateKeys(ctx context.Context) error {
+	err := k.refresh(ctx)
+	if err == nil && k.keyContainer.GetLatest().current.Algorithm != k.algorithm {
+		// Switch to the configured algorithm right away; the old key keeps verifying until it is past retention.
+		k.rotateStored(math.MaxInt64)
 
-	return keyRotator{
-		keyContainer:   keyContainer,
+		return nil
+	}
+	if !errors.Is(err, ErrNoActiveKey) {
+		return err
+	}
+
+	_, err = k.store.WithRotationLock(ctx, func() error {
+		// Another replica may have created the first key while this one waited for the lock.
+		if _, err := k.loadKeys(ctx); !errors.Is(err, ErrNoActiveKey) {
+			return err
+		}
+
+		return k.storeNewKey(ctx)
+	})
+	if err != nil {
+		return err
+	}
+
+	return k.refresh(ctx)
+}
+
+func NewSigningKeyRotator(idGenerator PublicKeyIDGenerator, options ...RotatorOption) (RotatingSigningKey, error) {
+	rotator := keyRotator{
 		keyIDGenerator: idGenerator,
-	}, nil
+		algorithm:      RS256,
+		retention:      DefaultRetention,
+		syncInterval:   DefaultSyncInterval,
+		reload:         &reloadThrottle{},
+		now:            time.Now,
+	}
+	for _, option := range options {
+		option(&rotator)
+	}
+
+	if rotator.store != nil {
+		rotator.keyContainer = container.New[*keySet](nil)
+		if err := rotator.loadOrCreateKeys(context.Background()); err != nil {
+			return nil, err
+		}
+
+		return rotator, nil
+	}
+
+	// We start with generating a key and keeping it in container[key].
+	keyPair, err := generateNewKeyPairWithID(idGenerator, rotator.algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	rotator.keyContainer = container.New[*keySet](&keySet{current: keyPair, createdAt: rotator.now()})
+
+	return rotator, nil
 }
 
-func generateNewKeyPairWithID(idGenerator PublicKeyIDGenerator) (*key, error) {
-	keyPair, err := GenerateKeyPair()
+func generateNewKeyPairWithID(idGenerator PublicKeyIDGenerator, algorithm Algorithm) (*key, error) {
+	keyPair, err := GenerateKeyPairFor(algorithm)
 	if err != nil {
 		return nil, err
 	}
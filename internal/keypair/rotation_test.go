@@ -64,4 +64,34 @@ func TestNewSigningKeyRotator(t *testing.T) {
 		rotatingKeyPair.Rotate()
 		assert.Equal(t, "key_7", rotatingKeyPair.GetLatest().ID)
 	})
+	t.Run("keeps rotated out public keys within retention", func(t *testing.T) {
+		rotatingKeyPair, err := keypair.NewSigningKeyRotator(getIDGenerator("key_%d", nil))
+		assert.NoError(t, err)
+		rotatingKeyPair.Rotate()
+		rotatingKeyPair.Rotate()
+
+		var ids []string
+		for _, publicKey := range rotatingKeyPair.GetPublicKeys() {
+			assert.Contains(t, publicKey.Key, "PUBLIC KEY")
+			ids = append(ids, publicKey.ID)
+		}
+		assert.Equal(t, []string{"key_3", "key_2", "key_1"}, ids)
+
+		publicKey, found := rotatingKeyPair.GetPublicKey("key_2")
+		assert.True(t, found)
+		assert.Equal(t, "key_2", publicKey.ID)
+	})
+
+	t.Run("drops rotated out public keys past retention", func(t *testing.T) {
+		rotatingKeyPair, err := keypair.NewSigningKeyRotator(getIDGenerator("key_%d", nil), keypair.WithRetention(0))
+		assert.NoError(t, err)
+		rotatingKeyPair.Rotate()
+
+		publicKeys := rotatingKeyPair.GetPublicKeys()
+		assert.Len(t, publicKeys, 1)
+		assert.Equal(t, "key_2", publicKeys[0].ID)
+
+		_, found := rotatingKeyPair.GetPublicKey("key_1")
+		assert.False(t, found)
+	})
 }
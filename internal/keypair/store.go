@@ -0,0 +1,27 @@
+package keypair
+
+import (
+	"context"
+	"time"
+)
+
+// StoredKey is a signing key as kept in a KeyStore. The active key has no RetiredAt.
+type StoredKey struct {
+	ID         string
+	PrivateKey string
+	PublicKey  string
+	Algorithm  Algorithm
+	CreatedAt  time.Time
+	RetiredAt  *time.Time
+}
+
+// KeyStore persists signing keys so every replica signs with the same key and verifies tokens signed by the others.
+type KeyStore interface {
+	// LoadKeys returns the active key and the keys retired after retiredSince, newest first.
+	LoadKeys(ctx context.Context, retiredSince time.Time) ([]StoredKey, error)
+	// SaveKey stores key as the active key, retiring the previously active one at key.CreatedAt.
+	SaveKey(ctx context.Context, key StoredKey) error
+	// WithRotationLock runs fn while holding a lock shared by every replica. It reports false without
+	// running fn when another replica holds the lock.
+	WithRotationLock(ctx context.Context, fn func() error) (bool, error)
+}
@@ -0,0 +1,175 @@
+package keypair_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/internal/keypair"
+)
+
+// fakeKeyStore stands in for the database shared by replicas.
+type fakeKeyStore struct {
+	mu      sync.Mutex
+	keys    []keypair.StoredKey
+	locked  bool
+	loadErr error
+}
+
+func (s *fakeKeyStore) LoadKeys(ctx context.Context, retiredSince time.Time) ([]keypair.StoredKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.loadErr != nil {
+		return nil, s.loadErr
+	}
+
+	var keys []keypair.StoredKey
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if s.keys[i].RetiredAt == nil || s.keys[i].RetiredAt.After(retiredSince) {
+			keys = append(keys, s.keys[i])
+		}
+	}
+
+	return keys, nil
+}
+
+func (s *fakeKeyStore) SaveKey(ctx context.Context, key keypair.StoredKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.keys {
+		if s.keys[i].RetiredAt == nil {
+			retiredAt := key.CreatedAt
+			s.keys[i].RetiredAt = &retiredAt
+		}
+	}
+	s.keys = append(s.keys, key)
+
+	return nil
+}
+
+func (s *fakeKeyStore) WithRotationLock(ctx context.Context, fn func() error) (bool, error) {
+	s.mu.Lock()
+	if s.locked {
+		s.mu.Unlock()
+
+		return false, nil
+	}
+	s.locked = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.locked = false
+		s.mu.Unlock()
+	}()
+
+	return true, fn()
+}
+
+func TestNewSigningKeyRotatorWithStore(t *testing.T) {
+	t.Run("replicas share the key the first one created", func(t *testing.T) {
+		store := &fakeKeyStore{}
+		first, err := keypair.NewSigningKeyRotator(getIDGenerator("key_%d", nil), keypair.WithStore(store, 0))
+		assert.NoError(t, err)
+		second, err := keypair.NewSigningKeyRotator(getIDGenerator("other_%d", nil), keypair.WithStore(store, 0))
+		assert.NoError(t, err)
+
+		assert.Equal(t, "key_1", first.GetLatest().ID)
+		assert.Equal(t, first.GetLatest(), second.GetLatest())
+		assert.Len(t, store.keys, 1)
+	})
+
+	t.Run("replicas pick up a key rotated elsewhere when they see it", func(t *testing.T) {
+		store := &fakeKeyStore{}
+		first, err := keypair.NewSigningKeyRotator(getIDGenerator("key_%d", nil), keypair.WithStore(store, 0))
+		assert.NoError(t, err)
+		second, err := keypair.NewSigningKeyRotator(getIDGenerator("other_%d", nil), keypair.WithStore(store, 0))
+		assert.NoError(t, err)
+
+		first.Rotate()
+		assert.Equal(t, "key_2", first.GetLatest().ID)
+		assert.Equal(t, "key_1", second.GetLatest().ID)
+
+		publicKey, found := second.GetPublicKey("key_2")
+		assert.True(t, found)
+		assert.Equal(t, "key_2", publicKey.ID)
+		assert.Equal(t, "key_2", second.GetLatest().ID)
+
+		_, found = second.GetPublicKey("key_1")
+		assert.True(t, found, "the rotated out key is retained")
+	})
+
+	t.Run("does not rotate while another replica holds the lock", func(t *testing.T) {
+		store := &fakeKeyStore{}
+		rotator, err := keypair.NewSigningKeyRotator(getIDGenerator("key_%d", nil), keypair.WithStore(store, 0))
+		assert.NoError(t, err)
+
+		store.locked = true
+		rotator.Rotate()
+
+		assert.Equal(t, "key_1", rotator.GetLatest().ID)
+		assert.Len(t, store.keys, 1)
+	})
+
+	t.Run("retries a failed rotation on the next sync", func(t *testing.T) {
+		count := 0
+		failingOnce := keypair.PublicKeyIDGenerator(func(publicKey string) (string, error) {
+			count = count + 1
+			if count == 2 {
+				return "", errors.New("key service unavailable")
+			}
+
+			return fmt.Sprintf("key_%d", count), nil
+		})
+
+		store := &fakeKeyStore{}
+		rotator, err := keypair.NewSigningKeyRotator(
+			failingOnce,
+			keypair.WithAlgorithm(keypair.EdDSA),
+			keypair.WithStore(store, 10*time.Millisecond),
+		)
+		assert.NoError(t, err)
+
+		rotator.RotateInBackground(time.Hour)
+		store.mu.Lock()
+		store.keys[0].CreatedAt = store.keys[0].CreatedAt.Add(-2 * time.Hour)
+		store.mu.Unlock()
+
+		assert.Eventually(t, func() bool {
+			return rotator.GetLatest().ID == "key_3"
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("fails to start when the store cannot be read", func(t *testing.T) {
+		store := &fakeKeyStore{loadErr: errors.New("connection refused")}
+		rotator, err := keypair.NewSigningKeyRotator(getIDGenerator("key_%d", nil), keypair.WithStore(store, 0))
+		assert.Nil(t, rotator)
+		assert.Error(t, err)
+	})
+	t.Run("switches to the configured algorithm on startup and keeps verifying the old key", func(t *testing.T) {
+		store := &fakeKeyStore{}
+		_, err := keypair.NewSigningKeyRotator(getIDGenerator("rsa_%d", nil), keypair.WithStore(store, 0))
+		assert.NoError(t, err)
+
+		rotator, err := keypair.NewSigningKeyRotator(
+			getIDGenerator("ec_%d", nil),
+			keypair.WithStore(store, 0),
+			keypair.WithAlgorithm(keypair.ES256),
+		)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "ec_1", rotator.GetLatest().ID)
+		assert.Equal(t, keypair.ES256, rotator.GetLatest().Algorithm)
+
+		publicKey, found := rotator.GetPublicKey("rsa_1")
+		assert.True(t, found)
+		assert.Equal(t, keypair.RS256, publicKey.Algorithm)
+	})
+}
@@ -4,9 +4,20 @@ type key struct {
 	PrivateKey string
 	PublicKey  string
 	ID         string
+	Algorithm  Algorithm
 }
 
+// SigningKey holds a PEM encoded private key. An empty Algorithm means RS256, which all keys used before
+// the algorithm became configurable sign with.
 type SigningKey struct {
-	Key string
-	ID  string
+	Key       string
+	ID        string
+	Algorithm Algorithm
+}
+
+// PublicKey holds a PEM encoded public key and the ID tokens signed with its private key carry.
+type PublicKey struct {
+	Key       string
+	ID        string
+	Algorithm Algorithm
 }
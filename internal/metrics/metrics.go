@@ -83,6 +83,71 @@ func initMetrics(prometheusInstance *PrometheusClient) {
 		"Total number of session operations",
 		[]string{"service", "operation", "result", "env"},
 	)
+
+	// Lockout metrics
+	prometheusInstance.CreateCounterVec(
+		"account_lockouts_total",
+		"Total number of usernames or source IPs locked after failed sign ins",
+		[]string{"service", "scope", "env"},
+	)
+
+	// Signing key metrics
+	prometheusInstance.CreateCounterVec(
+		"key_publish_attempts_total",
+		"Total number of attempts to publish a signing key",
+		[]string{"service", "result", "env"},
+	)
+	prometheusInstance.CreateCounterVec(
+		"signing_key_rotations_total",
+		"Total number of signing key rotations",
+		[]string{"service", "result", "env"},
+	)
+	prometheusInstance.CreateGaugeVec(
+		"signing_key_age_seconds",
+		"Age of the active signing key in seconds",
+		[]string{"service", "env"},
+	)
+	prometheusInstance.CreateGaugeVec(
+		"signing_key_max_age_exceeded",
+		"Whether the active signing key is older than its max age because rotation has stalled",
+		[]string{"service", "env"},
+	)
+
+	// Janitor metrics
+	prometheusInstance.CreateCounterVec(
+		"janitor_rows_removed_total",
+		"Total number of expired or stale rows removed by the janitor",
+		[]string{"service", "task", "env"},
+	)
+	prometheusInstance.CreateCounterVec(
+		"janitor_runs_total",
+		"Total number of janitor runs",
+		[]string{"service", "result", "env"},
+	)
+
+	// Rate limit metrics
+	prometheusInstance.CreateCounterVec(
+		"rate_limited_requests_total",
+		"Total number of GraphQL operations rejected by a rate limit",
+		[]string{"service", "operation", "key", "env"},
+	)
+
+	// Breached password metrics
+	prometheusInstance.CreateGaugeVec(
+		"breached_password_filter_false_positive_rate",
+		"Estimated false positive rate of the loaded breached password filter",
+		[]string{"service", "env"},
+	)
+	prometheusInstance.CreateGaugeVec(
+		"breached_password_filter_entries",
+		"Number of hashes in the loaded breached password filter",
+		[]string{"service", "env"},
+	)
+	prometheusInstance.CreateCounterVec(
+		"breached_password_checks_total",
+		"Total number of passwords checked against the breached password filter",
+		[]string{"service", "result", "env"},
+	)
 }
 
 func (m *AppMetrics) ResolverMetric(duration float64, resolver string, result string) {
@@ -136,4 +201,90 @@ func (m *AppMetrics) SessionOperationMetric(operation, result string) {
 		"env":       m.defaultTags["env"],
 	}
 	m.prometheus.IncrementCounter("session_operations_total", labels)
-}
\ No newline at end of file
+}
+
+func (m *AppMetrics) BreachedPasswordFilterMetric(falsePositiveRate float64, entries uint64) {
+	labels := prometheus.Labels{
+		"service": m.defaultTags["service"],
+		"env":     m.defaultTags["env"],
+	}
+	m.prometheus.SetGauge("breached_password_filter_false_positive_rate", falsePositiveRate, labels)
+	m.prometheus.SetGauge("breached_password_filter_entries", float64(entries), labels)
+}
+
+func (m *AppMetrics) BreachedPasswordCheckMetric(result string) {
+	labels := prometheus.Labels{
+		"service": m.defaultTags["service"],
+		"result":  result,
+		"env":     m.defaultTags["env"],
+	}
+	m.prometheus.IncrementCounter("breached_password_checks_total", labels)
+}
+
+func (m *AppMetrics) AccountLockoutMetric(scope string) {
+	labels := prometheus.Labels{
+		"service": m.defaultTags["service"],
+		"scope":   scope,
+		"env":     m.defaultTags["env"],
+	}
+	m.prometheus.IncrementCounter("account_lockouts_total", labels)
+}
+
+func (m *AppMetrics) RateLimitedMetric(operation, key string) {
+	labels := prometheus.Labels{
+		"service":   m.defaultTags["service"],
+		"operation": operation,
+		"key":       key,
+		"env":       m.defaultTags["env"],
+	}
+	m.prometheus.IncrementCounter("rate_limited_requests_total", labels)
+}
+
+func (m *AppMetrics) KeyPublishMetric(result string) {
+	labels := prometheus.Labels{
+		"service": m.defaultTags["service"],
+		"result":  result,
+		"env":     m.defaultTags["env"],
+	}
+	m.prometheus.IncrementCounter("key_publish_attempts_total", labels)
+}
+
+func (m *AppMetrics) SigningKeyRotationMetric(result string) {
+	labels := prometheus.Labels{
+		"service": m.defaultTags["service"],
+		"result":  result,
+		"env":     m.defaultTags["env"],
+	}
+	m.prometheus.IncrementCounter("signing_key_rotations_total", labels)
+}
+
+func (m *AppMetrics) SigningKeyAgeMetric(age float64, maxAgeExceeded bool) {
+	labels := prometheus.Labels{
+		"service": m.defaultTags["service"],
+		"env":     m.defaultTags["env"],
+	}
+	exceeded := 0.0
+	if maxAgeExceeded {
+		exceeded = 1
+	}
+	m.prometheus.SetGauge("signing_key_age_seconds", age, labels)
+	m.prometheus.SetGauge("signing_key_max_age_exceeded", exceeded, labels)
+}
+
+func (m *AppMetrics) JanitorRowsRemovedMetric(task string, rows int64) {
+	labels := prometheus.Labels{
+		"service": m.defaultTags["service"],
+		"task":    task,
+		"env":     m.defaultTags["env"],
+	}
+	m.prometheus.AddCounter("janitor_rows_removed_total", float64(rows), labels)
+}
+
+func (m *AppMetrics) JanitorRunMetric(result string) {
+	labels := prometheus.Labels{
+		"service": m.defaultTags["service"],
+		"result":  result,
+		"env":     m.defaultTags["env"],
+	}
+	m.prometheus.IncrementCounter("janitor_runs_total", labels)
+}
@@ -112,6 +112,15 @@ func (p *PrometheusClient) IncrementCounter(name string, labels prometheus.Label
 	}
 }
 
+func (p *PrometheusClient) AddCounter(name string, value float64, labels prometheus.Labels) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if counter, exists := p.counters[name]; exists {
+		counter.With(labels).Add(value)
+	}
+}
+
 func (p *PrometheusClient) SetGauge(name string, value float64, labels prometheus.Labels) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/weeb-vip/auth/http/handlers/requestinfo"
+	"github.com/weeb-vip/auth/internal/logger"
+	"github.com/weeb-vip/auth/internal/metrics"
+	"github.com/weeb-vip/auth/internal/ratelimit"
+	"github.com/weeb-vip/auth/internal/xerrors"
+)
+
+// RateLimitKey is what requests are grouped by when counting them against a rule.
+type RateLimitKey string
+
+const (
+	RateLimitKeyIP       RateLimitKey = "ip"
+	RateLimitKeyUsername RateLimitKey = "username"
+	RateLimitKeyUserID   RateLimitKey = "user_id"
+)
+
+type RateLimitRule struct {
+	Operation string
+	Key       RateLimitKey
+	Rule      ratelimit.Rule
+}
+
+// ParseRateLimitRules reads rules in the "Operation:key=limit/window,key=limit/window;Operation:..." format
+// used by config.RateLimitConfig, applying algorithm to each of them.
+func ParseRateLimitRules(spec string, algorithm ratelimit.Algorithm) ([]RateLimitRule, error) {
+	var rules []RateLimitRule
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		operation, limits, found := strings.Cut(entry, ":")
+		operation = strings.TrimSpace(operation)
+		if !found || operation == "" {
+			return nil, fmt.Errorf("rate limit rule %q has no operation", entry)
+		}
+
+		for _, limit := range strings.Split(limits, ",") {
+			rule, err := parseRateLimit(operation, strings.TrimSpace(limit), algorithm)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, nil
+}
+
+func parseRateLimit(operation string, limit string, algorithm ratelimit.Algorithm) (RateLimitRule, error) {
+	key, value, found := strings.Cut(limit, "=")
+	if !found {
+		return RateLimitRule{}, fmt.Errorf("rate limit %q for %s is not key=limit/window", limit, operation)
+	}
+
+	switch RateLimitKey(key) {
+	case RateLimitKeyIP, RateLimitKeyUsername, RateLimitKeyUserID:
+	default:
+		return RateLimitRule{}, fmt.Errorf("rate limit %q for %s has unknown key %q", limit, operation, key)
+	}
+
+	count, window, found := strings.Cut(value, "/")
+	if !found {
+		return RateLimitRule{}, fmt.Errorf("rate limit %q for %s is not key=limit/window", limit, operation)
+	}
+
+	requests, err := strconv.Atoi(count)
+	if err != nil || requests <= 0 {
+		return RateLimitRule{}, fmt.Errorf("rate limit %q for %s has an invalid limit", limit, operation)
+	}
+
+	duration, err := time.ParseDuration(window)
+	if err != nil || duration <= 0 {
+		return RateLimitRule{}, fmt.Errorf("rate limit %q for %s has an invalid window", limit, operation)
+	}
+
+	return RateLimitRule{
+		Operation: operation,
+		Key:       RateLimitKey(key),
+		Rule:      ratelimit.Rule{Algorithm: algorithm, Limit: requests, Window: duration},
+	}, nil
+}
+
+// RateLimitExtension rejects root Query and Mutation fields with a RATE_LIMITED error once a rule
+// for that field is exhausted. Failures of the limiter itself are logged and let the request through.
+type RateLimitExtension struct {
+	limiter *ratelimit.Limiter
+	rules   map[string][]RateLimitRule
+}
+
+func NewRateLimitExtension(limiter *ratelimit.Limiter, rules []RateLimitRule) *RateLimitExtension {
+	byOperation := make(map[string][]RateLimitRule)
+	for _, rule := range rules {
+		byOperation[rule.Operation] = append(byOperation[rule.Operation], rule)
+	}
+
+	return &RateLimitExtension{
+		limiter: limiter,
+		rules:   byOperation,
+	}
+}
+
+func (e *RateLimitExtension) ExtensionName() string {
+	return "RateLimit"
+}
+
+func (e *RateLimitExtension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (e *RateLimitExtension) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || (fc.Object != "Mutation" && fc.Object != "Query") {
+		return next(ctx)
+	}
+
+	rules, found := e.rules[fc.Field.Name]
+	if !found {
+		return next(ctx)
+	}
+
+	log := logger.FromCtx(ctx)
+	for _, rule := range rules {
+		subject := rateLimitSubject(ctx, fc, rule.Key)
+		if subject == "" {
+			continue
+		}
+
+		result, err := e.limiter.Allow(ctx, rule.Operation+":"+string(rule.Key)+":"+subject, rule.Rule)
+		if err != nil {
+			log.Error().Err(err).Str("operation", rule.Operation).Str("key", string(rule.Key)).Msg("Rate limit check failed")
+
+			continue
+		}
+
+		if !result.Allowed {
+			log.Warn().
+				Str("operation", rule.Operation).
+				Str("key", string(rule.Key)).
+				Dur("retry_after", result.RetryAfter).
+				Msg("GraphQL operation rate limited")
+			metrics.GetAppMetrics().RateLimitedMetric(rule.Operation, string(rule.Key))
+
+			retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+
+			return nil, xerrors.RateLimitedError("too many requests, try again later", max(retryAfter, 1))
+		}
+	}
+
+	return next(ctx)
+}
+
+func rateLimitSubject(ctx context.Context, fc *graphql.FieldContext, key RateLimitKey) string {
+	switch key {
+	case RateLimitKeyIP:
+		return requestinfo.RemoteIP(ctx)
+	case RateLimitKeyUserID:
+		return requestinfo.UserID(ctx)
+	case RateLimitKeyUsername:
+		var variables map[string]interface{}
+		if graphql.HasOperationContext(ctx) {
+			variables = graphql.GetOperationContext(ctx).Variables
+		}
+
+		// Operations take the username either directly or on their input object.
+		var username string
+		for _, argument := range fc.Field.Arguments {
+			value, err := argument.Value.Value(variables)
+			if err != nil {
+				continue
+			}
+
+			switch argument.Name {
+			case "username":
+				username, _ = value.(string)
+			case "input":
+				if input, ok := value.(map[string]interface{}); ok && username == "" {
+					username, _ = input["username"].(string)
+				}
+			}
+		}
+
+		return strings.ToLower(strings.TrimSpace(username))
+	default:
+		return ""
+	}
+}
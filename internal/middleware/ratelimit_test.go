@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/http/handlers/requestinfo"
+	"github.com/weeb-vip/auth/internal/ratelimit"
+)
+
+func requestContext(t *testing.T, remoteIP string) context.Context {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	req.Header.Set("x-remote-ip", remoteIP)
+
+	var ctx context.Context
+	requestinfo.Handler()(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ctx = request.Context()
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	return ctx
+}
+
+func createSessionContext(ctx context.Context, username string) context.Context {
+	ctx = graphql.WithOperationContext(ctx, &graphql.OperationContext{
+		Variables: map[string]interface{}{"input": map[string]interface{}{"username": username}},
+	})
+
+	return graphql.WithFieldContext(ctx, &graphql.FieldContext{
+		Object: "Mutation",
+		Field: graphql.CollectedField{Field: &ast.Field{
+			Name:      "CreateSession",
+			Arguments: ast.ArgumentList{{Name: "input", Value: &ast.Value{Kind: ast.Variable, Raw: "input"}}},
+		}},
+	})
+}
+
+func TestParseRateLimitRules(t *testing.T) {
+	rules, err := ParseRateLimitRules(" CreateSession:ip=30/1m,username=10/1m; Register:ip=5/1h;", ratelimit.TokenBucket)
+	assert.NoError(t, err)
+	assert.Equal(t, []RateLimitRule{
+		{Operation: "CreateSession", Key: RateLimitKeyIP, Rule: ratelimit.Rule{Algorithm: ratelimit.TokenBucket, Limit: 30, Window: time.Minute}},
+		{Operation: "CreateSession", Key: RateLimitKeyUsername, Rule: ratelimit.Rule{Algorithm: ratelimit.TokenBucket, Limit: 10, Window: time.Minute}},
+		{Operation: "Register", Key: RateLimitKeyIP, Rule: ratelimit.Rule{Algorithm: ratelimit.TokenBucket, Limit: 5, Window: time.Hour}},
+	}, rules)
+
+	for _, spec := range []string{"ip=30/1m", "CreateSession:email=3/1m", "CreateSession:ip=0/1m", "CreateSession:ip=3/soon", "CreateSession:ip=3"} {
+		_, err := ParseRateLimitRules(spec, ratelimit.SlidingWindow)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestDefaultRateLimitRules(t *testing.T) {
+	field, _ := reflect.TypeOf(config.RateLimitConfig{}).FieldByName("Rules")
+
+	rules, err := ParseRateLimitRules(field.Tag.Get("default"), ratelimit.SlidingWindow)
+	assert.NoError(t, err)
+
+	keys := make(map[string][]RateLimitKey)
+	for _, rule := range rules {
+		keys[rule.Operation] = append(keys[rule.Operation], rule.Key)
+	}
+
+	// Unauthenticated operations that check a secret, send mail or write rows are limited by IP, and by
+	// username where they take one.
+	for _, operation := range []string{
+		"CreateSession",
+		"Register",
+		"RequestPasswordReset",
+		"ResetPassword",
+		"ResendVerificationEmail",
+		"CompleteMFAChallenge",
+		"RequestAccountUnlock",
+		"UnlockAccount",
+		"BeginPasskeyLogin",
+		"FinishPasskeyLogin",
+	} {
+		assert.Contains(t, keys[operation], RateLimitKeyIP, operation)
+	}
+
+	for _, operation := range []string{"CreateSession", "RequestPasswordReset", "ResetPassword", "ResendVerificationEmail", "RequestAccountUnlock"} {
+		assert.Contains(t, keys[operation], RateLimitKeyUsername, operation)
+	}
+}
+
+func TestRateLimitExtension(t *testing.T) {
+	next := func(ctx context.Context) (interface{}, error) {
+		return true, nil
+	}
+
+	t.Run("limits an operation by username across IPs", func(t *testing.T) {
+		rules, err := ParseRateLimitRules("CreateSession:username=2/1m", ratelimit.SlidingWindow)
+		assert.NoError(t, err)
+		extension := NewRateLimitExtension(ratelimit.NewLimiter(ratelimit.NewMemoryStore()), rules)
+
+		for _, ip := range []string{"203.0.113.7", "198.51.100.1"} {
+			result, err := extension.InterceptField(createSessionContext(requestContext(t, ip), "user@weeb.vip"), next)
+			assert.NoError(t, err)
+			assert.Equal(t, true, result)
+		}
+
+		_, err = extension.InterceptField(createSessionContext(requestContext(t, "192.0.2.1"), " User@Weeb.vip"), next)
+		var gqlErr *gqlerror.Error
+		if assert.ErrorAs(t, err, &gqlErr) {
+			assert.Equal(t, "RATE_LIMITED", gqlErr.Extensions["code"])
+			assert.Positive(t, gqlErr.Extensions["retryAfter"])
+		}
+
+		result, err := extension.InterceptField(createSessionContext(requestContext(t, "192.0.2.1"), "other@weeb.vip"), next)
+		assert.NoError(t, err)
+		assert.Equal(t, true, result)
+	})
+
+	t.Run("limits an operation by IP", func(t *testing.T) {
+		rules, err := ParseRateLimitRules("CreateSession:ip=1/1m", ratelimit.TokenBucket)
+		assert.NoError(t, err)
+		extension := NewRateLimitExtension(ratelimit.NewLimiter(ratelimit.NewMemoryStore()), rules)
+
+		_, err = extension.InterceptField(createSessionContext(requestContext(t, "203.0.113.7"), "user@weeb.vip"), next)
+		assert.NoError(t, err)
+
+		_, err = extension.InterceptField(createSessionContext(requestContext(t, "203.0.113.7"), "other@weeb.vip"), next)
+		assert.Error(t, err)
+	})
+
+	t.Run("ignores operations and keys without a rule or subject", func(t *testing.T) {
+		rules, err := ParseRateLimitRules("Register:ip=1/1m;CreateSession:user_id=1/1m", ratelimit.TokenBucket)
+		assert.NoError(t, err)
+		extension := NewRateLimitExtension(ratelimit.NewLimiter(ratelimit.NewMemoryStore()), rules)
+
+		for i := 0; i < 3; i++ {
+			_, err := extension.InterceptField(createSessionContext(requestContext(t, "203.0.113.7"), "user@weeb.vip"), next)
+			assert.NoError(t, err)
+		}
+	})
+}
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS totp_secrets
+(
+    id             VARCHAR(100) PRIMARY KEY,
+    user_id        VARCHAR(100) NOT NULL,
+    secret         VARCHAR(255) NOT NULL,
+    enabled        BOOLEAN      NOT NULL DEFAULT FALSE,
+    last_used_step BIGINT       NOT NULL DEFAULT 0,
+    created_at     timestamp    NOT NULL,
+    updated_at     timestamp    NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_totp_secrets_user_id ON totp_secrets(user_id);
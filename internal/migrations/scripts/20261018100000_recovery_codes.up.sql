@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS recovery_codes
+(
+    id         VARCHAR(100) PRIMARY KEY,
+    user_id    VARCHAR(100) NOT NULL,
+    code_hash  VARCHAR(255) NOT NULL,
+    used_at    timestamp    NULL,
+    created_at timestamp    NOT NULL,
+    updated_at timestamp    NOT NULL
+);
+
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes(user_id);
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS webauthn_credentials
+(
+    id               VARCHAR(100)   PRIMARY KEY,
+    user_id          VARCHAR(100)   NOT NULL,
+    credential_id    VARCHAR(1400)  NOT NULL,
+    public_key       BLOB           NOT NULL,
+    attestation_type VARCHAR(64)    NOT NULL,
+    aaguid           VARBINARY(16)  NULL,
+    sign_count       INT UNSIGNED   NOT NULL DEFAULT 0,
+    clone_warning    BOOLEAN        NOT NULL DEFAULT FALSE,
+    transports       VARCHAR(255)   NOT NULL DEFAULT '',
+    backup_eligible  BOOLEAN        NOT NULL DEFAULT FALSE,
+    backup_state     BOOLEAN        NOT NULL DEFAULT FALSE,
+    name             VARCHAR(100)   NOT NULL,
+    last_used_at     timestamp      NULL,
+    created_at       timestamp      NOT NULL,
+    updated_at       timestamp      NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_webauthn_credentials_credential_id ON webauthn_credentials(credential_id(255));
+CREATE INDEX idx_webauthn_credentials_user_id ON webauthn_credentials(user_id);
+
+CREATE TABLE IF NOT EXISTS webauthn_ceremonies
+(
+    id         VARCHAR(100) PRIMARY KEY,
+    user_id    VARCHAR(100) NOT NULL DEFAULT '',
+    kind       VARCHAR(32)  NOT NULL,
+    data       TEXT         NOT NULL,
+    expires_at timestamp    NOT NULL,
+    created_at timestamp    NOT NULL,
+    updated_at timestamp    NOT NULL
+);
+
+CREATE INDEX idx_webauthn_ceremonies_expires_at ON webauthn_ceremonies(expires_at);
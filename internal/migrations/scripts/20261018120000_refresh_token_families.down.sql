@@ -0,0 +1,4 @@
+DROP INDEX idx_refresh_tokens_family_id ON refresh_tokens;
+
+ALTER TABLE refresh_tokens DROP COLUMN used_at;
+ALTER TABLE refresh_tokens DROP COLUMN family_id;
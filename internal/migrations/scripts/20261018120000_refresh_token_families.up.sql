@@ -0,0 +1,7 @@
+ALTER TABLE refresh_tokens ADD COLUMN family_id VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE refresh_tokens ADD COLUMN used_at timestamp NULL;
+
+-- Every existing token becomes the first token of its own family.
+UPDATE refresh_tokens SET family_id = id WHERE family_id = '';
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens(family_id);
@@ -0,0 +1,3 @@
+-- Hashed rows are useless to a version that compares plaintext tokens.
+DELETE FROM refresh_tokens;
+DELETE FROM password_resets;
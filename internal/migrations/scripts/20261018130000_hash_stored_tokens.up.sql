@@ -0,0 +1,5 @@
+-- Tokens are now stored as an HMAC keyed with a secret that is not available to SQL, so existing plaintext
+-- rows cannot be rehashed in place. They are invalidated instead: users sign in again and pending password
+-- reset links have to be requested again.
+DELETE FROM refresh_tokens;
+DELETE FROM password_resets;
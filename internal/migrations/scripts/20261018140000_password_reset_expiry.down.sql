@@ -0,0 +1,4 @@
+DROP INDEX idx_password_resets_credential_id_created_at ON password_resets;
+
+ALTER TABLE password_resets DROP COLUMN invalidated_at;
+ALTER TABLE password_resets DROP COLUMN expires_at;
@@ -0,0 +1,5 @@
+-- Pending tokens predate the expiry and are treated as expired.
+ALTER TABLE password_resets ADD COLUMN expires_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE password_resets ADD COLUMN invalidated_at timestamp NULL;
+
+CREATE INDEX idx_password_resets_credential_id_created_at ON password_resets(credential_id, created_at);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS login_failures
+(
+    id                      VARCHAR(100) PRIMARY KEY,
+    scope                   VARCHAR(20)  NOT NULL,
+    subject                 VARCHAR(255) NOT NULL,
+    failures                INT          NOT NULL DEFAULT 0,
+    last_failed_at          timestamp    NULL,
+    locked_until            timestamp    NULL,
+    unlock_token            VARCHAR(100) NULL,
+    unlock_token_expires_at timestamp    NULL,
+    created_at              timestamp    NOT NULL,
+    updated_at              timestamp    NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_login_failures_scope_subject ON login_failures(scope, subject);
+CREATE UNIQUE INDEX idx_login_failures_unlock_token ON login_failures(unlock_token);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS signing_keys
+(
+    id          VARCHAR(100) PRIMARY KEY,
+    private_key TEXT         NOT NULL,
+    public_key  TEXT         NOT NULL,
+    retired_at  timestamp    NULL,
+    created_at  timestamp    NOT NULL,
+    updated_at  timestamp    NOT NULL
+);
+
+CREATE INDEX idx_signing_keys_retired_at ON signing_keys(retired_at);
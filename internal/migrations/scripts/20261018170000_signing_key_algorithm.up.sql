@@ -0,0 +1 @@
+ALTER TABLE signing_keys ADD COLUMN algorithm VARCHAR(10) NOT NULL DEFAULT 'RS256' AFTER public_key;
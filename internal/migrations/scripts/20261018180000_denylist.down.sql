@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `denied_subjects`;
+DROP TABLE IF EXISTS `denied_tokens`;
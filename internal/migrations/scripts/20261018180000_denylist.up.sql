@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS denied_tokens
+(
+    id         VARCHAR(100) PRIMARY KEY,
+    expires_at timestamp    NOT NULL,
+    created_at timestamp    NOT NULL,
+    updated_at timestamp    NOT NULL
+);
+
+CREATE INDEX idx_denied_tokens_expires_at ON denied_tokens(expires_at);
+
+CREATE TABLE IF NOT EXISTS denied_subjects
+(
+    id            VARCHAR(100) PRIMARY KEY,
+    issued_before timestamp    NOT NULL,
+    expires_at    timestamp    NOT NULL,
+    created_at    timestamp    NOT NULL,
+    updated_at    timestamp    NOT NULL
+);
+
+CREATE INDEX idx_denied_subjects_expires_at ON denied_subjects(expires_at);
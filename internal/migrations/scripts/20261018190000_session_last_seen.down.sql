@@ -0,0 +1,3 @@
+DROP INDEX idx_sessions_user_id_last_seen_at ON sessions;
+
+ALTER TABLE sessions DROP COLUMN last_seen_at;
@@ -0,0 +1,8 @@
+ALTER TABLE sessions ADD COLUMN last_seen_at timestamp NULL;
+
+-- Existing sessions were last seen when they were last updated.
+UPDATE sessions SET last_seen_at = updated_at;
+
+ALTER TABLE sessions MODIFY COLUMN last_seen_at timestamp NOT NULL;
+
+CREATE INDEX idx_sessions_user_id_last_seen_at ON sessions(user_id, last_seen_at);
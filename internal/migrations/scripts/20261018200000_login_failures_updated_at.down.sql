@@ -0,0 +1 @@
+DROP INDEX idx_login_failures_updated_at ON login_failures;
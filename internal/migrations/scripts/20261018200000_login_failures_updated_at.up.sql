@@ -0,0 +1 @@
+CREATE INDEX idx_login_failures_updated_at ON login_failures(updated_at);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS denied_sessions
+(
+    id         VARCHAR(100) PRIMARY KEY,
+    expires_at timestamp    NOT NULL,
+    created_at timestamp    NOT NULL,
+    updated_at timestamp    NOT NULL
+);
+
+CREATE INDEX idx_denied_sessions_expires_at ON denied_sessions(expires_at);
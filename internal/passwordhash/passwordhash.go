@@ -0,0 +1,57 @@
+package passwordhash
+
+import (
+	"errors"
+)
+
+var (
+	ErrUnknownAlgorithm = errors.New("unknown password hash algorithm")
+	ErrMalformedHash    = errors.New("malformed password hash")
+)
+
+// Algorithm hashes passwords into self describing strings that carry the algorithm and its parameters.
+type Algorithm interface {
+	Name() string
+	Hash(password string) (string, error)
+	Verify(password string, encoded string) (bool, error)
+	// Recognizes reports whether the encoded hash was produced by this algorithm.
+	Recognizes(encoded string) bool
+	// Outdated reports whether a recognized hash was produced with other parameters than the configured ones.
+	Outdated(encoded string) bool
+}
+
+// Hasher hashes with the preferred algorithm and still verifies hashes of the legacy ones,
+// telling the caller when a verified hash should be replaced.
+type Hasher struct {
+	preferred  Algorithm
+	algorithms []Algorithm
+}
+
+func New(preferred Algorithm, legacy ...Algorithm) *Hasher {
+	return &Hasher{
+		preferred:  preferred,
+		algorithms: append([]Algorithm{preferred}, legacy...),
+	}
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	return h.preferred.Hash(password)
+}
+
+// Verify checks the password, needsRehash is only meaningful for a matching password.
+func (h *Hasher) Verify(password string, encoded string) (ok bool, needsRehash bool, err error) {
+	for _, algorithm := range h.algorithms {
+		if !algorithm.Recognizes(encoded) {
+			continue
+		}
+
+		ok, err = algorithm.Verify(password, encoded)
+		if err != nil || !ok {
+			return false, false, err
+		}
+
+		return true, algorithm != h.preferred || algorithm.Outdated(encoded), nil
+	}
+
+	return false, false, ErrUnknownAlgorithm
+}
@@ -0,0 +1,107 @@
+package passwordhash_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/weeb-vip/auth/internal/passwordhash"
+)
+
+// Cheap parameters keep the tests fast, they are not meant for production.
+var testArgon2id = passwordhash.Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2id(t *testing.T) {
+	t.Run("produces a PHC string that verifies", func(t *testing.T) {
+		encoded, err := testArgon2id.Hash("violet-Harbor-92")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$"))
+
+		ok, err := testArgon2id.Verify("violet-Harbor-92", encoded)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = testArgon2id.Verify("violet-Harbor-93", encoded)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("salts every hash", func(t *testing.T) {
+		first, _ := testArgon2id.Hash("violet-Harbor-92")
+		second, _ := testArgon2id.Hash("violet-Harbor-92")
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("verifies with the parameters stored in the hash", func(t *testing.T) {
+		encoded, _ := testArgon2id.Hash("violet-Harbor-92")
+
+		stronger := testArgon2id
+		stronger.Iterations = 2
+
+		ok, err := stronger.Verify("violet-Harbor-92", encoded)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.True(t, stronger.Outdated(encoded))
+		assert.False(t, testArgon2id.Outdated(encoded))
+	})
+
+	t.Run("rejects malformed hashes", func(t *testing.T) {
+		for _, encoded := range []string{
+			"$argon2id$",
+			"$argon2id$v=18$m=64,t=1,p=1$c2FsdA$a2V5",
+			"$argon2id$v=19$m=64,t=0,p=1$c2FsdA$a2V5",
+			"$argon2id$v=19$m=64,t=1,p=1$!!!$a2V5",
+		} {
+			_, err := testArgon2id.Verify("violet-Harbor-92", encoded)
+			assert.ErrorIs(t, err, passwordhash.ErrMalformedHash, encoded)
+		}
+	})
+}
+
+func TestHasher(t *testing.T) {
+	legacy := passwordhash.Bcrypt{Cost: bcrypt.MinCost}
+	hasher := passwordhash.New(testArgon2id, legacy)
+
+	t.Run("hashes with the preferred algorithm", func(t *testing.T) {
+		encoded, err := hasher.Hash("violet-Harbor-92")
+		assert.NoError(t, err)
+		assert.True(t, testArgon2id.Recognizes(encoded))
+
+		ok, needsRehash, err := hasher.Verify("violet-Harbor-92", encoded)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.False(t, needsRehash)
+	})
+
+	t.Run("verifies legacy hashes and asks for a rehash", func(t *testing.T) {
+		encoded, _ := legacy.Hash("violet-Harbor-92")
+
+		ok, needsRehash, err := hasher.Verify("violet-Harbor-92", encoded)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.True(t, needsRehash)
+
+		ok, needsRehash, err = hasher.Verify("violet-Harbor-93", encoded)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		assert.False(t, needsRehash)
+	})
+
+	t.Run("asks for a rehash when the parameters changed", func(t *testing.T) {
+		encoded, _ := hasher.Hash("violet-Harbor-92")
+
+		stronger := testArgon2id
+		stronger.Memory = 128
+
+		_, needsRehash, err := passwordhash.New(stronger, legacy).Verify("violet-Harbor-92", encoded)
+		assert.NoError(t, err)
+		assert.True(t, needsRehash)
+	})
+
+	t.Run("rejects hashes of unknown algorithms", func(t *testing.T) {
+		_, _, err := hasher.Verify("violet-Harbor-92", "$scrypt$ln=15,r=8,p=1$c2FsdA$a2V5")
+		assert.ErrorIs(t, err, passwordhash.ErrUnknownAlgorithm)
+	})
+}
@@ -0,0 +1,73 @@
+package passwordpolicy
+
+import (
+	_ "embed"
+	"strings"
+)
+
+// minDictionaryWordLength skips short entries such as "1234" when looking for common words inside longer passwords.
+const minDictionaryWordLength = 4
+
+//go:embed common_passwords.txt
+var commonPasswordsList string
+
+var commonPasswords, dictionaryWords = parseCommonPasswords(commonPasswordsList)
+
+func parseCommonPasswords(list string) (map[string]struct{}, [][]rune) {
+	passwords := map[string]struct{}{}
+
+	var words [][]rune
+
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		passwords[line] = struct{}{}
+
+		if len([]rune(line)) >= minDictionaryWordLength {
+			words = append(words, []rune(line))
+		}
+	}
+
+	return passwords, words
+}
+
+// isCommon reports whether the password, ignoring case and common character substitutions, is on the denylist.
+func isCommon(password string) bool {
+	lower := strings.ToLower(password)
+	if _, ok := commonPasswords[lower]; ok {
+		return true
+	}
+
+	_, ok := commonPasswords[string(normalize([]rune(lower)))]
+
+	return ok
+}
+
+var substitutions = map[rune]rune{ // nolint
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'@': 'a',
+	'$': 's',
+}
+
+// normalize reverses common leetspeak substitutions rune by rune, so indices still line up with the input.
+func normalize(password []rune) []rune {
+	normalized := make([]rune, len(password))
+
+	for i, r := range password {
+		if substitution, ok := substitutions[r]; ok {
+			r = substitution
+		}
+
+		normalized[i] = r
+	}
+
+	return normalized
+}
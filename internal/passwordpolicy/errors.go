@@ -0,0 +1,63 @@
+package passwordpolicy
+
+import "strings"
+
+// ErrorCode is reported for any password rejected by the policy, the violated rules are listed alongside it.
+const ErrorCode = "PASSWORD_POLICY_VIOLATION"
+
+type Violation string
+
+const (
+	ViolationTooShort         Violation = "PASSWORD_TOO_SHORT"
+	ViolationTooLong          Violation = "PASSWORD_TOO_LONG"
+	ViolationMissingLowercase Violation = "PASSWORD_MISSING_LOWERCASE"
+	ViolationMissingUppercase Violation = "PASSWORD_MISSING_UPPERCASE"
+	ViolationMissingDigit     Violation = "PASSWORD_MISSING_DIGIT"
+	ViolationMissingSymbol    Violation = "PASSWORD_MISSING_SYMBOL"
+	ViolationContainsUsername Violation = "PASSWORD_CONTAINS_USERNAME"
+	ViolationTooCommon        Violation = "PASSWORD_TOO_COMMON"
+	ViolationTooWeak          Violation = "PASSWORD_TOO_WEAK"
+	ViolationBreached         Violation = "PASSWORD_BREACHED"
+	ViolationUnchanged        Violation = "PASSWORD_UNCHANGED"
+)
+
+var violationMessages = map[Violation]string{ // nolint
+	ViolationTooShort:         "is too short",
+	ViolationTooLong:          "is too long",
+	ViolationMissingLowercase: "needs a lowercase letter",
+	ViolationMissingUppercase: "needs an uppercase letter",
+	ViolationMissingDigit:     "needs a digit",
+	ViolationMissingSymbol:    "needs a symbol",
+	ViolationContainsUsername: "must not contain the username",
+	ViolationTooCommon:        "is too common",
+	ViolationTooWeak:          "is too easy to guess",
+	ViolationBreached:         "has appeared in a data breach",
+	ViolationUnchanged:        "must differ from the current password",
+}
+
+func (v Violation) String() string {
+	return string(v)
+}
+
+type Error struct {
+	Violations []Violation
+}
+
+func (e Error) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violationMessages[violation])
+	}
+
+	return "password " + strings.Join(messages, ", ")
+}
+
+// Codes returns the violated rules in the order they were checked.
+func (e Error) Codes() []string {
+	codes := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		codes = append(codes, violation.String())
+	}
+
+	return codes
+}
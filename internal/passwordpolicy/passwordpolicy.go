@@ -0,0 +1,157 @@
+package passwordpolicy
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/metrics"
+)
+
+// minUsernamePartLength keeps short email local parts such as "jo" from rejecting unrelated passwords.
+const minUsernamePartLength = 3
+
+type Policy interface {
+	// Check returns an *Error listing every violated rule, or nil when the password is acceptable.
+	Check(password string, username string) error
+}
+
+// BreachChecker looks a password up in a corpus of passwords exposed by data breaches.
+type BreachChecker interface {
+	IsBreached(password string) bool
+}
+
+type policy struct {
+	config        config.PasswordPolicyConfig
+	breachChecker BreachChecker
+}
+
+// New builds the policy, passwords are only checked for breaches when a breachChecker is given.
+func New(cfg config.PasswordPolicyConfig, breachChecker BreachChecker) Policy {
+	return &policy{
+		config:        cfg,
+		breachChecker: breachChecker,
+	}
+}
+
+func (p *policy) Check(password string, username string) error {
+	var violations []Violation
+
+	if p.config.MinLength > 0 && utf8.RuneCountInString(password) < p.config.MinLength {
+		violations = append(violations, ViolationTooShort)
+	}
+
+	if p.config.MaxLength > 0 && len(password) > p.config.MaxLength {
+		violations = append(violations, ViolationTooLong)
+	}
+
+	violations = append(violations, p.checkCharacterClasses(password)...)
+
+	if p.config.DisallowUsername && containsUsername(password, username) {
+		violations = append(violations, ViolationContainsUsername)
+	}
+
+	if p.config.DisallowCommon && isCommon(password) {
+		violations = append(violations, ViolationTooCommon)
+	}
+
+	if p.config.MinScore > 0 && Score(password, username) < p.config.MinScore {
+		violations = append(violations, ViolationTooWeak)
+	}
+
+	if p.isBreached(password) {
+		violations = append(violations, ViolationBreached)
+	}
+
+	if len(violations) > 0 {
+		return &Error{
+			Violations: violations,
+		}
+	}
+
+	return nil
+}
+
+func (p *policy) isBreached(password string) bool {
+	if p.breachChecker == nil {
+		return false
+	}
+
+	if p.breachChecker.IsBreached(password) {
+		metrics.GetAppMetrics().BreachedPasswordCheckMetric("breached")
+		return true
+	}
+
+	metrics.GetAppMetrics().BreachedPasswordCheckMetric("clean")
+
+	return false
+}
+
+func (p *policy) checkCharacterClasses(password string) []Violation {
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	var violations []Violation
+
+	if p.config.RequireLowercase && !hasLower {
+		violations = append(violations, ViolationMissingLowercase)
+	}
+
+	if p.config.RequireUppercase && !hasUpper {
+		violations = append(violations, ViolationMissingUppercase)
+	}
+
+	if p.config.RequireDigit && !hasDigit {
+		violations = append(violations, ViolationMissingDigit)
+	}
+
+	if p.config.RequireSymbol && !hasSymbol {
+		violations = append(violations, ViolationMissingSymbol)
+	}
+
+	return violations
+}
+
+// containsUsername matches the whole username and, as usernames are emails, the local part of it.
+func containsUsername(password string, username string) bool {
+	password = strings.ToLower(password)
+
+	for _, part := range usernameParts(username) {
+		if strings.Contains(password, part) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func usernameParts(username string) []string {
+	username = strings.ToLower(strings.TrimSpace(username))
+
+	var parts []string
+
+	if utf8.RuneCountInString(username) >= minUsernamePartLength {
+		parts = append(parts, username)
+	}
+
+	if at := strings.LastIndex(username, "@"); at > 0 {
+		if local := username[:at]; utf8.RuneCountInString(local) >= minUsernamePartLength {
+			parts = append(parts, local)
+		}
+	}
+
+	return parts
+}
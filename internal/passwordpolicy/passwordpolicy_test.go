@@ -0,0 +1,142 @@
+package passwordpolicy_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/passwordpolicy"
+)
+
+func defaultConfig() config.PasswordPolicyConfig {
+	return config.PasswordPolicyConfig{
+		MinLength:        8,
+		MaxLength:        72,
+		DisallowUsername: true,
+		DisallowCommon:   true,
+		MinScore:         2,
+	}
+}
+
+func violations(t *testing.T, err error) []string {
+	t.Helper()
+
+	var policyErr *passwordpolicy.Error
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("expected a password policy error, got %v", err)
+	}
+
+	return policyErr.Codes()
+}
+
+func TestPolicyCheck(t *testing.T) {
+	t.Run("accepts a strong password", func(t *testing.T) {
+		policy := passwordpolicy.New(defaultConfig(), nil)
+		assert.NoError(t, policy.Check("violet-Harbor-92-lantern", "user@example.com"))
+	})
+
+	t.Run("reports length violations", func(t *testing.T) {
+		policy := passwordpolicy.New(defaultConfig(), nil)
+
+		assert.Contains(t, violations(t, policy.Check("x9#Lq", "user@example.com")), "PASSWORD_TOO_SHORT")
+		assert.Equal(t,
+			[]string{"PASSWORD_TOO_LONG"},
+			violations(t, policy.Check(strings.Repeat("kT9#xQ2m", 10), "user@example.com")),
+		)
+	})
+
+	t.Run("counts the minimum length in characters", func(t *testing.T) {
+		cfg := defaultConfig()
+		cfg.MinScore = 0
+		policy := passwordpolicy.New(cfg, nil)
+
+		assert.NoError(t, policy.Check("ĳøłßđŧæé", "user@example.com"))
+	})
+
+	t.Run("reports missing character classes", func(t *testing.T) {
+		cfg := defaultConfig()
+		cfg.RequireLowercase = true
+		cfg.RequireUppercase = true
+		cfg.RequireDigit = true
+		cfg.RequireSymbol = true
+		policy := passwordpolicy.New(cfg, nil)
+
+		assert.Equal(t,
+			[]string{"PASSWORD_MISSING_UPPERCASE", "PASSWORD_MISSING_DIGIT", "PASSWORD_MISSING_SYMBOL"},
+			violations(t, policy.Check("violetharborlantern", "user@example.com")),
+		)
+		assert.NoError(t, policy.Check("violet-Harbor-92-lantern", "user@example.com"))
+	})
+
+	t.Run("rejects passwords containing the username", func(t *testing.T) {
+		policy := passwordpolicy.New(defaultConfig(), nil)
+
+		assert.Contains(t, violations(t, policy.Check("Kq9#Margaret.Smith!", "margaret.smith@example.com")),
+			"PASSWORD_CONTAINS_USERNAME")
+		assert.Contains(t, violations(t, policy.Check("margaret.smith@example.com", "margaret.smith@example.com")),
+			"PASSWORD_CONTAINS_USERNAME")
+	})
+
+	t.Run("allows the username when configured to", func(t *testing.T) {
+		cfg := defaultConfig()
+		cfg.DisallowUsername = false
+		cfg.MinScore = 0
+		policy := passwordpolicy.New(cfg, nil)
+
+		assert.NoError(t, policy.Check("Kq9#Margaret.Smith!", "margaret.smith@example.com"))
+	})
+
+	t.Run("rejects common passwords and their substitutions", func(t *testing.T) {
+		policy := passwordpolicy.New(defaultConfig(), nil)
+
+		assert.Contains(t, violations(t, policy.Check("Password123", "user@example.com")), "PASSWORD_TOO_COMMON")
+		assert.Contains(t, violations(t, policy.Check("P@ssw0rd", "user@example.com")), "PASSWORD_TOO_COMMON")
+	})
+
+	t.Run("rejects guessable passwords", func(t *testing.T) {
+		policy := passwordpolicy.New(defaultConfig(), nil)
+
+		for _, password := range []string{"aaaaaaaaaa", "abcdefghij", "asdfghjkl;", "sunshine99"} {
+			assert.Contains(t, violations(t, policy.Check(password, "user@example.com")), "PASSWORD_TOO_WEAK", password)
+		}
+	})
+
+	t.Run("describes the violations", func(t *testing.T) {
+		err := passwordpolicy.New(defaultConfig(), nil).Check("aaaa", "user@example.com")
+
+		assert.EqualError(t, err, "password is too short, is too easy to guess")
+	})
+}
+
+type breachedPasswords map[string]bool
+
+func (b breachedPasswords) IsBreached(password string) bool {
+	return b[password]
+}
+
+func TestPolicyBreachCheck(t *testing.T) {
+	policy := passwordpolicy.New(defaultConfig(), breachedPasswords{"violet-Harbor-92": true})
+
+	assert.Equal(t, []string{"PASSWORD_BREACHED"}, violations(t, policy.Check("violet-Harbor-92", "user@example.com")))
+	assert.NoError(t, policy.Check("violet-Harbor-93", "user@example.com"))
+}
+
+func TestScore(t *testing.T) {
+	for password, expected := range map[string]int{
+		"":                             0,
+		"password":                     0,
+		"qwertyuiop":                   0,
+		"12345678":                     0,
+		"sunshine99":                   1,
+		"kT9#xQ2m":                     4,
+		"correct horse battery staple": 4,
+	} {
+		assert.Equal(t, expected, passwordpolicy.Score(password, ""), password)
+	}
+
+	assert.Less(t, passwordpolicy.Score("margaret.smith7", "margaret.smith@example.com"),
+		passwordpolicy.Score("margaret.smith7", "someone@example.com"))
+}
@@ -0,0 +1,173 @@
+package passwordpolicy
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	lowercasePoolSize = 26
+	uppercasePoolSize = 26
+	digitPoolSize     = 10
+	symbolPoolSize    = 33
+	otherPoolSize     = 100
+	// predictableBits is what a repeated, sequential or keyboard adjacent character adds, an attacker barely has to guess it.
+	predictableBits = 1.0
+)
+
+// scoreThresholds are the zxcvbn boundaries on log10 of the guesses needed, a password passing none scores 0.
+var scoreThresholds = []float64{3, 6, 8, 10} // nolint
+
+var keyboardRows = []string{ // nolint
+	"1234567890",
+	"qwertyuiop",
+	"asdfghjkl",
+	"zxcvbnm",
+}
+
+// Score estimates how hard the password is to guess on the zxcvbn scale from 0 (trivially guessable) to 4 (very strong).
+// Every character is worth the entropy of the character pool in use, unless it continues a repeat, sequence or
+// keyboard walk, and runs matching a common password or the username are only worth their position in that list.
+func Score(password string, username string) int {
+	runes := []rune(password)
+	if len(runes) == 0 {
+		return 0
+	}
+
+	bits := characterBits(runes)
+	lower := []rune(strings.ToLower(password))
+
+	var usernameWords [][]rune
+	for _, part := range usernameParts(username) {
+		usernameWords = append(usernameWords, []rune(part))
+	}
+
+	for _, variant := range [][]rune{lower, normalize(lower)} {
+		replaceMatches(bits, variant, dictionaryWords, math.Log2(float64(len(dictionaryWords))))
+		replaceMatches(bits, variant, usernameWords, predictableBits)
+	}
+
+	var total float64
+	for _, b := range bits {
+		total += b
+	}
+
+	guessesLog10 := total * math.Log10(2)
+
+	score := 0
+
+	for _, threshold := range scoreThresholds {
+		if guessesLog10 >= threshold {
+			score++
+		}
+	}
+
+	return score
+}
+
+func characterBits(runes []rune) []float64 {
+	perCharacter := math.Log2(float64(poolSize(runes)))
+	bits := make([]float64, len(runes))
+
+	for i, r := range runes {
+		if i > 0 && isPredictable(unicode.ToLower(runes[i-1]), unicode.ToLower(r)) {
+			bits[i] = predictableBits
+			continue
+		}
+
+		bits[i] = perCharacter
+	}
+
+	return bits
+}
+
+func poolSize(runes []rune) int {
+	var lower, upper, digit, symbol, other bool
+
+	for _, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	size := 0
+
+	for _, pool := range []struct {
+		used bool
+		size int
+	}{
+		{lower, lowercasePoolSize},
+		{upper, uppercasePoolSize},
+		{digit, digitPoolSize},
+		{symbol, symbolPoolSize},
+		{other, otherPoolSize},
+	} {
+		if pool.used {
+			size += pool.size
+		}
+	}
+
+	return size
+}
+
+func isPredictable(previous rune, current rune) bool {
+	if current == previous || current == previous+1 || current == previous-1 {
+		return true
+	}
+
+	for _, row := range keyboardRows {
+		i := strings.IndexRune(row, previous)
+		j := strings.IndexRune(row, current)
+
+		if i >= 0 && j >= 0 && (i-j == 1 || j-i == 1) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// replaceMatches spreads wordBits over every run of the password matching one of the words,
+// unless the characters of that run are already cheaper to guess.
+func replaceMatches(bits []float64, password []rune, words [][]rune, wordBits float64) {
+	for _, word := range words {
+		for start := 0; start+len(word) <= len(password); start++ {
+			if !hasPrefix(password[start:], word) {
+				continue
+			}
+
+			var current float64
+			for _, b := range bits[start : start+len(word)] {
+				current += b
+			}
+
+			if current <= wordBits {
+				continue
+			}
+
+			for i := start; i < start+len(word); i++ {
+				bits[i] = wordBits / float64(len(word))
+			}
+		}
+	}
+}
+
+func hasPrefix(password []rune, word []rune) bool {
+	for i, r := range word {
+		if password[i] != r {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,55 @@
+package publishkey
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+var ErrInvalidPublicKey = errors.New("public key is not a PEM encoded PKIX key")
+
+type filePublisher struct {
+	directory string
+}
+
+// NewFilePublisher writes keys to directory as <id>.pem instead of publishing them to the key management
+// service, for environments that verify tokens from a shared volume or the JWKS endpoint alone.
+func NewFilePublisher(directory string) KeyPublisher {
+	return filePublisher{directory: directory}
+}
+
+// PublishToKeyManagementService derives the ID from the key itself, so republishing a key keeps its ID.
+func (p filePublisher) PublishToKeyManagementService(publicKey string) (string, error) {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return "", ErrInvalidPublicKey
+	}
+
+	if _, err := x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+		return "", ErrInvalidPublicKey
+	}
+
+	digest := sha256.Sum256(block.Bytes)
+	id := base64.RawURLEncoding.EncodeToString(digest[:16])
+
+	if err := os.MkdirAll(p.directory, 0o755); err != nil {
+		return "", err
+	}
+
+	// Write to a temporary file first so readers never see a partially written key.
+	path := filepath.Join(p.directory, id+".pem")
+	temporary := path + ".tmp"
+	if err := os.WriteFile(temporary, []byte(publicKey), 0o644); err != nil { // nolint
+		return "", err
+	}
+
+	if err := os.Rename(temporary, path); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
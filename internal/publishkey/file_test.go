@@ -0,0 +1,45 @@
+package publishkey_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/internal/keypair"
+	"github.com/weeb-vip/auth/internal/publishkey"
+)
+
+func TestFilePublisher(t *testing.T) {
+	t.Run("writes the key under an ID derived from it", func(t *testing.T) {
+		directory := filepath.Join(t.TempDir(), "keys")
+		publisher := publishkey.NewFilePublisher(directory)
+
+		keyPair, err := keypair.GenerateKeyPairFor(keypair.ES256)
+		assert.NoError(t, err)
+
+		id, err := publisher.PublishToKeyManagementService(keyPair.PublicKey)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, id)
+
+		contents, err := os.ReadFile(filepath.Join(directory, id+".pem"))
+		assert.NoError(t, err)
+		assert.Equal(t, keyPair.PublicKey, string(contents))
+
+		republishedID, err := publisher.PublishToKeyManagementService(keyPair.PublicKey)
+		assert.NoError(t, err)
+		assert.Equal(t, id, republishedID)
+
+		entries, err := os.ReadDir(directory)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+
+	t.Run("rejects anything but a public key", func(t *testing.T) {
+		publisher := publishkey.NewFilePublisher(t.TempDir())
+
+		_, err := publisher.PublishToKeyManagementService("my-public-key")
+		assert.ErrorIs(t, err, publishkey.ErrInvalidPublicKey)
+	})
+}
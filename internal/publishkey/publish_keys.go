@@ -49,7 +49,10 @@ func (p keyPublisher) PublishToKeyManagementService(publicKey string) (string, e
 
 	request.Header.Add("Origin", origin)
 
-	response, err := run[Response](graphql.NewClient(p.graphQLEndpoint), request)
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	response, err := run[Response](ctx, graphql.NewClient(p.graphQLEndpoint), request)
 	if err != nil {
 		return "", err
 	}
@@ -57,10 +60,10 @@ func (p keyPublisher) PublishToKeyManagementService(publicKey string) (string, e
 	return response.RegisterPublicKey.ID, nil
 }
 
-func run[T any](client *graphql.Client, request *graphql.Request) (*T, error) {
+func run[T any](ctx context.Context, client *graphql.Client, request *graphql.Request) (*T, error) {
 	response := new(T)
 
-	err := client.Run(context.Background(), request, &response)
+	err := client.Run(ctx, request, &response)
 	if err != nil {
 		return nil, err
 	}
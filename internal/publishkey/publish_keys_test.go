@@ -4,6 +4,7 @@ package publishkey_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -14,8 +15,8 @@ import (
 // later, we'll most likely introduce proper integration test
 
 func TestNewKeyPublisher(t *testing.T) {
-	publisher := publishkey.NewKeyPublisher("http://localhost:5001/graphql")
-	id, err := publisher.ToKeyManagementService("my-public-key")
+	publisher := publishkey.NewKeyPublisher("http://localhost:5001/graphql", 10*time.Second)
+	id, err := publisher.PublishToKeyManagementService("my-public-key")
 	assert.NoError(t, err)
 	assert.NotEmpty(t, id)
 }
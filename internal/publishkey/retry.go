@@ -0,0 +1,58 @@
+package publishkey
+
+import (
+	"context"
+	"time"
+
+	"github.com/weeb-vip/auth/internal/logger"
+	"github.com/weeb-vip/auth/internal/metrics"
+)
+
+// maxBackoff caps the wait between two attempts, however many attempts are configured.
+const maxBackoff = 30 * time.Second
+
+type retryingPublisher struct {
+	publisher   KeyPublisher
+	maxAttempts int
+	backoff     time.Duration
+	sleep       func(time.Duration)
+}
+
+// NewRetryingPublisher retries publisher up to maxAttempts times in total, waiting backoff after the first
+// failure and doubling the wait after each further one.
+func NewRetryingPublisher(publisher KeyPublisher, maxAttempts int, backoff time.Duration) KeyPublisher {
+	return retryingPublisher{
+		publisher:   publisher,
+		maxAttempts: max(maxAttempts, 1),
+		backoff:     backoff,
+		sleep:       time.Sleep,
+	}
+}
+
+func (p retryingPublisher) PublishToKeyManagementService(publicKey string) (string, error) {
+	log := logger.FromCtx(context.Background())
+
+	wait := p.backoff
+	for attempt := 1; ; attempt++ {
+		id, err := p.publisher.PublishToKeyManagementService(publicKey)
+		if err == nil {
+			metrics.GetAppMetrics().KeyPublishMetric(metrics.Success)
+
+			return id, nil
+		}
+
+		metrics.GetAppMetrics().KeyPublishMetric(metrics.Error)
+		if attempt >= p.maxAttempts {
+			return "", err
+		}
+
+		log.Warn().
+			Err(err).
+			Int("attempt", attempt).
+			Dur("retry_in", wait).
+			Msg("Failed to publish signing key, retrying")
+
+		p.sleep(wait)
+		wait = min(wait*2, maxBackoff)
+	}
+}
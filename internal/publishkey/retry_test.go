@@ -0,0 +1,84 @@
+package publishkey
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type flakyPublisher struct {
+	failures int
+	attempts int
+}
+
+func (p *flakyPublisher) PublishToKeyManagementService(publicKey string) (string, error) {
+	p.attempts++
+	if p.attempts <= p.failures {
+		return "", errors.New("key service unavailable")
+	}
+
+	return "key_id", nil
+}
+
+func newTestRetryingPublisher(publisher KeyPublisher, maxAttempts int) (KeyPublisher, *[]time.Duration) {
+	var waits []time.Duration
+
+	retrying := NewRetryingPublisher(publisher, maxAttempts, time.Second).(retryingPublisher)
+	retrying.sleep = func(wait time.Duration) {
+		waits = append(waits, wait)
+	}
+
+	return retrying, &waits
+}
+
+func TestRetryingPublisher(t *testing.T) {
+	t.Run("retries with a doubling backoff until publishing succeeds", func(t *testing.T) {
+		flaky := &flakyPublisher{failures: 2}
+		publisher, waits := newTestRetryingPublisher(flaky, 3)
+
+		id, err := publisher.PublishToKeyManagementService("public-key")
+		assert.NoError(t, err)
+		assert.Equal(t, "key_id", id)
+		assert.Equal(t, 3, flaky.attempts)
+		assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, *waits)
+	})
+
+	t.Run("gives up after the maximum number of attempts", func(t *testing.T) {
+		flaky := &flakyPublisher{failures: 5}
+		publisher, waits := newTestRetryingPublisher(flaky, 3)
+
+		_, err := publisher.PublishToKeyManagementService("public-key")
+		assert.EqualError(t, err, "key service unavailable")
+		assert.Equal(t, 3, flaky.attempts)
+		assert.Len(t, *waits, 2)
+	})
+
+	t.Run("caps the backoff", func(t *testing.T) {
+		flaky := &flakyPublisher{failures: 8}
+		publisher, waits := newTestRetryingPublisher(flaky, 8)
+
+		_, err := publisher.PublishToKeyManagementService("public-key")
+		assert.Error(t, err)
+		assert.Equal(t, maxBackoff, (*waits)[len(*waits)-1])
+	})
+}
+
+func TestKeyPublisherTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	publisher := NewKeyPublisher(server.URL, 50*time.Millisecond)
+
+	started := time.Now()
+	_, err := publisher.PublishToKeyManagementService("public-key")
+	assert.Error(t, err)
+	assert.Less(t, time.Since(started), 5*time.Second)
+}
@@ -1,13 +1,19 @@
 package publishkey
 
+import (
+	"time"
+)
+
 type KeyPublisher interface {
 	PublishToKeyManagementService(publicKey string) (string, error)
 }
 
 type keyPublisher struct {
 	graphQLEndpoint string
+	timeout         time.Duration
 }
 
-func NewKeyPublisher(graphqlEndpoint string) KeyPublisher {
-	return keyPublisher{graphQLEndpoint: graphqlEndpoint}
+// NewKeyPublisher publishes keys to the key management service, giving up on a request after timeout.
+func NewKeyPublisher(graphqlEndpoint string, timeout time.Duration) KeyPublisher {
+	return keyPublisher{graphQLEndpoint: graphqlEndpoint, timeout: timeout}
 }
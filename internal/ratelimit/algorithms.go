@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// takeToken implements TokenBucket. The state is "tokens|last refill in unix nanoseconds"; a missing
+// or unreadable state is a full bucket.
+func takeToken(state string, now time.Time, rule Rule) (string, Result) {
+	capacity := float64(rule.Limit)
+	perNanosecond := capacity / float64(rule.Window)
+
+	tokens := capacity
+	if fields := strings.Split(state, "|"); len(fields) == 2 {
+		stored, tokensErr := strconv.ParseFloat(fields[0], 64)
+		refilledAt, refilledAtErr := strconv.ParseInt(fields[1], 10, 64)
+		if tokensErr == nil && refilledAtErr == nil {
+			elapsed := max(now.UnixNano()-refilledAt, 0)
+			tokens = math.Min(capacity, stored+float64(elapsed)*perNanosecond)
+		}
+	}
+
+	result := Result{}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - tokens) / perNanosecond))
+	}
+	result.Remaining = int(tokens)
+
+	return strconv.FormatFloat(tokens, 'f', -1, 64) + "|" + strconv.FormatInt(now.UnixNano(), 10), result
+}
+
+// takeSlidingWindow implements SlidingWindow by interpolating between fixed windows rather than
+// logging every request. The state is "window start in unix nanoseconds|current count|previous count".
+func takeSlidingWindow(state string, now time.Time, rule Rule) (string, Result) {
+	window := int64(rule.Window)
+	start := now.UnixNano() - now.UnixNano()%window
+
+	var current, previous int
+	if fields := strings.Split(state, "|"); len(fields) == 3 {
+		storedStart, startErr := strconv.ParseInt(fields[0], 10, 64)
+		storedCurrent, currentErr := strconv.Atoi(fields[1])
+		storedPrevious, previousErr := strconv.Atoi(fields[2])
+		if startErr == nil && currentErr == nil && previousErr == nil {
+			switch storedStart {
+			case start:
+				current, previous = storedCurrent, storedPrevious
+			case start - window:
+				previous = storedCurrent
+			}
+		}
+	}
+
+	elapsed := now.UnixNano() - start
+	weight := 1 - float64(elapsed)/float64(window)
+	estimated := float64(previous)*weight + float64(current)
+	limit := float64(rule.Limit)
+
+	result := Result{}
+	if estimated+1 <= limit {
+		current++
+		result.Allowed = true
+		result.Remaining = int(limit - estimated - 1)
+	} else {
+		result.RetryAfter = time.Duration(window - elapsed)
+		// Within this window the estimate only falls as the previous window slides out, so wait for
+		// the point where it leaves room for one more request if that comes before the next window.
+		if previous > 0 && float64(current)+1 <= limit {
+			clearsAt := int64(float64(window) * (1 - (limit-1-float64(current))/float64(previous)))
+			if clearsAt > elapsed {
+				result.RetryAfter = time.Duration(clearsAt - elapsed)
+			}
+		}
+	}
+
+	return strconv.FormatInt(start, 10) + "|" + strconv.Itoa(current) + "|" + strconv.Itoa(previous), result
+}
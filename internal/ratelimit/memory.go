@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how many updates the memory store handles between passes over expired keys.
+const sweepInterval = 1024
+
+type memoryEntry struct {
+	state     string
+	expiresAt time.Time
+}
+
+// MemoryStore keeps limiter state in process, so limits apply per instance.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	updates int
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]memoryEntry),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Update(ctx context.Context, key string, ttl time.Duration, fn func(state string) string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	s.updates++
+	if s.updates%sweepInterval == 0 {
+		for k, entry := range s.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+	}
+
+	var state string
+	if entry, found := s.entries[key]; found && now.Before(entry.expiresAt) {
+		state = entry.state
+	}
+
+	s.entries[key] = memoryEntry{
+		state:     fn(state),
+		expiresAt: now.Add(ttl),
+	}
+
+	return nil
+}
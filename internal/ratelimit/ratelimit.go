@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Algorithm selects how requests are counted against a Rule.
+type Algorithm string
+
+const (
+	// TokenBucket allows bursts of up to Limit requests and refills Limit tokens evenly over Window.
+	TokenBucket Algorithm = "token_bucket"
+	// SlidingWindow allows Limit requests in any Window, weighting the previous window by its overlap.
+	SlidingWindow Algorithm = "sliding_window"
+)
+
+// ParseAlgorithm returns the Algorithm with the given name.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch Algorithm(name) {
+	case TokenBucket, SlidingWindow:
+		return Algorithm(name), nil
+	default:
+		return "", fmt.Errorf("unknown rate limit algorithm %q", name)
+	}
+}
+
+type Rule struct {
+	Algorithm Algorithm
+	Limit     int
+	Window    time.Duration
+}
+
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long to wait before the next request can be allowed; zero when Allowed.
+	RetryAfter time.Duration
+}
+
+// Store keeps limiter state between requests. Update must apply fn atomically for a key and keep
+// the returned state for at least ttl; fn may be called more than once if the store retries.
+type Store interface {
+	Update(ctx context.Context, key string, ttl time.Duration, fn func(state string) string) error
+}
+
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// Allow counts one request for key against rule.
+func (l *Limiter) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	if rule.Limit <= 0 || rule.Window <= 0 {
+		return Result{}, fmt.Errorf("invalid rate limit rule %d/%s", rule.Limit, rule.Window)
+	}
+
+	var take func(state string, now time.Time, rule Rule) (string, Result)
+	var ttl time.Duration
+	switch rule.Algorithm {
+	case TokenBucket:
+		take, ttl = takeToken, rule.Window
+	case SlidingWindow:
+		take, ttl = takeSlidingWindow, 2*rule.Window
+	default:
+		return Result{}, fmt.Errorf("unknown rate limit algorithm %q", rule.Algorithm)
+	}
+
+	now := l.now()
+	var result Result
+	err := l.store.Update(ctx, key, ttl, func(state string) string {
+		var next string
+		next, result = take(state, now, rule)
+
+		return next
+	})
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to update rate limit state: %w", err)
+	}
+
+	return result, nil
+}
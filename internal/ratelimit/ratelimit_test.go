@@ -0,0 +1,210 @@
+package ratelimit
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// localRedis stands in for a Redis server, implementing WATCH semantics with per-key versions.
+type localRedis struct {
+	mu       sync.Mutex
+	values   map[string]string
+	ttls     map[string]time.Duration
+	versions map[string]int
+}
+
+func newLocalRedis() *localRedis {
+	return &localRedis{
+		values:   make(map[string]string),
+		ttls:     make(map[string]time.Duration),
+		versions: make(map[string]int),
+	}
+}
+
+type localRedisTx struct {
+	redis  *localRedis
+	writes map[string]string
+	ttls   map[string]time.Duration
+}
+
+func (r *localRedis) Watch(ctx context.Context, key string, fn func(tx RedisTx) error) error {
+	r.mu.Lock()
+	version := r.versions[key]
+	r.mu.Unlock()
+
+	tx := &localRedisTx{redis: r, writes: make(map[string]string), ttls: make(map[string]time.Duration)}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	// Give concurrent callers a chance to interleave between the read and the write.
+	runtime.Gosched()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.versions[key] != version {
+		return ErrTxConflict
+	}
+	for k, v := range tx.writes {
+		r.values[k] = v
+		r.ttls[k] = tx.ttls[k]
+		r.versions[k]++
+	}
+
+	return nil
+}
+
+func (tx *localRedisTx) Get(ctx context.Context, key string) (string, error) {
+	tx.redis.mu.Lock()
+	defer tx.redis.mu.Unlock()
+
+	return tx.redis.values[key], nil
+}
+
+func (tx *localRedisTx) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	tx.writes[key] = value
+	tx.ttls[key] = ttl
+
+	return nil
+}
+
+func newTestLimiter(store Store, now *time.Time) *Limiter {
+	limiter := NewLimiter(store)
+	limiter.now = func() time.Time { return *now }
+
+	return limiter
+}
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("token bucket allows a burst then refills evenly", func(t *testing.T) {
+		now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+		limiter := newTestLimiter(NewMemoryStore(), &now)
+		rule := Rule{Algorithm: TokenBucket, Limit: 3, Window: 3 * time.Second}
+
+		for remaining := 2; remaining >= 0; remaining-- {
+			result, err := limiter.Allow(ctx, "ip:203.0.113.7", rule)
+			assert.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, remaining, result.Remaining)
+		}
+
+		result, err := limiter.Allow(ctx, "ip:203.0.113.7", rule)
+		assert.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, time.Second, result.RetryAfter)
+
+		now = now.Add(time.Second)
+		result, err = limiter.Allow(ctx, "ip:203.0.113.7", rule)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+
+		result, err = limiter.Allow(ctx, "ip:198.51.100.1", rule)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed, "keys are limited independently")
+	})
+
+	t.Run("sliding window weights the previous window by its overlap", func(t *testing.T) {
+		now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+		limiter := newTestLimiter(NewMemoryStore(), &now)
+		rule := Rule{Algorithm: SlidingWindow, Limit: 2, Window: time.Minute}
+
+		for i := 0; i < 2; i++ {
+			result, err := limiter.Allow(ctx, "username:user@weeb.vip", rule)
+			assert.NoError(t, err)
+			assert.True(t, result.Allowed)
+		}
+
+		now = now.Add(15 * time.Second)
+		result, err := limiter.Allow(ctx, "username:user@weeb.vip", rule)
+		assert.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, 45*time.Second, result.RetryAfter)
+
+		// Halfway through the next window the two earlier requests count as one.
+		now = now.Add(75 * time.Second)
+		result, err = limiter.Allow(ctx, "username:user@weeb.vip", rule)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+
+		result, err = limiter.Allow(ctx, "username:user@weeb.vip", rule)
+		assert.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, 30*time.Second, result.RetryAfter)
+	})
+
+	t.Run("rejects invalid rules", func(t *testing.T) {
+		limiter := NewLimiter(NewMemoryStore())
+
+		_, err := limiter.Allow(ctx, "key", Rule{Algorithm: TokenBucket, Limit: 0, Window: time.Minute})
+		assert.Error(t, err)
+
+		_, err = limiter.Allow(ctx, "key", Rule{Algorithm: "leaky_bucket", Limit: 1, Window: time.Minute})
+		assert.Error(t, err)
+	})
+
+	t.Run("memory store forgets expired state", func(t *testing.T) {
+		now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+		store := NewMemoryStore()
+		store.now = func() time.Time { return now }
+
+		assert.NoError(t, store.Update(ctx, "key", time.Minute, func(state string) string { return "state" }))
+
+		now = now.Add(time.Minute)
+		assert.NoError(t, store.Update(ctx, "key", time.Minute, func(state string) string {
+			assert.Empty(t, state)
+
+			return state
+		}))
+	})
+
+	for _, algorithm := range []Algorithm{TokenBucket, SlidingWindow} {
+		t.Run("redis store applies concurrent "+string(algorithm)+" updates atomically", func(t *testing.T) {
+			redis := newLocalRedis()
+			limiter := NewLimiter(NewRedisStore(redis, "ratelimit:"))
+			rule := Rule{Algorithm: algorithm, Limit: 10, Window: time.Hour}
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			allowed := 0
+			for i := 0; i < 40; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					result, err := limiter.Allow(ctx, "ip:203.0.113.7", rule)
+					if err != nil {
+						return
+					}
+
+					mu.Lock()
+					defer mu.Unlock()
+					if result.Allowed {
+						allowed++
+					}
+				}()
+			}
+			wg.Wait()
+
+			assert.Equal(t, 10, allowed)
+			assert.Contains(t, redis.values, "ratelimit:ip:203.0.113.7")
+			assert.Positive(t, redis.ttls["ratelimit:ip:203.0.113.7"])
+		})
+	}
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	algorithm, err := ParseAlgorithm("sliding_window")
+	assert.NoError(t, err)
+	assert.Equal(t, SlidingWindow, algorithm)
+
+	_, err = ParseAlgorithm("fixed_window")
+	assert.Error(t, err)
+}
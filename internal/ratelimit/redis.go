@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTxConflict is returned by RedisClient.Watch when the watched key changed before the write.
+var ErrTxConflict = errors.New("watched key changed during transaction")
+
+// redisMaxAttempts bounds how often RedisStore retries an update that lost a race for its key.
+const redisMaxAttempts = 10
+
+// RedisClient is the part of a Redis client the Redis store needs. It maps onto WATCH/GET/MULTI/SET/EXEC,
+// so a thin adapter over any client speaking to Redis or a compatible server satisfies it.
+type RedisClient interface {
+	// Watch runs fn with key watched. Writes made through tx are applied atomically after fn returns,
+	// and Watch fails with ErrTxConflict instead if key was changed by anyone else in the meantime.
+	Watch(ctx context.Context, key string, fn func(tx RedisTx) error) error
+}
+
+type RedisTx interface {
+	// Get returns the value of key, or an empty string when it does not exist.
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+}
+
+// RedisStore keeps limiter state in Redis, so limits are shared by every instance.
+type RedisStore struct {
+	client RedisClient
+	prefix string
+}
+
+func NewRedisStore(client RedisClient, prefix string) *RedisStore {
+	return &RedisStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (s *RedisStore) Update(ctx context.Context, key string, ttl time.Duration, fn func(state string) string) error {
+	key = s.prefix + key
+
+	for attempt := 0; attempt < redisMaxAttempts; attempt++ {
+		err := s.client.Watch(ctx, key, func(tx RedisTx) error {
+			state, err := tx.Get(ctx, key)
+			if err != nil {
+				return err
+			}
+
+			return tx.Set(ctx, key, fn(state), ttl)
+		})
+		if !errors.Is(err, ErrTxConflict) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("gave up on %s after %d conflicting updates: %w", key, redisMaxAttempts, ErrTxConflict)
+}
@@ -0,0 +1,86 @@
+package resolvers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/logger"
+	"github.com/weeb-vip/auth/internal/metrics"
+	"github.com/weeb-vip/auth/internal/services/credential"
+	"github.com/weeb-vip/auth/internal/services/lockout"
+	"github.com/weeb-vip/auth/internal/services/mail"
+)
+
+// RequestAccountUnlock emails an unlock link to a locked account, it reports success either way
+// so it cannot be used to find out which usernames exist or are locked.
+func RequestAccountUnlock(
+	ctx context.Context,
+	credentialService credential.Credential,
+	lockoutService lockout.Lockout,
+	mailService mail.MailService,
+	cfg *config.Config,
+	username string,
+) (bool, error) {
+	log := logger.FromCtx(ctx)
+
+	foundCredential, err := credentialService.GetCredentials(ctx, username)
+	if err != nil {
+		metrics.GetAppMetrics().AuthRequestMetric("request_account_unlock", metrics.Error)
+		_, err := handleError(ctx, "false", err)
+		return false, err
+	}
+
+	if foundCredential == nil || foundCredential.ID == "" {
+		return true, nil
+	}
+
+	token, err := lockoutService.RequestUnlock(ctx, foundCredential.Username)
+	if err != nil {
+		metrics.GetAppMetrics().AuthRequestMetric("request_account_unlock", metrics.Error)
+		_, err := handleError(ctx, "false", err)
+		return false, err
+	}
+
+	if token == "" {
+		return true, nil
+	}
+
+	unlockURL, err := url.Parse(cfg.APPConfig.UnlockBaseURL)
+	if err != nil {
+		return false, fmt.Errorf("invalid unlock base URL: %w", err)
+	}
+
+	query := unlockURL.Query()
+	query.Set("token", token)
+	unlockURL.RawQuery = query.Encode()
+
+	err = mailService.SendMail(ctx, []string{foundCredential.Username}, "Unlock your account", "unlock-account.mjml", map[string]string{
+		"unlock_url": unlockURL.String(),
+		"name":       foundCredential.Username,
+	})
+	if err != nil {
+		metrics.GetAppMetrics().AuthRequestMetric("request_account_unlock", metrics.Error)
+		log.Error().Err(err).Str("user_id", foundCredential.UserID).Msg("Failed to send unlock email")
+
+		return false, err
+	}
+
+	metrics.GetAppMetrics().AuthRequestMetric("request_account_unlock", metrics.Success)
+
+	return true, nil
+}
+
+func UnlockAccount(ctx context.Context, lockoutService lockout.Lockout, token string) (bool, error) {
+	err := lockoutService.Unlock(ctx, token)
+	if err != nil {
+		metrics.GetAppMetrics().AuthRequestMetric("unlock_account", metrics.Error)
+		_, err := handleError(ctx, "false", err)
+		return false, err
+	}
+
+	metrics.GetAppMetrics().AuthRequestMetric("unlock_account", metrics.Success)
+
+	return true, nil
+}
@@ -0,0 +1,127 @@
+package resolvers
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/services/lockout"
+)
+
+type recordingLockoutService struct {
+	lockedUsername string
+	unlockToken    string
+	unlocked       []string
+	maxMFAFailures int // 0 never locks the second factor.
+	mfaFailures    int
+}
+
+func (m *recordingLockoutService) Check(ctx context.Context, username string, ip string) error {
+	return nil
+}
+
+func (m *recordingLockoutService) RecordFailure(ctx context.Context, username string, ip string) error {
+	return nil
+}
+
+func (m *recordingLockoutService) RecordSuccess(ctx context.Context, username string) error {
+	return nil
+}
+
+func (m *recordingLockoutService) RequestUnlock(ctx context.Context, username string) (string, error) {
+	if username != m.lockedUsername {
+		return "", nil
+	}
+
+	return m.unlockToken, nil
+}
+
+func (m *recordingLockoutService) Unlock(ctx context.Context, token string) error {
+	if token != m.unlockToken {
+		return &lockout.Error{Code: lockout.LockoutErrorInvalidUnlockToken, Message: "invalid or expired unlock token"}
+	}
+
+	m.unlocked = append(m.unlocked, token)
+
+	return nil
+}
+
+func (m *recordingLockoutService) CheckMFA(ctx context.Context, userID string) error {
+	if m.maxMFAFailures > 0 && m.mfaFailures >= m.maxMFAFailures {
+		return &lockout.Error{Code: lockout.LockoutErrorLocked, Message: "too many failed sign in attempts, try again later"}
+	}
+
+	return nil
+}
+
+func (m *recordingLockoutService) RecordMFAFailure(ctx context.Context, userID string) error {
+	m.mfaFailures++
+
+	return m.CheckMFA(ctx, userID)
+}
+
+func (m *recordingLockoutService) RecordMFASuccess(ctx context.Context, userID string) error {
+	m.mfaFailures = 0
+
+	return nil
+}
+
+func TestRequestAccountUnlock(t *testing.T) {
+	cfg := &config.Config{APPConfig: config.AppConfig{UnlockBaseURL: "https://weeb.vip/auth/unlock"}}
+
+	t.Run("emails an unlock link to a locked account", func(t *testing.T) {
+		ctx, _ := newGraphQLContext()
+		lockoutService := &recordingLockoutService{lockedUsername: "user@weeb.vip", unlockToken: "unlock_token"}
+		mailService := &recordingMailService{}
+
+		ok, err := RequestAccountUnlock(ctx, &resettableCredentialService{}, lockoutService, mailService, cfg, "user@weeb.vip")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Empty(t, graphql.GetErrors(ctx))
+
+		assert.Len(t, mailService.sent, 1)
+		assert.Equal(t, []string{"user@weeb.vip"}, mailService.sent[0].to)
+		assert.Equal(t, "unlock-account.mjml", mailService.sent[0].template)
+
+		unlockURL, err := url.Parse(mailService.sent[0].values["unlock_url"])
+		assert.NoError(t, err)
+		assert.Equal(t, "unlock_token", unlockURL.Query().Get("token"))
+	})
+
+	t.Run("reports success without mail when the account is not locked", func(t *testing.T) {
+		ctx, _ := newGraphQLContext()
+		lockoutService := &recordingLockoutService{lockedUsername: "other@weeb.vip", unlockToken: "unlock_token"}
+		mailService := &recordingMailService{}
+
+		ok, err := RequestAccountUnlock(ctx, &resettableCredentialService{}, lockoutService, mailService, cfg, "user@weeb.vip")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Empty(t, mailService.sent)
+	})
+}
+
+func TestUnlockAccount(t *testing.T) {
+	t.Run("unlocks with a valid token", func(t *testing.T) {
+		ctx, _ := newGraphQLContext()
+		lockoutService := &recordingLockoutService{unlockToken: "unlock_token"}
+
+		ok, err := UnlockAccount(ctx, lockoutService, "unlock_token")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"unlock_token"}, lockoutService.unlocked)
+	})
+
+	t.Run("reports an invalid token", func(t *testing.T) {
+		ctx, _ := newGraphQLContext()
+		lockoutService := &recordingLockoutService{unlockToken: "unlock_token"}
+
+		ok, err := UnlockAccount(ctx, lockoutService, "stale_token")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, "INVALID_UNLOCK_TOKEN", graphql.GetErrors(ctx)[0].Extensions["code"])
+	})
+}
@@ -0,0 +1,67 @@
+package resolvers
+
+import (
+	"context"
+	"time"
+
+	"github.com/weeb-vip/auth/graph/model"
+	"github.com/weeb-vip/auth/internal/dataloader"
+	"github.com/weeb-vip/auth/internal/entities"
+	"github.com/weeb-vip/auth/internal/jwt"
+	"github.com/weeb-vip/auth/internal/logger"
+	"github.com/weeb-vip/auth/internal/services/refresh_token"
+	SessionModels "github.com/weeb-vip/auth/internal/services/session/models"
+)
+
+// ActiveSessions lists the sessions of a user to that same user, most recently seen first, flagging the one
+// the request was made from. The sessions of every user in a federated query are loaded in one batch.
+func ActiveSessions(
+	ctx context.Context,
+	sessionLoader *dataloader.Loader[string, []*SessionModels.Session],
+	jwtTokenizer jwt.Tokenizer,
+	refreshTokenService refresh_token.RefreshToken,
+	userID string,
+) ([]*model.SessionDetails, error) {
+	authenticatedID, err := authenticatedUserID(ctx)
+	if err == nil && authenticatedID != userID {
+		err = &entities.ServiceError{
+			Code:    AccessDeniedCode,
+			Message: "access denied",
+		}
+	}
+
+	if err != nil {
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	sessions, err := sessionLoader.Load(ctx, userID)
+	if err != nil {
+		log := logger.FromCtx(ctx)
+		log.Error().
+			Err(err).
+			Str("user_id", userID).
+			Msg("Failed to load active sessions")
+
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	currentID := currentSessionID(ctx, jwtTokenizer, refreshTokenService)
+
+	details := make([]*model.SessionDetails, 0, len(sessions))
+	for _, session := range sessions {
+		details = append(details, &model.SessionDetails{
+			ID:         session.ID,
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			UserID:     session.UserID,
+			Token:      session.Token,
+			CreatedAt:  session.CreatedAt.UTC().Format(time.RFC3339),
+			LastSeenAt: session.LastSeenAt.UTC().Format(time.RFC3339),
+			Current:    currentID != "" && session.ID == currentID,
+		})
+	}
+
+	return details, nil
+}
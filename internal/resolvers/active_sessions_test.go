@@ -0,0 +1,187 @@
+package resolvers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/graph/model"
+	"github.com/weeb-vip/auth/http/handlers/requestinfo"
+	"github.com/weeb-vip/auth/http/handlers/responsecontext"
+	"github.com/weeb-vip/auth/internal/dataloader"
+	"github.com/weeb-vip/auth/internal/db"
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/jwt"
+	SessionModels "github.com/weeb-vip/auth/internal/services/session/models"
+)
+
+// newClientContext is the context of a request forwarded by the gateway for a client at remoteIP.
+func newClientContext(userID string, rawToken string, remoteIP string, userAgent string) context.Context {
+	request := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	request.Header.Set("x-remote-ip", remoteIP)
+	request.Header.Set("x-user-agent", userAgent)
+	if userID != "" {
+		request.Header.Set("x-user-id", userID)
+	}
+	if rawToken != "" {
+		request.Header.Set("x-raw-token", rawToken)
+	}
+
+	var ctx context.Context
+
+	requestinfo.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})).ServeHTTP(httptest.NewRecorder(), request)
+
+	ctx = graphql.WithResponseContext(ctx, graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+
+	return responsecontext.WithResponseWriter(ctx, httptest.NewRecorder())
+}
+
+func TestActiveSessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokenizer := newTestTokenizer(t)
+	refreshTokenService := NewMockRefreshTokenService(ctrl)
+
+	subject := "user_123"
+	refreshToken := "refresh_token_123"
+	accessToken, err := tokenizer.Tokenize(jwt.Claims{Subject: &subject, RefreshToken: &refreshToken})
+	assert.NoError(t, err)
+
+	createdAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	lastSeenAt := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	sessions := map[string][]*SessionModels.Session{
+		"user_123": {
+			{
+				BaseModel:  db.BaseModel{ID: "session_123", CreatedAt: createdAt},
+				UserID:     "user_123",
+				IPAddress:  "192.168.1.1",
+				UserAgent:  "Firefox",
+				Token:      "refresh_token_id_123",
+				LastSeenAt: lastSeenAt,
+			},
+			{
+				BaseModel:  db.BaseModel{ID: "session_456", CreatedAt: createdAt},
+				UserID:     "user_123",
+				IPAddress:  "10.0.0.1",
+				UserAgent:  "Safari",
+				Token:      "refresh_token_id_456",
+				LastSeenAt: createdAt,
+			},
+		},
+	}
+
+	var loaded [][]string
+	newSessionLoader := func() *dataloader.Loader[string, []*SessionModels.Session] {
+		loaded = nil
+
+		return dataloader.NewLoader(func(ctx context.Context, userIDs []string) (map[string][]*SessionModels.Session, error) {
+			loaded = append(loaded, userIDs)
+
+			return sessions, nil
+		}, time.Millisecond, 100)
+	}
+
+	t.Run("lists the sessions of the user and flags the current one", func(t *testing.T) {
+		ctx := newClientContext("user_123", accessToken, "192.168.1.1", "Firefox")
+
+		details, err := ActiveSessions(ctx, newSessionLoader(), tokenizer, refreshTokenService, "user_123")
+		assert.NoError(t, err)
+		assert.Empty(t, graphql.GetErrors(ctx))
+		assert.Equal(t, [][]string{{"user_123"}}, loaded)
+
+		assert.Equal(t, []*model.SessionDetails{
+			{
+				ID:         "session_123",
+				IPAddress:  "192.168.1.1",
+				UserAgent:  "Firefox",
+				UserID:     "user_123",
+				Token:      "refresh_token_id_123",
+				CreatedAt:  "2026-10-01T12:00:00Z",
+				LastSeenAt: "2026-10-18T09:30:00Z",
+				Current:    true,
+			},
+			{
+				ID:         "session_456",
+				IPAddress:  "10.0.0.1",
+				UserAgent:  "Safari",
+				UserID:     "user_123",
+				Token:      "refresh_token_id_456",
+				CreatedAt:  "2026-10-01T12:00:00Z",
+				LastSeenAt: "2026-10-01T12:00:00Z",
+				Current:    false,
+			},
+		}, details)
+	})
+
+	t.Run("returns an empty list for a user without sessions", func(t *testing.T) {
+		ctx := newClientContext("user_789", "", "192.168.1.1", "Firefox")
+
+		details, err := ActiveSessions(ctx, newSessionLoader(), tokenizer, refreshTokenService, "user_789")
+		assert.NoError(t, err)
+		assert.NotNil(t, details)
+		assert.Empty(t, details)
+	})
+
+	t.Run("does not show the sessions of another user", func(t *testing.T) {
+		ctx := newClientContext("user_456", "", "192.168.1.1", "Firefox")
+
+		details, err := ActiveSessions(ctx, newSessionLoader(), tokenizer, refreshTokenService, "user_123")
+		assert.NoError(t, err)
+		assert.Nil(t, details)
+		assert.Empty(t, loaded)
+		assert.Equal(t, AccessDeniedCode, graphql.GetErrors(ctx)[0].Extensions["code"])
+	})
+
+	t.Run("does not show sessions to anonymous requests", func(t *testing.T) {
+		ctx := newClientContext("", "", "192.168.1.1", "Firefox")
+
+		details, err := ActiveSessions(ctx, newSessionLoader(), tokenizer, refreshTokenService, "user_123")
+		assert.NoError(t, err)
+		assert.Nil(t, details)
+		assert.Equal(t, AccessDeniedCode, graphql.GetErrors(ctx)[0].Extensions["code"])
+	})
+}
+
+func TestSessionActivity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testConfig := &config.Config{APPConfig: config.AppConfig{CookieDomain: ".weeb.vip"}}
+
+	t.Run("records the client of a new session and links its refresh token", func(t *testing.T) {
+		ctx := newClientContext("", "", "192.168.1.1", "Firefox")
+		sessionService := &recordingSessionService{}
+
+		createdSession, err := sessionService.CreateSession(ctx, "user_123", requestinfo.RemoteIP(ctx), requestinfo.UserAgent(ctx))
+		assert.NoError(t, err)
+		assert.Equal(t, "192.168.1.1", createdSession.IPAddress)
+		assert.Equal(t, "Firefox", createdSession.UserAgent)
+
+		_, err = issueSession(ctx, createdSession, sessionService, NewMockRefreshTokenService(ctrl), NewMockJWTTokenizer(ctrl), denylist.NewMemoryDenylist(), testConfig)
+		assert.NoError(t, err)
+		assert.Equal(t, []touchedSession{
+			{sessionID: "session_123", refreshTokenID: "refresh_token_id_123", ipAddress: "192.168.1.1", userAgent: "Firefox"},
+		}, sessionService.touched)
+	})
+
+	t.Run("marks the session as seen on refresh", func(t *testing.T) {
+		ctx := newClientContext("", "", "10.0.0.1", "Safari")
+		sessionService := &recordingSessionService{}
+
+		_, err := RefreshToken(ctx, sessionService, NewMockRefreshTokenService(ctrl), NewMockJWTTokenizer(ctrl), (&recordingProducer{}).produce, testConfig, "refresh_token_123")
+		assert.NoError(t, err)
+		assert.Equal(t, []touchedSession{
+			{sessionID: "session_123", refreshTokenID: "refresh_token_id_456", ipAddress: "10.0.0.1", userAgent: "Safari"},
+		}, sessionService.touched)
+	})
+}
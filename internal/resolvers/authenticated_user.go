@@ -0,0 +1,52 @@
+package resolvers
+
+import (
+	"context"
+
+	"github.com/weeb-vip/auth/http/handlers/requestinfo"
+	"github.com/weeb-vip/auth/internal/entities"
+	"github.com/weeb-vip/auth/internal/jwt"
+	"github.com/weeb-vip/auth/internal/services/refresh_token"
+)
+
+const AccessDeniedCode = "ACCESS_DENIED"
+
+// authenticatedUserID returns the user ID of a request made with a regular user access token.
+// Tokens issued for a specific purpose (email verification, mfa challenge, ...) are rejected.
+func authenticatedUserID(ctx context.Context) (string, error) {
+	req := requestinfo.FromContext(ctx)
+
+	if req.UserID == nil || req.UserType == nil || *req.UserType != requestinfo.UserTypeUser || req.Purpose != nil {
+		return "", &entities.ServiceError{
+			Code:    AccessDeniedCode,
+			Message: "access denied",
+		}
+	}
+
+	return *req.UserID, nil
+}
+
+// currentSessionID returns the session of the access token used for the request, found through the refresh
+// token it was issued with, or an empty string when the session cannot be told.
+func currentSessionID(
+	ctx context.Context,
+	jwtTokenizer jwt.Tokenizer,
+	refreshTokenService refresh_token.RefreshToken,
+) string {
+	req := requestinfo.FromContext(ctx)
+	if req.RawToken == nil || req.UserID == nil {
+		return ""
+	}
+
+	claims, err := jwtTokenizer.GetClaims(*req.RawToken)
+	if err != nil || claims.RefreshToken == nil {
+		return ""
+	}
+
+	refreshToken, err := refreshTokenService.GetToken(*claims.RefreshToken)
+	if err != nil || refreshToken == nil || refreshToken.UserID != *req.UserID {
+		return ""
+	}
+
+	return refreshToken.FamilyID
+}
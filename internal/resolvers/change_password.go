@@ -0,0 +1,72 @@
+package resolvers
+
+import (
+	"context"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+
+	"github.com/weeb-vip/auth/graph/model"
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/jwt"
+	"github.com/weeb-vip/auth/internal/logger"
+	"github.com/weeb-vip/auth/internal/metrics"
+	"github.com/weeb-vip/auth/internal/services/credential"
+	"github.com/weeb-vip/auth/internal/services/mail"
+	"github.com/weeb-vip/auth/internal/services/refresh_token"
+	"github.com/weeb-vip/auth/internal/services/session"
+)
+
+// ChangePassword replaces the password of the signed in user, who stays signed in while every other session is revoked.
+func ChangePassword( // nolint
+	ctx context.Context,
+	credentialService credential.Credential,
+	sessionService session.Session,
+	tokenDenylist denylist.Denylist,
+	refreshTokenService refresh_token.RefreshToken,
+	mailService mail.MailService,
+	jwtTokenizer jwt.Tokenizer,
+	userProducer func(ctx context.Context, message *kafka.Message) error,
+	input model.ChangePasswordInput,
+) (bool, error) {
+	log := logger.FromCtx(ctx)
+
+	userID, err := authenticatedUserID(ctx)
+	if err != nil {
+		_, err := handleError(ctx, "false", err)
+		return false, err
+	}
+
+	credentials, err := credentialService.GetCredentialsByUserID(ctx, userID)
+	if err == nil {
+		credentials, err = credentialService.ChangePassword(ctx, credentials.Username, input.OldPassword, input.NewPassword)
+	}
+
+	if err != nil {
+		metrics.GetAppMetrics().AuthRequestMetric("change_password", metrics.Error)
+		log.Warn().
+			Err(err).
+			Str("user_id", userID).
+			Msg("Password change failed")
+
+		_, err := handleError(ctx, "false", err)
+		return false, err
+	}
+
+	keepSessionID := currentSessionID(ctx, jwtTokenizer, refreshTokenService)
+
+	err = afterPasswordChange(ctx, sessionService, tokenDenylist, mailService, userProducer, credentials, PasswordChangeReasonChange, keepSessionID)
+	if err != nil {
+		metrics.GetAppMetrics().AuthRequestMetric("change_password", metrics.Error)
+		_, err := handleError(ctx, "false", err)
+		return false, err
+	}
+
+	metrics.GetAppMetrics().AuthRequestMetric("change_password", metrics.Success)
+
+	log.Info().
+		Str("user_id", userID).
+		Bool("kept_current_session", keepSessionID != "").
+		Msg("Password changed successfully")
+
+	return true, nil
+}
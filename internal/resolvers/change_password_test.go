@@ -0,0 +1,119 @@
+package resolvers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/weeb-vip/auth/graph/model"
+	"github.com/weeb-vip/auth/http/handlers/requestinfo"
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/jwt"
+	"github.com/weeb-vip/auth/internal/services/credential"
+	CredentialModels "github.com/weeb-vip/auth/internal/services/credential/models"
+)
+
+// newAuthenticatedContext builds the context of a request the gateway forwarded for the given user and access token.
+func newAuthenticatedContext(userID string, rawToken string) context.Context {
+	request := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	request.Header.Set("x-user-id", userID)
+
+	if rawToken != "" {
+		request.Header.Set("x-raw-token", rawToken)
+	}
+
+	var ctx context.Context
+
+	requestinfo.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})).ServeHTTP(httptest.NewRecorder(), request)
+
+	return graphql.WithResponseContext(ctx, graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+}
+
+type incorrectPasswordCredentialService struct {
+	MockCredentialService
+}
+
+func (m *incorrectPasswordCredentialService) ChangePassword(ctx context.Context, username string, oldPassword string, newPassword string) (*CredentialModels.Credential, error) {
+	return nil, &credential.Error{Code: credential.CredentialErrorIncorrectPassword, Message: "current password is incorrect"}
+}
+
+func TestChangePassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokenizer := newTestTokenizer(t)
+	input := model.ChangePasswordInput{OldPassword: "current-password", NewPassword: "a-new-password"}
+
+	subject := "user_123"
+	refreshToken := "existing_refresh_token"
+	accessToken, err := tokenizer.Tokenize(jwt.Claims{Subject: &subject, RefreshToken: &refreshToken})
+	assert.NoError(t, err)
+
+	t.Run("keeps the current session and revokes the others", func(t *testing.T) {
+		ctx := newAuthenticatedContext("user_123", accessToken)
+		sessionService := &recordingSessionService{}
+		mailService := &recordingMailService{}
+		producer := &recordingProducer{}
+		tokenDenylist := denylist.NewMemoryDenylist()
+
+		ok, err := ChangePassword(ctx, NewMockCredentialService(ctrl), sessionService, tokenDenylist, NewMockRefreshTokenService(ctrl), mailService, tokenizer, producer.produce, input)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Empty(t, graphql.GetErrors(ctx))
+
+		assert.Equal(t, []revokedSessions{{userID: "user_123", keepSessionID: "session_123"}}, sessionService.revoked)
+
+		denied, err := tokenDenylist.IsDenied(ctx, denylist.Token{Subject: "user_123", IssuedAt: time.Now().Add(-time.Minute)})
+		assert.NoError(t, err)
+		assert.True(t, denied, "access tokens issued before the change are revoked")
+
+		assert.Len(t, producer.messages, 1)
+		var event PasswordChangedEvent
+		assert.NoError(t, json.Unmarshal(producer.messages[0].Value, &event))
+		assert.Equal(t, PasswordChangeReasonChange, event.Reason)
+
+		assert.Len(t, mailService.sent, 1)
+		assert.Equal(t, []string{"testuser"}, mailService.sent[0].to)
+	})
+
+	t.Run("revokes every session when the current one cannot be told", func(t *testing.T) {
+		ctx := newAuthenticatedContext("user_123", "")
+		sessionService := &recordingSessionService{}
+
+		ok, err := ChangePassword(ctx, NewMockCredentialService(ctrl), sessionService, denylist.NewMemoryDenylist(), NewMockRefreshTokenService(ctrl), &recordingMailService{}, tokenizer, (&recordingProducer{}).produce, input)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []revokedSessions{{userID: "user_123", keepSessionID: ""}}, sessionService.revoked)
+	})
+
+	t.Run("reports an incorrect current password", func(t *testing.T) {
+		ctx := newAuthenticatedContext("user_123", accessToken)
+		sessionService := &recordingSessionService{}
+		mailService := &recordingMailService{}
+
+		ok, err := ChangePassword(ctx, &incorrectPasswordCredentialService{}, sessionService, denylist.NewMemoryDenylist(), NewMockRefreshTokenService(ctrl), mailService, tokenizer, (&recordingProducer{}).produce, input)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, "INCORRECT_PASSWORD", graphql.GetErrors(ctx)[0].Extensions["code"])
+		assert.Empty(t, sessionService.revoked)
+		assert.Empty(t, mailService.sent)
+	})
+
+	t.Run("rejects a guest", func(t *testing.T) {
+		ctx := newAuthenticatedContext("guest_123", "")
+
+		ok, err := ChangePassword(ctx, NewMockCredentialService(ctrl), &recordingSessionService{}, denylist.NewMemoryDenylist(), NewMockRefreshTokenService(ctrl), &recordingMailService{}, tokenizer, (&recordingProducer{}).produce, input)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, AccessDeniedCode, graphql.GetErrors(ctx)[0].Extensions["code"])
+	})
+}
@@ -5,26 +5,35 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/99designs/gqlgen/graphql"
+
 	"github.com/weeb-vip/auth/config"
 	"github.com/weeb-vip/auth/graph/model"
+	"github.com/weeb-vip/auth/http/handlers/requestinfo"
 	"github.com/weeb-vip/auth/http/handlers/responsecontext"
+	"github.com/weeb-vip/auth/internal/denylist"
 	"github.com/weeb-vip/auth/internal/jwt"
 	"github.com/weeb-vip/auth/internal/logger"
 	"github.com/weeb-vip/auth/internal/metrics"
 	"github.com/weeb-vip/auth/internal/services/refresh_token"
+	RefreshTokenModels "github.com/weeb-vip/auth/internal/services/refresh_token/models"
 
 	"github.com/weeb-vip/auth/internal/services/credential"
+	"github.com/weeb-vip/auth/internal/services/mfa"
 	"github.com/weeb-vip/auth/internal/services/session"
 	SessionModels "github.com/weeb-vip/auth/internal/services/session/models"
 	"github.com/weeb-vip/auth/internal/ulid"
+	"github.com/weeb-vip/auth/internal/xerrors"
 )
 
 func CreateSession( // nolint
 	ctx context.Context,
 	credentialService credential.Credential,
+	mfaService mfa.MFA,
 	sessionService session.Session,
 	refreshTokenService refresh_token.RefreshToken,
 	jwtTokenizer jwt.Tokenizer,
+	tokenDenylist denylist.Denylist,
 	config *config.Config,
 	input *model.LoginInput,
 ) (*model.SigninResult, error) {
@@ -40,7 +49,7 @@ func CreateSession( // nolint
 		Str("username", username).
 		Msg("CreateSession started")
 
-	createdSession, err := createSession(ctx, input, sessionService, credentialService)
+	createdSession, challenge, err := createSession(ctx, input, sessionService, credentialService, mfaService, jwtTokenizer, config)
 
 	if err != nil {
 		metrics.GetAppMetrics().AuthRequestMetric("create_session", metrics.Error)
@@ -56,18 +65,69 @@ func CreateSession( // nolint
 		return nil, err
 	}
 
+	if challenge != nil {
+		log.Info().
+			Str("username", username).
+			Dur("duration", time.Since(startTime)).
+			Msg("CreateSession requires multi-factor authentication")
+
+		graphql.AddError(ctx, xerrors.ChallengeError(
+			"multi-factor authentication required",
+			MFARequiredCode,
+			"mfa",
+			*challenge,
+			"totp",
+			nil,
+		))
+
+		return nil, nil
+	}
+
+	result, err := issueSession(ctx, createdSession, sessionService, refreshTokenService, jwtTokenizer, tokenDenylist, config)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.GetAppMetrics().AuthRequestMetric("create_session", metrics.Success)
+	metrics.GetAppMetrics().SessionOperationMetric("create", metrics.Success)
+
+	log.Info().
+		Str("username", username).
+		Str("user_id", createdSession.UserID).
+		Dur("duration", time.Since(startTime)).
+		Msg("CreateSession completed successfully")
+
+	return result, nil
+}
+
+// issueSession mints the refresh token and access token for a session and sets them as cookies, ending the
+// oldest sessions of the user beyond the session limit.
+func issueSession(
+	ctx context.Context,
+	createdSession *SessionModels.Session,
+	sessionService session.Session,
+	refreshTokenService refresh_token.RefreshToken,
+	jwtTokenizer jwt.Tokenizer,
+	tokenDenylist denylist.Denylist,
+	config *config.Config,
+) (*model.SigninResult, error) {
 	subject := createdSession.UserID
 
-	refreshToken, err := refreshTokenService.CreateToken(subject)
+	evictSessions(ctx, sessionService, tokenDenylist, createdSession)
+
+	refreshToken, err := refreshTokenService.CreateToken(subject, createdSession.ID)
 	if err != nil {
 		return nil, err
 	}
 
+	touchSession(ctx, sessionService, refreshToken)
+
 	token, err := jwtTokenizer.Tokenize(jwt.Claims{
 		Subject:      &subject,
 		TTL:          nil,
 		Purpose:      nil,
 		RefreshToken: &refreshToken.Token,
+		SessionID:    &createdSession.ID,
 	})
 
 	if err != nil {
@@ -97,15 +157,6 @@ func CreateSession( // nolint
 	responseWriter.Header().Add("Set-Cookie", accessTokenCookieStr)
 	responseWriter.Header().Add("Set-Cookie", refreshTokenCookieStr)
 
-	metrics.GetAppMetrics().AuthRequestMetric("create_session", metrics.Success)
-	metrics.GetAppMetrics().SessionOperationMetric("create", metrics.Success)
-
-	log.Info().
-		Str("username", username).
-		Str("user_id", createdSession.UserID).
-		Dur("duration", time.Since(startTime)).
-		Msg("CreateSession completed successfully")
-
 	return &model.SigninResult{
 		ID: createdSession.UserID,
 		Credentials: &model.Credentials{
@@ -115,29 +166,71 @@ func CreateSession( // nolint
 	}, nil
 }
 
+// touchSession links the session to its new refresh token and marks it as seen. The session works without,
+// so a failure is only logged.
+func touchSession(
+	ctx context.Context,
+	sessionService session.Session,
+	refreshToken *RefreshTokenModels.RefreshToken,
+) {
+	err := sessionService.TouchSession(ctx, refreshToken.FamilyID, refreshToken.ID, requestinfo.RemoteIP(ctx), requestinfo.UserAgent(ctx))
+	if err != nil {
+		log := logger.FromCtx(ctx)
+		log.Error().
+			Err(err).
+			Str("user_id", refreshToken.UserID).
+			Str("session_id", refreshToken.FamilyID).
+			Msg("Failed to update session activity")
+	}
+}
+
 func createSession(ctx context.Context,
 	input *model.LoginInput,
 	sessionService session.Session,
 	credentialService credential.Credential,
-) (*SessionModels.Session, error) {
+	mfaService mfa.MFA,
+	jwtTokenizer jwt.Tokenizer,
+	config *config.Config,
+) (*SessionModels.Session, *string, error) {
 	if input == nil {
-		return sessionService.CreateSession(ctx, ulid.New("guest"))
+		guestSession, err := sessionService.CreateSession(ctx, ulid.New("guest"), requestinfo.RemoteIP(ctx), requestinfo.UserAgent(ctx))
+
+		return guestSession, nil, err
 	}
 
-	return createUserSession(ctx, sessionService, credentialService, *input)
+	return createUserSession(ctx, sessionService, credentialService, mfaService, jwtTokenizer, config, *input)
 }
 
 func createUserSession(
 	ctx context.Context,
 	sessionService session.Session,
 	credentialService credential.Credential,
+	mfaService mfa.MFA,
+	jwtTokenizer jwt.Tokenizer,
+	config *config.Config,
 	input model.LoginInput,
-) (*SessionModels.Session, error) {
+) (*SessionModels.Session, *string, error) {
 	result, err := credentialService.SignIn(ctx, input.Username, input.Password)
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return sessionService.CreateSession(ctx, result.UserID)
+	mfaEnabled, err := mfaService.IsEnabled(ctx, result.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if mfaEnabled {
+		challenge, err := createMFAChallenge(jwtTokenizer, config, result.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return nil, &challenge, nil
+	}
+
+	createdSession, err := sessionService.CreateSession(ctx, result.UserID, requestinfo.RemoteIP(ctx), requestinfo.UserAgent(ctx))
+
+	return createdSession, nil, err
 }
@@ -12,8 +12,10 @@ import (
 	"github.com/weeb-vip/auth/graph/model"
 	"github.com/weeb-vip/auth/http/handlers/responsecontext"
 	"github.com/weeb-vip/auth/internal/db"
+	"github.com/weeb-vip/auth/internal/denylist"
 	"github.com/weeb-vip/auth/internal/jwt"
 	CredentialModels "github.com/weeb-vip/auth/internal/services/credential/models"
+	"github.com/weeb-vip/auth/internal/services/mfa"
 	SessionModels "github.com/weeb-vip/auth/internal/services/session/models"
 	RefreshTokenModels "github.com/weeb-vip/auth/internal/services/refresh_token/models"
 
@@ -41,10 +43,18 @@ func (m *MockCredentialService) GetCredentials(ctx context.Context, username str
 	return &CredentialModels.Credential{UserID: "user_123"}, nil
 }
 
+func (m *MockCredentialService) CheckPassword(ctx context.Context, username string, password string) error {
+	return nil
+}
+
 func (m *MockCredentialService) UpdatePassword(ctx context.Context, username string, newPassword string) error {
 	return nil
 }
 
+func (m *MockCredentialService) ChangePassword(ctx context.Context, username string, oldPassword string, newPassword string) (*CredentialModels.Credential, error) {
+	return &CredentialModels.Credential{UserID: "user_123", Username: username}, nil
+}
+
 func (m *MockCredentialService) ActivateCredentials(ctx context.Context, identifier string) error {
 	return nil
 }
@@ -53,6 +63,54 @@ func (m *MockCredentialService) GetCredentialsByIdentifier(ctx context.Context,
 	return &CredentialModels.Credential{UserID: "user_123"}, nil
 }
 
+func (m *MockCredentialService) GetCredentialsByUserID(ctx context.Context, userID string) (*CredentialModels.Credential, error) {
+	return &CredentialModels.Credential{UserID: userID, Username: "testuser"}, nil
+}
+
+type MockMFAService struct {
+	ctrl    *gomock.Controller
+	enabled bool
+}
+
+func NewMockMFAService(ctrl *gomock.Controller) *MockMFAService {
+	return &MockMFAService{ctrl: ctrl}
+}
+
+func (m *MockMFAService) Enroll(ctx context.Context, userID string, accountName string) (*mfa.Enrollment, error) {
+	return &mfa.Enrollment{Secret: "SECRET", URI: "otpauth://totp/WEEB%20VIP:" + accountName + "?secret=SECRET"}, nil
+}
+
+func (m *MockMFAService) ConfirmEnrollment(ctx context.Context, userID string, code string) ([]string, error) {
+	return []string{"abcde-fghjk"}, nil
+}
+
+func (m *MockMFAService) Disable(ctx context.Context, userID string) error {
+	return nil
+}
+
+func (m *MockMFAService) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	return m.enabled, nil
+}
+
+func (m *MockMFAService) VerifyCode(ctx context.Context, userID string, code string) (mfa.VerificationMethod, error) {
+	switch code {
+	case "123456":
+		return mfa.VerificationMethodTOTP, nil
+	case "abcde-fghjk":
+		return mfa.VerificationMethodRecoveryCode, nil
+	default:
+		return "", &mfa.Error{Code: mfa.MFAErrorInvalidCode, Message: "invalid verification code"}
+	}
+}
+
+func (m *MockMFAService) RegenerateRecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+	return []string{"abcde-fghjk"}, nil
+}
+
+func (m *MockMFAService) CountRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	return 9, nil
+}
+
 type MockSessionService struct {
 	ctrl *gomock.Controller
 }
@@ -61,13 +119,39 @@ func NewMockSessionService(ctrl *gomock.Controller) *MockSessionService {
 	return &MockSessionService{ctrl: ctrl}
 }
 
-func (m *MockSessionService) CreateSession(ctx context.Context, userID string) (*SessionModels.Session, error) {
+func (m *MockSessionService) CreateSession(ctx context.Context, userID string, ipAddress string, userAgent string) (*SessionModels.Session, error) {
 	return &SessionModels.Session{
 		BaseModel: db.BaseModel{ID: "session_123"},
 		UserID:    userID,
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
 	}, nil
 }
 
+func (m *MockSessionService) TouchSession(ctx context.Context, sessionID string, refreshTokenID string, ipAddress string, userAgent string) error {
+	return nil
+}
+
+func (m *MockSessionService) ListUserSessions(ctx context.Context, userIDs []string) (map[string][]*SessionModels.Session, error) {
+	return map[string][]*SessionModels.Session{}, nil
+}
+
+func (m *MockSessionService) CheckSession(ctx context.Context, userID string, sessionID string) error {
+	return nil
+}
+
+func (m *MockSessionService) EvictSessions(ctx context.Context, userID string, keepSessionID string) ([]*SessionModels.Session, error) {
+	return nil, nil
+}
+
+func (m *MockSessionService) RevokeUserSessions(ctx context.Context, userID string, keepSessionID string) error {
+	return nil
+}
+
+func (m *MockSessionService) RevokeSession(ctx context.Context, userID string, sessionID string) (bool, error) {
+	return true, nil
+}
+
 type MockRefreshTokenService struct {
 	ctrl *gomock.Controller
 }
@@ -78,8 +162,10 @@ func NewMockRefreshTokenService(ctrl *gomock.Controller) *MockRefreshTokenServic
 
 func (m *MockRefreshTokenService) GetToken(token string) (*RefreshTokenModels.RefreshToken, error) {
 	return &RefreshTokenModels.RefreshToken{
-		Token:  token,
-		UserID: "user_123",
+		Token:    token,
+		UserID:   "user_123",
+		FamilyID: "session_123",
+		Expiry:   time.Now().Add(time.Hour).Unix(),
 	}, nil
 }
 
@@ -87,10 +173,21 @@ func (m *MockRefreshTokenService) GetTokenByUserID(userID string) (*RefreshToken
 	return nil, nil
 }
 
-func (m *MockRefreshTokenService) CreateToken(userID string) (*RefreshTokenModels.RefreshToken, error) {
+func (m *MockRefreshTokenService) CreateToken(userID string, sessionID string) (*RefreshTokenModels.RefreshToken, error) {
+	return &RefreshTokenModels.RefreshToken{
+		BaseModel: db.BaseModel{ID: "refresh_token_id_123"},
+		Token:     "refresh_token_123",
+		UserID:    userID,
+		FamilyID:  sessionID,
+	}, nil
+}
+
+func (m *MockRefreshTokenService) RotateToken(current *RefreshTokenModels.RefreshToken) (*RefreshTokenModels.RefreshToken, error) {
 	return &RefreshTokenModels.RefreshToken{
-		Token:  "refresh_token_123",
-		UserID: userID,
+		BaseModel: db.BaseModel{ID: "refresh_token_id_456"},
+		Token:     "refresh_token_123",
+		UserID:    current.UserID,
+		FamilyID:  current.FamilyID,
 	}, nil
 }
 
@@ -123,6 +220,7 @@ func TestCreateSession(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockCredentialService := NewMockCredentialService(ctrl)
+	mockMFAService := NewMockMFAService(ctrl)
 	mockSessionService := NewMockSessionService(ctrl)
 	mockRefreshTokenService := NewMockRefreshTokenService(ctrl)
 	mockJWTTokenizer := NewMockJWTTokenizer(ctrl)
@@ -147,9 +245,11 @@ func TestCreateSession(t *testing.T) {
 		result, err := CreateSession(
 			ctx,
 			mockCredentialService,
+			mockMFAService,
 			mockSessionService,
 			mockRefreshTokenService,
 			mockJWTTokenizer,
+			denylist.NewMemoryDenylist(),
 			testConfig,
 			input,
 		)
@@ -270,9 +370,11 @@ func TestCreateSession(t *testing.T) {
 		_, err := CreateSession(
 			ctx,
 			mockCredentialService,
+			mockMFAService,
 			mockSessionService,
 			mockRefreshTokenService,
 			mockJWTTokenizer,
+			denylist.NewMemoryDenylist(),
 			localConfig,
 			input,
 		)
@@ -307,9 +409,11 @@ func TestCreateSession(t *testing.T) {
 		_, err := CreateSession(
 			ctx,
 			mockCredentialService,
+			mockMFAService,
 			mockSessionService,
 			mockRefreshTokenService,
 			mockJWTTokenizer,
+			denylist.NewMemoryDenylist(),
 			configWithDotDomain,
 			input,
 		)
@@ -338,9 +442,11 @@ func TestCreateSession(t *testing.T) {
 		result, err := CreateSession(
 			ctx,
 			mockCredentialService,
+			mockMFAService,
 			guestSessionService,
 			mockRefreshTokenService,
 			mockJWTTokenizer,
+			denylist.NewMemoryDenylist(),
 			testConfig,
 			nil, // nil input for guest session
 		)
@@ -0,0 +1,37 @@
+package resolvers
+
+import (
+	"context"
+	"time"
+
+	"github.com/weeb-vip/auth/http/handlers/requestinfo"
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/jwt"
+)
+
+// denyCurrentAccessToken revokes the access token used for the request, if there is one.
+func denyCurrentAccessToken(ctx context.Context, jwtTokenizer jwt.Tokenizer, tokenDenylist denylist.Denylist) error {
+	rawToken := requestinfo.RawToken(ctx)
+	if rawToken == "" {
+		return nil
+	}
+
+	claims, err := jwtTokenizer.GetClaims(rawToken)
+	if err != nil || claims.ID == nil || claims.ExpiresAt == nil {
+		// Nothing to revoke, the token is already rejected or expires soon enough.
+		return nil
+	}
+
+	return tokenDenylist.Deny(ctx, *claims.ID, *claims.ExpiresAt)
+}
+
+// denySessionAccessTokens revokes every access token issued for the session. Access tokens issued before they
+// named their session are left to expire.
+func denySessionAccessTokens(ctx context.Context, tokenDenylist denylist.Denylist, sessionID string) error {
+	return tokenDenylist.DenySession(ctx, sessionID, time.Now().Add(jwt.DefaultTTL))
+}
+
+// denyUserAccessTokens revokes every access token issued to the user before issuedBefore.
+func denyUserAccessTokens(ctx context.Context, tokenDenylist denylist.Denylist, userID string, issuedBefore time.Time) error {
+	return tokenDenylist.DenySubject(ctx, userID, issuedBefore, issuedBefore.Add(jwt.DefaultTTL))
+}
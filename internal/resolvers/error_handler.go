@@ -8,7 +8,14 @@ import (
 	"github.com/99designs/gqlgen/graphql"
 
 	"github.com/weeb-vip/auth/internal/entities"
+	"github.com/weeb-vip/auth/internal/passwordpolicy"
 	"github.com/weeb-vip/auth/internal/services/credential"
+	"github.com/weeb-vip/auth/internal/services/lockout"
+	"github.com/weeb-vip/auth/internal/services/mfa"
+	"github.com/weeb-vip/auth/internal/services/passkey"
+	"github.com/weeb-vip/auth/internal/services/passwordreset"
+	"github.com/weeb-vip/auth/internal/services/refresh_token"
+	"github.com/weeb-vip/auth/internal/services/session"
 	"github.com/weeb-vip/auth/internal/xerrors"
 )
 
@@ -16,6 +23,13 @@ func handleError(ctx context.Context, result interface{}, err error) (interface{
 	log.Println(result)
 	log.Println(err)
 
+	var policyErr *passwordpolicy.Error
+	if errors.As(err, &policyErr) {
+		graphql.AddError(ctx, xerrors.PolicyViolationError(err.Error(), passwordpolicy.ErrorCode, policyErr.Codes()))
+
+		return result, nil
+	}
+
 	graphql.AddError(ctx, xerrors.ServiceError(err.Error(), getCode(err)))
 
 	return result, nil
@@ -27,6 +41,41 @@ func getCode(err error) string {
 		return credErr.Code.String()
 	}
 
+	var lockoutErr *lockout.Error
+	if ok := errors.As(err, &lockoutErr); ok {
+		return lockoutErr.Code.String()
+	}
+
+	var mfaErr *mfa.Error
+	if ok := errors.As(err, &mfaErr); ok {
+		return mfaErr.Code.String()
+	}
+
+	var passkeyErr *passkey.Error
+	if ok := errors.As(err, &passkeyErr); ok {
+		return passkeyErr.Code.String()
+	}
+
+	var passwordResetErr *passwordreset.Error
+	if ok := errors.As(err, &passwordResetErr); ok {
+		return passwordResetErr.Code.String()
+	}
+
+	var refreshTokenErr *refresh_token.Error
+	if ok := errors.As(err, &refreshTokenErr); ok {
+		return refreshTokenErr.Code.String()
+	}
+
+	var sessionErr *session.Error
+	if ok := errors.As(err, &sessionErr); ok {
+		return sessionErr.Code.String()
+	}
+
+	var policyErr *passwordpolicy.Error
+	if ok := errors.As(err, &policyErr); ok {
+		return passwordpolicy.ErrorCode
+	}
+
 	var servErr *entities.ServiceError
 	if ok := errors.As(err, &servErr); ok {
 		return servErr.Code
@@ -0,0 +1,81 @@
+package resolvers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+const RecoveryCodeUsedEventName = "recovery_code_used"
+
+type RecoveryCodeUsedEvent struct {
+	Event          string    `json:"event"`
+	UserID         string    `json:"user_id"`
+	RemainingCodes int       `json:"remaining_codes"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+const RefreshTokenReuseDetectedEventName = "refresh_token_reuse_detected"
+
+type RefreshTokenReuseDetectedEvent struct {
+	Event     string    `json:"event"`
+	UserID    string    `json:"user_id"`
+	FamilyID  string    `json:"family_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+const PasswordChangedEventName = "password_changed"
+
+const (
+	PasswordChangeReasonReset  = "reset"
+	PasswordChangeReasonChange = "change"
+)
+
+type PasswordChangedEvent struct {
+	Event     string    `json:"event"`
+	UserID    string    `json:"user_id"`
+	Reason    string    `json:"reason"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+const UserLoggedOutEventName = "user_logged_out"
+
+type UserLoggedOutEvent struct {
+	Event      string    `json:"event"`
+	UserID     string    `json:"user_id"`
+	SessionID  string    `json:"session_id,omitempty"`
+	AllDevices bool      `json:"all_devices"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+const SessionsRevokedEventName = "sessions_revoked"
+
+// SessionsRevokedEvent reports sessions a user ended from another session: the one with SessionID, or every
+// other session when OtherSessions is set.
+type SessionsRevokedEvent struct {
+	Event         string    `json:"event"`
+	UserID        string    `json:"user_id"`
+	SessionID     string    `json:"session_id,omitempty"`
+	OtherSessions bool      `json:"other_sessions"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// publishEvent produces the event keyed by user ID so events of one user stay ordered within a partition.
+func publishEvent(
+	ctx context.Context,
+	userProducer func(ctx context.Context, message *kafka.Message) error,
+	userID string,
+	event any,
+) error {
+	payloadBytes, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return userProducer(ctx, &kafka.Message{
+		Key:   []byte(userID),
+		Value: payloadBytes,
+	})
+}
@@ -3,12 +3,149 @@ package resolvers
 import (
 	"context"
 	"fmt"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 
 	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/http/handlers/requestinfo"
 	"github.com/weeb-vip/auth/http/handlers/responsecontext"
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/jwt"
+	"github.com/weeb-vip/auth/internal/logger"
+	"github.com/weeb-vip/auth/internal/metrics"
+	"github.com/weeb-vip/auth/internal/services/refresh_token"
+	"github.com/weeb-vip/auth/internal/services/session"
 )
 
-func Logout(ctx context.Context, config *config.Config) (bool, error) {
+// Logout signs out the session of the refresh token, given as an argument or read from the refresh_token
+// cookie, or every session of the signed in user with allDevices. The access token used for the request is
+// revoked either way, and the cookies are cleared even when revoking fails.
+func Logout( // nolint
+	ctx context.Context,
+	config *config.Config,
+	sessionService session.Session,
+	refreshTokenService refresh_token.RefreshToken,
+	jwtTokenizer jwt.Tokenizer,
+	tokenDenylist denylist.Denylist,
+	userProducer func(ctx context.Context, message *kafka.Message) error,
+	refreshToken *string,
+	allDevices *bool,
+) (bool, error) {
+	log := logger.FromCtx(ctx)
+
+	clearTokenCookies(ctx, config)
+
+	token := requestinfo.RefreshToken(ctx)
+	if refreshToken != nil {
+		token = *refreshToken
+	}
+
+	event, err := revokeLoggedOutSessions(ctx, sessionService, refreshTokenService, tokenDenylist, token, allDevices != nil && *allDevices)
+	if err == nil {
+		err = denyCurrentAccessToken(ctx, jwtTokenizer, tokenDenylist)
+	}
+
+	if err != nil {
+		metrics.GetAppMetrics().AuthRequestMetric("logout", metrics.Error)
+		log.Error().
+			Err(err).
+			Msg("Failed to revoke session on logout")
+
+		_, err := handleError(ctx, "false", err)
+		return false, err
+	}
+
+	metrics.GetAppMetrics().AuthRequestMetric("logout", metrics.Success)
+
+	if event == nil {
+		return true, nil
+	}
+
+	log.Info().
+		Str("user_id", event.UserID).
+		Str("session_id", event.SessionID).
+		Bool("all_devices", event.AllDevices).
+		Msg("User logged out")
+
+	err = publishEvent(ctx, userProducer, event.UserID, event)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("user_id", event.UserID).
+			Msg("Failed to publish user logged out event")
+	}
+
+	return true, nil
+}
+
+// revokeLoggedOutSessions returns the event to publish, or nil when the request did not tell whose session
+// to end: logging out without a known refresh token or authenticated user only clears the cookies. Ending
+// every session takes an authenticated user, a refresh token alone may be an old one that leaked.
+func revokeLoggedOutSessions(
+	ctx context.Context,
+	sessionService session.Session,
+	refreshTokenService refresh_token.RefreshToken,
+	tokenDenylist denylist.Denylist,
+	token string,
+	allDevices bool,
+) (*UserLoggedOutEvent, error) {
+	event := &UserLoggedOutEvent{
+		Event:      UserLoggedOutEventName,
+		AllDevices: allDevices,
+		Timestamp:  time.Now(),
+	}
+
+	userID, err := authenticatedUserID(ctx)
+	if err == nil {
+		event.UserID = userID
+	}
+
+	if allDevices {
+		if err != nil {
+			return nil, err
+		}
+
+		err = sessionService.RevokeUserSessions(ctx, event.UserID, "")
+		if err != nil {
+			return nil, err
+		}
+
+		return event, denyUserAccessTokens(ctx, tokenDenylist, event.UserID, event.Timestamp)
+	}
+
+	if token != "" {
+		current, err := refreshTokenService.GetToken(token)
+		if err != nil {
+			return nil, err
+		}
+
+		// Holding the refresh token is enough to end its session, whoever is signed in.
+		if current != nil {
+			event.UserID = current.UserID
+			event.SessionID = current.FamilyID
+		}
+	}
+
+	if event.UserID == "" {
+		return nil, nil
+	}
+
+	if event.SessionID != "" {
+		_, err := sessionService.RevokeSession(ctx, event.UserID, event.SessionID)
+
+		return event, err
+	}
+
+	// Tokens issued before refresh token families have no session to end.
+	if token != "" {
+		return event, refreshTokenService.DeleteToken(token)
+	}
+
+	return event, nil
+}
+
+func clearTokenCookies(ctx context.Context, config *config.Config) {
 	// Get response writer from context to set cookies
 	responseWriter := responsecontext.FromContext(ctx)
 
@@ -27,6 +164,4 @@ func Logout(ctx context.Context, config *config.Config) (bool, error) {
 	// Set cookies manually to bypass Go's domain normalization
 	responseWriter.Header().Add("Set-Cookie", accessTokenCookieStr)
 	responseWriter.Header().Add("Set-Cookie", refreshTokenCookieStr)
-
-	return true, nil
-}
\ No newline at end of file
+}
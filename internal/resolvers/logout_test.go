@@ -2,31 +2,70 @@ package resolvers
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
 	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/http/handlers/requestinfo"
 	"github.com/weeb-vip/auth/http/handlers/responsecontext"
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/jwt"
+	RefreshTokenModels "github.com/weeb-vip/auth/internal/services/refresh_token/models"
 )
 
+// newLogoutContext builds the context of a logout request carrying the refresh token cookie, if any.
+func newLogoutContext(userID string, rawToken string, refreshToken string) context.Context {
+	request := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	if userID != "" {
+		request.Header.Set("x-user-id", userID)
+	}
+	if rawToken != "" {
+		request.Header.Set("x-raw-token", rawToken)
+	}
+	if refreshToken != "" {
+		request.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
+	}
+
+	var ctx context.Context
+
+	requestinfo.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})).ServeHTTP(httptest.NewRecorder(), request)
+
+	ctx = graphql.WithResponseContext(ctx, graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+
+	return responsecontext.WithResponseWriter(ctx, httptest.NewRecorder())
+}
+
 func TestLogout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokenizer := newTestTokenizer(t)
+
 	tests := []struct {
-		name         string
-		cookieDomain string
+		name                       string
+		cookieDomain               string
 		expectedAccessTokenCookie  string
 		expectedRefreshTokenCookie string
 	}{
 		{
-			name:         "logout with standard domain",
-			cookieDomain: ".weeb.vip",
+			name:                       "logout with standard domain",
+			cookieDomain:               ".weeb.vip",
 			expectedAccessTokenCookie:  "access_token=; Path=/; Domain=.weeb.vip; Max-Age=0; HttpOnly; Secure; SameSite=None",
 			expectedRefreshTokenCookie: "refresh_token=; Path=/; Domain=.weeb.vip; Max-Age=0; HttpOnly; Secure; SameSite=None",
 		},
 		{
-			name:         "logout with localhost domain",
-			cookieDomain: "localhost",
+			name:                       "logout with localhost domain",
+			cookieDomain:               "localhost",
 			expectedAccessTokenCookie:  "access_token=; Path=/; Domain=localhost; Max-Age=0; HttpOnly; Secure; SameSite=None",
 			expectedRefreshTokenCookie: "refresh_token=; Path=/; Domain=localhost; Max-Age=0; HttpOnly; Secure; SameSite=None",
 		},
@@ -45,10 +84,10 @@ func TestLogout(t *testing.T) {
 			recorder := httptest.NewRecorder()
 
 			// Create context with response writer
-			ctx := responsecontext.WithResponseWriter(context.Background(), recorder)
+			ctx := responsecontext.WithResponseWriter(newAuthenticatedContext("", ""), recorder)
 
 			// Call logout function
-			result, err := Logout(ctx, cfg)
+			result, err := Logout(ctx, cfg, &recordingSessionService{}, NewMockRefreshTokenService(ctrl), tokenizer, denylist.NewMemoryDenylist(), (&recordingProducer{}).produce, nil, nil)
 
 			// Assertions
 			assert.NoError(t, err)
@@ -77,4 +116,110 @@ func TestLogout(t *testing.T) {
 			assert.True(t, refreshTokenFound, "refresh_token cookie should be set")
 		})
 	}
-}
\ No newline at end of file
+
+	subject := "user_123"
+	accessToken, err := tokenizer.Tokenize(jwt.Claims{Subject: &subject})
+	assert.NoError(t, err)
+
+	claims, err := tokenizer.GetClaims(accessToken)
+	assert.NoError(t, err)
+
+	t.Run("ends the session of the refresh token cookie", func(t *testing.T) {
+		ctx := newLogoutContext("user_123", accessToken, "refresh_token_123")
+		sessionService := &recordingSessionService{}
+		tokenDenylist := denylist.NewMemoryDenylist()
+		producer := &recordingProducer{}
+
+		result, err := Logout(ctx, &config.Config{}, sessionService, NewMockRefreshTokenService(ctrl), tokenizer, tokenDenylist, producer.produce, nil, nil)
+		assert.NoError(t, err)
+		assert.True(t, result)
+		assert.Empty(t, graphql.GetErrors(ctx))
+
+		assert.Equal(t, []string{"user_123/session_123"}, sessionService.ended)
+		assert.Empty(t, sessionService.revoked)
+
+		denied, err := tokenDenylist.IsDenied(ctx, denylist.FromClaims(claims))
+		assert.NoError(t, err)
+		assert.True(t, denied, "the access token used to log out is revoked")
+
+		assert.Len(t, producer.messages, 1)
+		var event UserLoggedOutEvent
+		assert.NoError(t, json.Unmarshal(producer.messages[0].Value, &event))
+		assert.Equal(t, UserLoggedOutEventName, event.Event)
+		assert.Equal(t, "user_123", event.UserID)
+		assert.Equal(t, "session_123", event.SessionID)
+		assert.False(t, event.AllDevices)
+	})
+
+	t.Run("prefers the refresh token argument over the cookie", func(t *testing.T) {
+		ctx := newLogoutContext("", "", "refresh_token_cookie")
+		refreshTokenService := &recordingRefreshTokenService{MockRefreshTokenService: NewMockRefreshTokenService(ctrl)}
+		refreshToken := "refresh_token_argument"
+
+		result, err := Logout(ctx, &config.Config{}, &recordingSessionService{}, refreshTokenService, tokenizer, denylist.NewMemoryDenylist(), (&recordingProducer{}).produce, &refreshToken, nil)
+		assert.NoError(t, err)
+		assert.True(t, result)
+		assert.Equal(t, []string{"refresh_token_argument"}, refreshTokenService.looked)
+	})
+
+	t.Run("signs out every device", func(t *testing.T) {
+		ctx := newLogoutContext("user_123", accessToken, "")
+		sessionService := &recordingSessionService{}
+		tokenDenylist := denylist.NewMemoryDenylist()
+		producer := &recordingProducer{}
+		allDevices := true
+
+		result, err := Logout(ctx, &config.Config{}, sessionService, NewMockRefreshTokenService(ctrl), tokenizer, tokenDenylist, producer.produce, nil, &allDevices)
+		assert.NoError(t, err)
+		assert.True(t, result)
+
+		assert.Equal(t, []revokedSessions{{userID: "user_123", keepSessionID: ""}}, sessionService.revoked)
+
+		denied, err := tokenDenylist.IsDenied(ctx, denylist.Token{Subject: "user_123", IssuedAt: time.Now().Add(-time.Minute)})
+		assert.NoError(t, err)
+		assert.True(t, denied, "access tokens of every device are revoked")
+
+		var event UserLoggedOutEvent
+		assert.NoError(t, json.Unmarshal(producer.messages[0].Value, &event))
+		assert.True(t, event.AllDevices)
+	})
+
+	t.Run("signs out every device only for a signed in user", func(t *testing.T) {
+		ctx := newLogoutContext("", "", "refresh_token_123")
+		sessionService := &recordingSessionService{}
+		producer := &recordingProducer{}
+		allDevices := true
+
+		result, err := Logout(ctx, &config.Config{}, sessionService, NewMockRefreshTokenService(ctrl), tokenizer, denylist.NewMemoryDenylist(), producer.produce, nil, &allDevices)
+		assert.NoError(t, err)
+		assert.False(t, result)
+		assert.Equal(t, AccessDeniedCode, graphql.GetErrors(ctx)[0].Extensions["code"])
+		assert.Empty(t, sessionService.revoked)
+		assert.Empty(t, sessionService.ended)
+		assert.Empty(t, producer.messages)
+	})
+
+	t.Run("only clears the cookies of an anonymous request", func(t *testing.T) {
+		ctx := newLogoutContext("", "", "")
+		sessionService := &recordingSessionService{}
+		producer := &recordingProducer{}
+
+		result, err := Logout(ctx, &config.Config{}, sessionService, NewMockRefreshTokenService(ctrl), tokenizer, denylist.NewMemoryDenylist(), producer.produce, nil, nil)
+		assert.NoError(t, err)
+		assert.True(t, result)
+		assert.Empty(t, sessionService.revoked)
+		assert.Empty(t, sessionService.ended)
+		assert.Empty(t, producer.messages)
+	})
+}
+
+type recordingRefreshTokenService struct {
+	*MockRefreshTokenService
+	looked []string
+}
+
+func (m *recordingRefreshTokenService) GetToken(token string) (*RefreshTokenModels.RefreshToken, error) {
+	m.looked = append(m.looked, token)
+
+	return m.MockRefreshTokenService.GetToken(token)
+}
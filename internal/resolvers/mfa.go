@@ -0,0 +1,346 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/graph/model"
+	"github.com/weeb-vip/auth/http/handlers/requestinfo"
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/entities"
+	"github.com/weeb-vip/auth/internal/jwt"
+	"github.com/weeb-vip/auth/internal/logger"
+	"github.com/weeb-vip/auth/internal/metrics"
+	"github.com/weeb-vip/auth/internal/services/credential"
+	CredentialModels "github.com/weeb-vip/auth/internal/services/credential/models"
+	"github.com/weeb-vip/auth/internal/services/lockout"
+	"github.com/weeb-vip/auth/internal/services/mfa"
+	"github.com/weeb-vip/auth/internal/services/refresh_token"
+	"github.com/weeb-vip/auth/internal/services/session"
+)
+
+const (
+	MFAChallengePurpose         = "MFA_CHALLENGE"
+	MFARequiredCode             = "MFA_REQUIRED"
+	InvalidMFAChallengeCode     = "INVALID_MFA_CHALLENGE"
+	defaultMFAChallengeTTLInSec = 300
+)
+
+func EnrollMFA(
+	ctx context.Context,
+	credentialService credential.Credential,
+	mfaService mfa.MFA,
+) (*model.MFAEnrollment, error) {
+	userID, err := authenticatedUserID(ctx)
+	if err != nil {
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	credentials, err := credentialService.GetCredentialsByUserID(ctx, userID)
+	if err != nil {
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	enrollment, err := mfaService.Enroll(ctx, userID, credentials.Username)
+	if err != nil {
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	return &model.MFAEnrollment{
+		Secret: enrollment.Secret,
+		URI:    enrollment.URI,
+	}, nil
+}
+
+// ConfirmMFAEnrollment enables mfa and returns the recovery codes, they are only ever shown here.
+func ConfirmMFAEnrollment(ctx context.Context, mfaService mfa.MFA, code string) ([]string, error) {
+	userID, err := authenticatedUserID(ctx)
+	if err != nil {
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	recoveryCodes, err := mfaService.ConfirmEnrollment(ctx, userID, code)
+	if err != nil {
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+func DisableMFA(
+	ctx context.Context,
+	mfaService mfa.MFA,
+	lockoutService lockout.Lockout,
+	userProducer func(ctx context.Context, message *kafka.Message) error,
+	code string,
+) (bool, error) {
+	userID, err := authenticatedUserID(ctx)
+	if err != nil {
+		_, err := handleError(ctx, "false", err)
+		return false, err
+	}
+
+	err = verifySecondFactor(ctx, mfaService, lockoutService, userProducer, userID, code)
+	if err != nil {
+		_, err := handleError(ctx, "false", err)
+		return false, err
+	}
+
+	err = mfaService.Disable(ctx, userID)
+	if err != nil {
+		_, err := handleError(ctx, "false", err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RegenerateRecoveryCodes replaces every recovery code of the user, a valid code is required so a stolen
+// access token alone can't be used to mint new codes.
+func RegenerateRecoveryCodes(
+	ctx context.Context,
+	mfaService mfa.MFA,
+	lockoutService lockout.Lockout,
+	userProducer func(ctx context.Context, message *kafka.Message) error,
+	code string,
+) ([]string, error) {
+	userID, err := authenticatedUserID(ctx)
+	if err != nil {
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	err = verifySecondFactor(ctx, mfaService, lockoutService, userProducer, userID, code)
+	if err != nil {
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	recoveryCodes, err := mfaService.RegenerateRecoveryCodes(ctx, userID)
+	if err != nil {
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+func RecoveryCodesRemaining(ctx context.Context, mfaService mfa.MFA) (int, error) {
+	userID, err := authenticatedUserID(ctx)
+	if err != nil {
+		_, err := handleError(ctx, "0", err)
+		return 0, err
+	}
+
+	count, err := mfaService.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		_, err := handleError(ctx, "0", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// CompleteMFAChallenge exchanges a challenge token issued by CreateSession and a valid code for a session.
+// The challenge is denied once it is exchanged or wrong codes lock the second factor, so the password has to be
+// entered again.
+func CompleteMFAChallenge( // nolint
+	ctx context.Context,
+	credentialService credential.Credential,
+	mfaService mfa.MFA,
+	lockoutService lockout.Lockout,
+	sessionService session.Session,
+	refreshTokenService refresh_token.RefreshToken,
+	jwtTokenizer jwt.Tokenizer,
+	tokenDenylist denylist.Denylist,
+	userProducer func(ctx context.Context, message *kafka.Message) error,
+	config *config.Config,
+	input model.CompleteMFAChallengeInput,
+) (*model.SigninResult, error) {
+	log := logger.FromCtx(ctx)
+
+	credentials, challenge, err := getMFAChallengeCredentials(ctx, credentialService, jwtTokenizer, tokenDenylist, input.Token)
+	if err != nil {
+		metrics.GetAppMetrics().AuthRequestMetric("complete_mfa_challenge", metrics.Error)
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	err = verifySecondFactor(ctx, mfaService, lockoutService, userProducer, credentials.UserID, input.Code)
+	if err != nil {
+		metrics.GetAppMetrics().AuthRequestMetric("complete_mfa_challenge", metrics.Error)
+		log.Warn().
+			Str("user_id", credentials.UserID).
+			Msg("MFA challenge failed")
+
+		var lockoutErr *lockout.Error
+		if errors.As(err, &lockoutErr) && lockoutErr.Code == lockout.LockoutErrorLocked {
+			denyMFAChallenge(ctx, tokenDenylist, challenge)
+		}
+
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	createdSession, err := sessionService.CreateSession(ctx, credentials.UserID, requestinfo.RemoteIP(ctx), requestinfo.UserAgent(ctx))
+	if err != nil {
+		metrics.GetAppMetrics().SessionOperationMetric("create", metrics.Error)
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	result, err := issueSession(ctx, createdSession, sessionService, refreshTokenService, jwtTokenizer, tokenDenylist, config)
+	if err != nil {
+		return nil, err
+	}
+
+	denyMFAChallenge(ctx, tokenDenylist, challenge)
+
+	metrics.GetAppMetrics().AuthRequestMetric("complete_mfa_challenge", metrics.Success)
+	metrics.GetAppMetrics().SessionOperationMetric("create", metrics.Success)
+
+	return result, nil
+}
+
+// verifySecondFactor checks a TOTP or recovery code and publishes an event when a recovery code was consumed.
+// Wrong codes are counted per user, once they lock the second factor no code is checked until the lock ends.
+func verifySecondFactor(
+	ctx context.Context,
+	mfaService mfa.MFA,
+	lockoutService lockout.Lockout,
+	userProducer func(ctx context.Context, message *kafka.Message) error,
+	userID string,
+	code string,
+) error {
+	err := lockoutService.CheckMFA(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	method, err := mfaService.VerifyCode(ctx, userID, code)
+
+	var mfaErr *mfa.Error
+	if errors.As(err, &mfaErr) && mfaErr.Code == mfa.MFAErrorInvalidCode {
+		lockoutErr := lockoutService.RecordMFAFailure(ctx, userID)
+		if lockoutErr != nil {
+			return lockoutErr
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	log := logger.FromCtx(ctx)
+
+	err = lockoutService.RecordMFASuccess(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to reset mfa failures")
+	}
+
+	if method != mfa.VerificationMethodRecoveryCode {
+		return nil
+	}
+
+	remaining, err := mfaService.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to count remaining recovery codes")
+	}
+
+	// The code is already consumed at this point, failing to publish must not fail the request.
+	err = publishEvent(ctx, userProducer, userID, RecoveryCodeUsedEvent{
+		Event:          RecoveryCodeUsedEventName,
+		UserID:         userID,
+		RemainingCodes: remaining,
+		Timestamp:      time.Now(),
+	})
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to produce recovery code used event")
+	}
+
+	return nil
+}
+
+// createMFAChallenge issues a short-lived token proving the password step succeeded for the credential.
+// The subject is the credential ID rather than the user ID so the token can't be used as an access token.
+func createMFAChallenge(jwtTokenizer jwt.Tokenizer, config *config.Config, credentialID string) (string, error) {
+	ttlInSeconds := config.MFAConfig.ChallengeTTLInSeconds
+	if ttlInSeconds <= 0 {
+		ttlInSeconds = defaultMFAChallengeTTLInSec
+	}
+
+	ttl := time.Duration(ttlInSeconds) * time.Second
+	purpose := MFAChallengePurpose
+
+	return jwtTokenizer.Tokenize(jwt.Claims{
+		Subject: &credentialID,
+		TTL:     &ttl,
+		Purpose: &purpose,
+	})
+}
+
+// denyMFAChallenge revokes the challenge token until it expires. Failing only logs, the challenge is short-lived
+// and still needs a valid code that is not locked.
+func denyMFAChallenge(ctx context.Context, tokenDenylist denylist.Denylist, challenge *jwt.Claims) {
+	if challenge.ID == nil || challenge.ExpiresAt == nil {
+		return
+	}
+
+	log := logger.FromCtx(ctx)
+
+	err := tokenDenylist.Deny(ctx, *challenge.ID, *challenge.ExpiresAt)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to deny mfa challenge")
+	}
+}
+
+func getMFAChallengeCredentials(
+	ctx context.Context,
+	credentialService credential.Credential,
+	jwtTokenizer jwt.Tokenizer,
+	tokenDenylist denylist.Denylist,
+	token string,
+) (*CredentialModels.Credential, *jwt.Claims, error) {
+	invalidChallenge := &entities.ServiceError{
+		Code:    InvalidMFAChallengeCode,
+		Message: "invalid or expired mfa challenge",
+	}
+
+	claims, err := jwtTokenizer.GetClaims(token)
+	if err != nil {
+		return nil, nil, invalidChallenge
+	}
+
+	if claims.Purpose == nil || *claims.Purpose != MFAChallengePurpose || claims.Subject == nil {
+		return nil, nil, invalidChallenge
+	}
+
+	denied, err := tokenDenylist.IsDenied(ctx, denylist.FromClaims(claims))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if denied {
+		return nil, nil, invalidChallenge
+	}
+
+	credentials, err := credentialService.GetCredentialsByIdentifier(ctx, *claims.Subject)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if credentials == nil || credentials.UserID == "" || !credentials.Active {
+		return nil, nil, invalidChallenge
+	}
+
+	return credentials, claims, nil
+}
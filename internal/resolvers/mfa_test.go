@@ -0,0 +1,229 @@
+package resolvers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/graph/model"
+	"github.com/weeb-vip/auth/http/handlers/responsecontext"
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/jwt"
+	"github.com/weeb-vip/auth/internal/keypair"
+	CredentialModels "github.com/weeb-vip/auth/internal/services/credential/models"
+)
+
+type activeCredentialService struct {
+	MockCredentialService
+}
+
+func (m *activeCredentialService) SignIn(ctx context.Context, username, password string) (*CredentialModels.Credential, error) {
+	return m.GetCredentialsByIdentifier(ctx, "credential_123")
+}
+
+func (m *activeCredentialService) GetCredentialsByIdentifier(ctx context.Context, identifier string) (*CredentialModels.Credential, error) {
+	credentials := &CredentialModels.Credential{UserID: "user_123", Username: "testuser", Active: true}
+	credentials.ID = identifier
+
+	return credentials, nil
+}
+
+func (m *activeCredentialService) GetCredentialsByUserID(ctx context.Context, userID string) (*CredentialModels.Credential, error) {
+	return &CredentialModels.Credential{UserID: userID, Username: "testuser", Active: true}, nil
+}
+
+func newTestTokenizer(t *testing.T) jwt.Tokenizer {
+	signingKey, err := keypair.NewSigningKeyRotator(func(publicKey string) (string, error) {
+		return "key_id", nil
+	})
+	assert.NoError(t, err)
+
+	return jwt.New(signingKey)
+}
+
+func newGraphQLContext() (context.Context, *httptest.ResponseRecorder) {
+	recorder := httptest.NewRecorder()
+	ctx := responsecontext.WithResponseWriter(context.Background(), recorder)
+	ctx = graphql.WithResponseContext(ctx, graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+
+	return ctx, recorder
+}
+
+type recordingProducer struct {
+	messages []*kafka.Message
+}
+
+func (p *recordingProducer) produce(ctx context.Context, message *kafka.Message) error {
+	p.messages = append(p.messages, message)
+
+	return nil
+}
+
+func TestCreateSessionWithMFA(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokenizer := newTestTokenizer(t)
+	credentialService := &activeCredentialService{}
+	mfaService := &MockMFAService{ctrl: ctrl, enabled: true}
+	testConfig := &config.Config{APPConfig: config.AppConfig{CookieDomain: ".weeb.vip"}}
+	input := &model.LoginInput{Username: "testuser", Password: "testpass"}
+	producer := &recordingProducer{}
+
+	t.Run("returns an mfa challenge instead of tokens", func(t *testing.T) {
+		ctx, recorder := newGraphQLContext()
+
+		result, err := CreateSession(ctx, credentialService, mfaService, NewMockSessionService(ctrl), NewMockRefreshTokenService(ctrl), tokenizer, denylist.NewMemoryDenylist(), testConfig, input)
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+		assert.Empty(t, recorder.Result().Header.Values("Set-Cookie"))
+
+		errs := graphql.GetErrors(ctx)
+		assert.Len(t, errs, 1)
+		assert.Equal(t, MFARequiredCode, errs[0].Extensions["code"])
+
+		claims, err := tokenizer.GetClaims(errs[0].Extensions["challenge"].(string))
+		assert.NoError(t, err)
+		assert.Equal(t, MFAChallengePurpose, *claims.Purpose)
+		assert.Equal(t, "credential_123", *claims.Subject)
+	})
+
+	t.Run("exchanges the challenge and a valid code for tokens", func(t *testing.T) {
+		ctx, _ := newGraphQLContext()
+		_, _ = CreateSession(ctx, credentialService, mfaService, NewMockSessionService(ctrl), NewMockRefreshTokenService(ctrl), tokenizer, denylist.NewMemoryDenylist(), testConfig, input)
+		challenge := graphql.GetErrors(ctx)[0].Extensions["challenge"].(string)
+
+		ctx, recorder := newGraphQLContext()
+		result, err := CompleteMFAChallenge(ctx, credentialService, mfaService, &recordingLockoutService{}, NewMockSessionService(ctrl), NewMockRefreshTokenService(ctrl), tokenizer, denylist.NewMemoryDenylist(), producer.produce, testConfig, model.CompleteMFAChallengeInput{
+			Token: challenge,
+			Code:  "123456",
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, graphql.GetErrors(ctx))
+		assert.Equal(t, "user_123", result.ID)
+		assert.Equal(t, "refresh_token_123", *result.Credentials.RefreshToken)
+		assert.Len(t, recorder.Result().Header.Values("Set-Cookie"), 2)
+	})
+
+	t.Run("accepts a challenge only once", func(t *testing.T) {
+		challenge, _ := createMFAChallenge(tokenizer, testConfig, "credential_123")
+		tokenDenylist := denylist.NewMemoryDenylist()
+
+		complete := func() (*model.SigninResult, context.Context) {
+			ctx, _ := newGraphQLContext()
+			result, err := CompleteMFAChallenge(ctx, credentialService, mfaService, &recordingLockoutService{}, NewMockSessionService(ctrl), NewMockRefreshTokenService(ctrl), tokenizer, tokenDenylist, producer.produce, testConfig, model.CompleteMFAChallengeInput{
+				Token: challenge,
+				Code:  "123456",
+			})
+			assert.NoError(t, err)
+
+			return result, ctx
+		}
+
+		result, _ := complete()
+		assert.NotNil(t, result)
+
+		result, ctx := complete()
+		assert.Nil(t, result, "a replayed challenge issues no second session")
+		assert.Equal(t, InvalidMFAChallengeCode, graphql.GetErrors(ctx)[0].Extensions["code"])
+	})
+
+	t.Run("accepts a recovery code and publishes an event", func(t *testing.T) {
+		ctx, _ := newGraphQLContext()
+		challenge, _ := createMFAChallenge(tokenizer, testConfig, "credential_123")
+		producer := &recordingProducer{}
+
+		result, err := CompleteMFAChallenge(ctx, credentialService, mfaService, &recordingLockoutService{}, NewMockSessionService(ctrl), NewMockRefreshTokenService(ctrl), tokenizer, denylist.NewMemoryDenylist(), producer.produce, testConfig, model.CompleteMFAChallengeInput{
+			Token: challenge,
+			Code:  "abcde-fghjk",
+		})
+		assert.NoError(t, err)
+		assert.NotNil(t, result)
+		assert.Len(t, producer.messages, 1)
+		assert.Equal(t, "user_123", string(producer.messages[0].Key))
+
+		var event RecoveryCodeUsedEvent
+		assert.NoError(t, json.Unmarshal(producer.messages[0].Value, &event))
+		assert.Equal(t, RecoveryCodeUsedEventName, event.Event)
+		assert.Equal(t, 9, event.RemainingCodes)
+	})
+
+	t.Run("rejects an invalid code", func(t *testing.T) {
+		ctx, _ := newGraphQLContext()
+		challenge, _ := createMFAChallenge(tokenizer, testConfig, "credential_123")
+
+		result, err := CompleteMFAChallenge(ctx, credentialService, mfaService, &recordingLockoutService{}, NewMockSessionService(ctrl), NewMockRefreshTokenService(ctrl), tokenizer, denylist.NewMemoryDenylist(), producer.produce, testConfig, model.CompleteMFAChallengeInput{
+			Token: challenge,
+			Code:  "000000",
+		})
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+		assert.Equal(t, "INVALID_MFA_CODE", graphql.GetErrors(ctx)[0].Extensions["code"])
+	})
+
+	t.Run("denies the challenge once wrong codes lock the second factor", func(t *testing.T) {
+		challenge, _ := createMFAChallenge(tokenizer, testConfig, "credential_123")
+		lockoutService := &recordingLockoutService{maxMFAFailures: 3}
+		tokenDenylist := denylist.NewMemoryDenylist()
+
+		complete := func(code string) (*model.SigninResult, context.Context) {
+			ctx, _ := newGraphQLContext()
+			result, err := CompleteMFAChallenge(ctx, credentialService, mfaService, lockoutService, NewMockSessionService(ctrl), NewMockRefreshTokenService(ctrl), tokenizer, tokenDenylist, producer.produce, testConfig, model.CompleteMFAChallengeInput{
+				Token: challenge,
+				Code:  code,
+			})
+			assert.NoError(t, err)
+
+			return result, ctx
+		}
+
+		for i := 0; i < 2; i++ {
+			_, ctx := complete("000000")
+			assert.Equal(t, "INVALID_MFA_CODE", graphql.GetErrors(ctx)[0].Extensions["code"])
+		}
+
+		result, ctx := complete("000000")
+		assert.Nil(t, result)
+		assert.Equal(t, "ACCOUNT_LOCKED", graphql.GetErrors(ctx)[0].Extensions["code"])
+
+		lockoutService.mfaFailures = 0
+		result, ctx = complete("123456")
+		assert.Nil(t, result, "the challenge stays denied after the lock ends")
+		assert.Equal(t, InvalidMFAChallengeCode, graphql.GetErrors(ctx)[0].Extensions["code"])
+	})
+
+	t.Run("checks no code while the second factor is locked", func(t *testing.T) {
+		ctx, _ := newGraphQLContext()
+		challenge, _ := createMFAChallenge(tokenizer, testConfig, "credential_123")
+		lockoutService := &recordingLockoutService{maxMFAFailures: 1, mfaFailures: 1}
+
+		result, err := CompleteMFAChallenge(ctx, credentialService, mfaService, lockoutService, NewMockSessionService(ctrl), NewMockRefreshTokenService(ctrl), tokenizer, denylist.NewMemoryDenylist(), producer.produce, testConfig, model.CompleteMFAChallengeInput{
+			Token: challenge,
+			Code:  "123456",
+		})
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+		assert.Equal(t, "ACCOUNT_LOCKED", graphql.GetErrors(ctx)[0].Extensions["code"])
+	})
+
+	t.Run("rejects a token issued for another purpose", func(t *testing.T) {
+		ctx, _ := newGraphQLContext()
+		subject := "user_123"
+		accessToken, _ := tokenizer.Tokenize(jwt.Claims{Subject: &subject})
+
+		result, err := CompleteMFAChallenge(ctx, credentialService, mfaService, &recordingLockoutService{}, NewMockSessionService(ctrl), NewMockRefreshTokenService(ctrl), tokenizer, denylist.NewMemoryDenylist(), producer.produce, testConfig, model.CompleteMFAChallengeInput{
+			Token: accessToken,
+			Code:  "123456",
+		})
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+		assert.Equal(t, InvalidMFAChallengeCode, graphql.GetErrors(ctx)[0].Extensions["code"])
+	})
+}
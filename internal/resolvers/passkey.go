@@ -0,0 +1,146 @@
+package resolvers
+
+import (
+	"context"
+	"time"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/graph/model"
+	"github.com/weeb-vip/auth/http/handlers/requestinfo"
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/jwt"
+	"github.com/weeb-vip/auth/internal/logger"
+	"github.com/weeb-vip/auth/internal/metrics"
+	"github.com/weeb-vip/auth/internal/services/credential"
+	CredentialModels "github.com/weeb-vip/auth/internal/services/credential/models"
+	"github.com/weeb-vip/auth/internal/services/passkey"
+	"github.com/weeb-vip/auth/internal/services/refresh_token"
+	"github.com/weeb-vip/auth/internal/services/session"
+)
+
+func BeginPasskeyRegistration(
+	ctx context.Context,
+	credentialService credential.Credential,
+	passkeyService passkey.Passkey,
+) (*model.WebAuthnCeremony, error) {
+	userID, err := authenticatedUserID(ctx)
+	if err != nil {
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	credentials, err := credentialService.GetCredentialsByUserID(ctx, userID)
+	if err != nil {
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	ceremony, err := passkeyService.BeginRegistration(ctx, userID, credentials.Username)
+	if err != nil {
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	return &model.WebAuthnCeremony{
+		ID:      ceremony.ID,
+		Options: ceremony.Options,
+	}, nil
+}
+
+func FinishPasskeyRegistration(
+	ctx context.Context,
+	passkeyService passkey.Passkey,
+	input model.FinishPasskeyRegistrationInput,
+) (bool, error) {
+	userID, err := authenticatedUserID(ctx)
+	if err != nil {
+		_, err := handleError(ctx, "false", err)
+		return false, err
+	}
+
+	_, err = passkeyService.FinishRegistration(ctx, userID, input.CeremonyID, input.Name, input.Response)
+	if err != nil {
+		_, err := handleError(ctx, "false", err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func BeginPasskeyLogin(ctx context.Context, passkeyService passkey.Passkey) (*model.WebAuthnCeremony, error) {
+	ceremony, err := passkeyService.BeginLogin(ctx)
+	if err != nil {
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	return &model.WebAuthnCeremony{
+		ID:      ceremony.ID,
+		Options: ceremony.Options,
+	}, nil
+}
+
+// FinishPasskeyLogin verifies the assertion and issues a session exactly like a password login does.
+func FinishPasskeyLogin( // nolint
+	ctx context.Context,
+	credentialService credential.Credential,
+	passkeyService passkey.Passkey,
+	sessionService session.Session,
+	refreshTokenService refresh_token.RefreshToken,
+	jwtTokenizer jwt.Tokenizer,
+	tokenDenylist denylist.Denylist,
+	config *config.Config,
+	input model.FinishPasskeyLoginInput,
+) (*model.SigninResult, error) {
+	startTime := time.Now()
+	log := logger.FromCtx(ctx)
+
+	userID, err := passkeyService.FinishLogin(ctx, input.CeremonyID, input.Response)
+	if err != nil {
+		metrics.GetAppMetrics().AuthRequestMetric("finish_passkey_login", metrics.Error)
+		log.Warn().
+			Err(err).
+			Str("credential_type", string(CredentialModels.WebAuthnCredential)).
+			Msg("Passkey login failed")
+
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	credentials, err := credentialService.GetCredentialsByUserID(ctx, userID)
+	if err == nil && !credentials.Active {
+		err = &credential.Error{
+			Code:    credential.CredentialErrorInactiveCredentials,
+			Message: "credentials are not active",
+		}
+	}
+
+	if err != nil {
+		metrics.GetAppMetrics().AuthRequestMetric("finish_passkey_login", metrics.Error)
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	createdSession, err := sessionService.CreateSession(ctx, userID, requestinfo.RemoteIP(ctx), requestinfo.UserAgent(ctx))
+	if err != nil {
+		metrics.GetAppMetrics().SessionOperationMetric("create", metrics.Error)
+		_, err := handleError(ctx, "null", err)
+		return nil, err
+	}
+
+	result, err := issueSession(ctx, createdSession, sessionService, refreshTokenService, jwtTokenizer, tokenDenylist, config)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.GetAppMetrics().AuthRequestMetric("finish_passkey_login", metrics.Success)
+	metrics.GetAppMetrics().SessionOperationMetric("create", metrics.Success)
+
+	log.Info().
+		Str("user_id", userID).
+		Str("credential_type", string(CredentialModels.WebAuthnCredential)).
+		Dur("duration", time.Since(startTime)).
+		Msg("Passkey login completed successfully")
+
+	return result, nil
+}
@@ -0,0 +1,90 @@
+package resolvers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/graph/model"
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/services/passkey"
+	"github.com/weeb-vip/auth/internal/services/passkey/models"
+)
+
+type MockPasskeyService struct{}
+
+func (m *MockPasskeyService) BeginRegistration(ctx context.Context, userID string, username string) (*passkey.Ceremony, error) {
+	return &passkey.Ceremony{ID: "ceremony_123", Options: `{"publicKey":{}}`}, nil
+}
+
+func (m *MockPasskeyService) FinishRegistration(ctx context.Context, userID string, ceremonyID string, name string, response string) (*models.WebauthnCredential, error) {
+	return &models.WebauthnCredential{UserID: userID, Name: name}, nil
+}
+
+func (m *MockPasskeyService) BeginLogin(ctx context.Context) (*passkey.Ceremony, error) {
+	return &passkey.Ceremony{ID: "ceremony_123", Options: `{"publicKey":{}}`}, nil
+}
+
+func (m *MockPasskeyService) FinishLogin(ctx context.Context, ceremonyID string, response string) (string, error) {
+	if ceremonyID != "ceremony_123" {
+		return "", &passkey.Error{Code: passkey.PasskeyErrorInvalidCeremony, Message: "invalid or expired webauthn ceremony"}
+	}
+
+	return "user_123", nil
+}
+
+func TestFinishPasskeyLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokenizer := newTestTokenizer(t)
+	testConfig := &config.Config{APPConfig: config.AppConfig{CookieDomain: ".weeb.vip"}}
+
+	t.Run("issues the same session and tokens as a password login", func(t *testing.T) {
+		ctx, recorder := newGraphQLContext()
+
+		result, err := FinishPasskeyLogin(ctx, &activeCredentialService{}, &MockPasskeyService{}, NewMockSessionService(ctrl), NewMockRefreshTokenService(ctrl), tokenizer, denylist.NewMemoryDenylist(), testConfig, model.FinishPasskeyLoginInput{
+			CeremonyID: "ceremony_123",
+			Response:   "{}",
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, graphql.GetErrors(ctx))
+		assert.Equal(t, "user_123", result.ID)
+		assert.Equal(t, "refresh_token_123", *result.Credentials.RefreshToken)
+		assert.Len(t, recorder.Result().Header.Values("Set-Cookie"), 2)
+
+		claims, err := tokenizer.GetClaims(*result.Credentials.Token)
+		assert.NoError(t, err)
+		assert.Equal(t, "user_123", *claims.Subject)
+		assert.Nil(t, claims.Purpose)
+	})
+
+	t.Run("rejects a failed assertion", func(t *testing.T) {
+		ctx, recorder := newGraphQLContext()
+
+		result, err := FinishPasskeyLogin(ctx, &activeCredentialService{}, &MockPasskeyService{}, NewMockSessionService(ctrl), NewMockRefreshTokenService(ctrl), tokenizer, denylist.NewMemoryDenylist(), testConfig, model.FinishPasskeyLoginInput{
+			CeremonyID: "ceremony_456",
+			Response:   "{}",
+		})
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+		assert.Equal(t, "INVALID_WEBAUTHN_CEREMONY", graphql.GetErrors(ctx)[0].Extensions["code"])
+		assert.Empty(t, recorder.Result().Header.Values("Set-Cookie"))
+	})
+
+	t.Run("rejects inactive credentials", func(t *testing.T) {
+		ctx, _ := newGraphQLContext()
+
+		result, err := FinishPasskeyLogin(ctx, &MockCredentialService{}, &MockPasskeyService{}, NewMockSessionService(ctrl), NewMockRefreshTokenService(ctrl), tokenizer, denylist.NewMemoryDenylist(), testConfig, model.FinishPasskeyLoginInput{
+			CeremonyID: "ceremony_123",
+			Response:   "{}",
+		})
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+		assert.Equal(t, "INACTIVE_CREDENTIALS", graphql.GetErrors(ctx)[0].Extensions["code"])
+	})
+}
@@ -0,0 +1,70 @@
+package resolvers
+
+import (
+	"context"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/logger"
+	CredentialModels "github.com/weeb-vip/auth/internal/services/credential/models"
+	"github.com/weeb-vip/auth/internal/services/mail"
+	"github.com/weeb-vip/auth/internal/services/session"
+)
+
+const passwordChangedTimeLayout = "2 Jan 2006 15:04 MST"
+
+// afterPasswordChange signs the user out of every session but keepSessionID, then notifies the user and other
+// services. Only the revocation fails the request, the event and the email are best effort.
+func afterPasswordChange(
+	ctx context.Context,
+	sessionService session.Session,
+	tokenDenylist denylist.Denylist,
+	mailService mail.MailService,
+	userProducer func(ctx context.Context, message *kafka.Message) error,
+	credentials *CredentialModels.Credential,
+	reason string,
+	keepSessionID string,
+) error {
+	log := logger.FromCtx(ctx)
+	changedAt := time.Now()
+
+	err := sessionService.RevokeUserSessions(ctx, credentials.UserID, keepSessionID)
+	if err != nil {
+		return err
+	}
+
+	// The access tokens of the revoked sessions would otherwise stay valid until they expire. That includes
+	// the token of the kept session, whose client gets a new one with its refresh token.
+	err = denyUserAccessTokens(ctx, tokenDenylist, credentials.UserID, changedAt)
+	if err != nil {
+		return err
+	}
+
+	err = publishEvent(ctx, userProducer, credentials.UserID, PasswordChangedEvent{
+		Event:     PasswordChangedEventName,
+		UserID:    credentials.UserID,
+		Reason:    reason,
+		Timestamp: changedAt,
+	})
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("user_id", credentials.UserID).
+			Msg("Failed to publish password changed event")
+	}
+
+	err = mailService.SendMail(ctx, []string{credentials.Username}, "Your password was changed", "password-changed.mjml", map[string]string{
+		"name":       credentials.Username,
+		"changed_at": changedAt.UTC().Format(passwordChangedTimeLayout),
+	})
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("user_id", credentials.UserID).
+			Msg("Failed to send password changed email")
+	}
+
+	return nil
+}
@@ -2,45 +2,84 @@ package resolvers
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+
 	"github.com/weeb-vip/auth/config"
 	"github.com/weeb-vip/auth/graph/model"
 	"github.com/weeb-vip/auth/http/handlers/responsecontext"
 	"github.com/weeb-vip/auth/internal/jwt"
+	"github.com/weeb-vip/auth/internal/logger"
 	"github.com/weeb-vip/auth/internal/services/refresh_token"
+	RefreshTokenModels "github.com/weeb-vip/auth/internal/services/refresh_token/models"
 	"github.com/weeb-vip/auth/internal/services/session"
 )
 
-func RefreshToken(ctx context.Context, sessionService session.Session, refreshTokenService refresh_token.RefreshToken, jwtTokenizer jwt.Tokenizer, config *config.Config, token string) (*model.SigninResult, error) {
-
-	refreshToken, err := refreshTokenService.GetToken(token)
+// RefreshToken exchanges a refresh token for a new access token within the same session, the presented
+// refresh token is consumed and replaced by its successor so every refresh token can only be used once.
+// A session that was idle for too long or reached its lifetime is ended instead.
+func RefreshToken( // nolint
+	ctx context.Context,
+	sessionService session.Session,
+	refreshTokenService refresh_token.RefreshToken,
+	jwtTokenizer jwt.Tokenizer,
+	userProducer func(ctx context.Context, message *kafka.Message) error,
+	config *config.Config,
+	token string,
+) (*model.SigninResult, error) {
+	current, err := refreshTokenService.GetToken(token)
 	if err != nil {
 		return nil, err
 	}
-	if refreshToken == nil {
-		return nil, fmt.Errorf("refresh token not found")
+	if current == nil {
+		_, err := handleError(ctx, "null", &refresh_token.Error{
+			Code:    refresh_token.RefreshTokenErrorInvalid,
+			Message: "refresh token not found",
+		})
+		return nil, err
 	}
 
-	session, err := sessionService.CreateSession(ctx, refreshToken.UserID)
+	err = sessionService.CheckSession(ctx, current.UserID, current.FamilyID)
 	if err != nil {
+		var sessionErr *session.Error
+		if errors.As(err, &sessionErr) && sessionErr.Code != session.SessionErrorInternalError && sessionErr.Code != session.SessionErrorRevoked {
+			logSessionEvicted(ctx, current.UserID, current.FamilyID, sessionErr.Code)
+		}
+
+		_, err := handleError(ctx, "null", err)
 		return nil, err
 	}
 
-	subject := session.UserID
-
-	refreshToken, err = refreshTokenService.CreateToken(subject)
+	refreshToken, err := refreshTokenService.RotateToken(current)
 	if err != nil {
+		var refreshTokenErr *refresh_token.Error
+		if errors.As(err, &refreshTokenErr) && refreshTokenErr.Code == refresh_token.RefreshTokenErrorReused {
+			publishRefreshTokenReuse(ctx, userProducer, current)
+		}
+
+		_, err := handleError(ctx, "null", err)
 		return nil, err
 	}
 
+	touchSession(ctx, sessionService, refreshToken)
+
+	subject := refreshToken.UserID
+
+	// Refresh tokens issued before sessions were tracked have no session to name.
+	var sessionID *string
+	if refreshToken.FamilyID != "" {
+		sessionID = &refreshToken.FamilyID
+	}
+
 	token, err = jwtTokenizer.Tokenize(jwt.Claims{
 		Subject:      &subject,
 		TTL:          nil,
 		Purpose:      nil,
 		RefreshToken: &refreshToken.Token,
+		SessionID:    sessionID,
 	})
 
 	if err != nil {
@@ -76,10 +115,31 @@ func RefreshToken(ctx context.Context, sessionService session.Session, refreshTo
 	http.SetCookie(responseWriter, refreshTokenCookie)
 
 	return &model.SigninResult{
-		ID: session.ID,
+		ID: refreshToken.FamilyID,
 		Credentials: &model.Credentials{
 			Token:        &token,
 			RefreshToken: &refreshToken.Token,
 		},
 	}, nil
 }
+
+// publishRefreshTokenReuse reports a replayed refresh token, a failure to publish does not change the outcome.
+func publishRefreshTokenReuse(
+	ctx context.Context,
+	userProducer func(ctx context.Context, message *kafka.Message) error,
+	refreshToken *RefreshTokenModels.RefreshToken,
+) {
+	err := publishEvent(ctx, userProducer, refreshToken.UserID, RefreshTokenReuseDetectedEvent{
+		Event:     RefreshTokenReuseDetectedEventName,
+		UserID:    refreshToken.UserID,
+		FamilyID:  refreshToken.FamilyID,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		log := logger.FromCtx(ctx)
+		log.Error().
+			Err(err).
+			Str("user_id", refreshToken.UserID).
+			Msg("Failed to publish refresh token reuse event")
+	}
+}
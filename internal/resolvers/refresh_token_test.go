@@ -2,13 +2,19 @@ package resolvers
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+
 	"github.com/weeb-vip/auth/config"
 	"github.com/weeb-vip/auth/http/handlers/responsecontext"
+	"github.com/weeb-vip/auth/internal/services/refresh_token"
+	RefreshTokenModels "github.com/weeb-vip/auth/internal/services/refresh_token/models"
 
 	"go.uber.org/mock/gomock"
 )
@@ -17,9 +23,10 @@ func TestRefreshToken(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockSessionService := NewMockSessionService(ctrl)
+	sessionService := &recordingSessionService{}
 	mockRefreshTokenService := NewMockRefreshTokenService(ctrl)
 	mockJWTTokenizer := NewMockJWTTokenizer(ctrl)
+	producer := &recordingProducer{}
 
 	testConfig := &config.Config{
 		APPConfig: config.AppConfig{
@@ -37,9 +44,10 @@ func TestRefreshToken(t *testing.T) {
 		// Execute the resolver
 		result, err := RefreshToken(
 			ctx,
-			mockSessionService,
+			sessionService,
 			mockRefreshTokenService,
 			mockJWTTokenizer,
+			producer.produce,
 			testConfig,
 			inputToken,
 		)
@@ -164,9 +172,10 @@ func TestRefreshToken(t *testing.T) {
 
 		_, err := RefreshToken(
 			ctx,
-			mockSessionService,
+			sessionService,
 			mockRefreshTokenService,
 			mockJWTTokenizer,
+			producer.produce,
 			customConfig,
 			inputToken,
 		)
@@ -197,9 +206,10 @@ func TestRefreshToken(t *testing.T) {
 		// The actual implementation would need to handle this case
 		_, err := RefreshToken(
 			ctx,
-			mockSessionService,
+			sessionService,
 			mockRefreshServiceNoToken,
 			mockJWTTokenizer,
+			producer.produce,
 			testConfig,
 			inputToken,
 		)
@@ -230,9 +240,10 @@ func TestRefreshToken(t *testing.T) {
 
 		result, err := RefreshToken(
 			ctx,
-			mockSessionService,
+			sessionService,
 			mockRefreshTokenService,
 			mockErrorTokenizer,
+			producer.produce,
 			testConfig,
 			inputToken,
 		)
@@ -261,9 +272,10 @@ func TestRefreshToken(t *testing.T) {
 
 		_, err := RefreshToken(
 			ctx,
-			mockSessionService,
+			sessionService,
 			mockRefreshTokenService,
 			mockJWTTokenizer,
+			producer.produce,
 			testConfig,
 			inputToken,
 		)
@@ -303,4 +315,45 @@ func TestRefreshToken(t *testing.T) {
 			}
 		}
 	})
-}
\ No newline at end of file
+}
+
+type reusedRefreshTokenService struct {
+	MockRefreshTokenService
+}
+
+func (m *reusedRefreshTokenService) RotateToken(current *RefreshTokenModels.RefreshToken) (*RefreshTokenModels.RefreshToken, error) {
+	return nil, &refresh_token.Error{
+		Code:    refresh_token.RefreshTokenErrorReused,
+		Message: "refresh token was already used, the session has been revoked",
+	}
+}
+
+func TestRefreshTokenReuse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testConfig := &config.Config{
+		APPConfig: config.AppConfig{
+			CookieDomain: ".weeb.vip",
+		},
+	}
+
+	t.Run("rejects a replayed token and publishes a reuse event", func(t *testing.T) {
+		ctx, recorder := newGraphQLContext()
+		producer := &recordingProducer{}
+
+		result, err := RefreshToken(ctx, &recordingSessionService{}, &reusedRefreshTokenService{}, NewMockJWTTokenizer(ctrl), producer.produce, testConfig, "replayed_refresh_token")
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+		assert.Empty(t, recorder.Result().Cookies())
+		assert.Equal(t, "REFRESH_TOKEN_REUSED", graphql.GetErrors(ctx)[0].Extensions["code"])
+
+		assert.Len(t, producer.messages, 1)
+		assert.Equal(t, "user_123", string(producer.messages[0].Key))
+
+		var event RefreshTokenReuseDetectedEvent
+		assert.NoError(t, json.Unmarshal(producer.messages[0].Value, &event))
+		assert.Equal(t, RefreshTokenReuseDetectedEventName, event.Event)
+		assert.Equal(t, "session_123", event.FamilyID)
+	})
+}
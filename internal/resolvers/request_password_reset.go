@@ -2,10 +2,12 @@ package resolvers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 
 	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/logger"
 	"github.com/weeb-vip/auth/internal/services/credential"
 	"github.com/weeb-vip/auth/internal/services/mail"
 	"github.com/weeb-vip/auth/internal/services/passwordreset"
@@ -18,15 +20,32 @@ func RequestPasswordReset(
 	mailService mail.MailService,
 	cfg *config.Config,
 	username string,
-	email string,
 ) (bool, error) {
 	foundCredential, err := authenticationService.GetCredentials(ctx, username)
 	if err != nil {
 		return false, err
 	}
 
+	// An unknown username reports success without a token, so it cannot be used to find out which usernames exist.
+	if foundCredential == nil || foundCredential.ID == "" {
+		return true, nil
+	}
+
 	reset, err := passwordResetService.PasswordResetRequest(ctx, foundCredential.ID)
+
+	// A throttled account reports success like an unknown username does, so it cannot tell the two apart.
+	var passwordResetErr *passwordreset.Error
+	if errors.As(err, &passwordResetErr) && passwordResetErr.Code == passwordreset.PasswordResetErrorTooManyRequests {
+		log := logger.FromCtx(ctx)
+		log.Warn().
+			Str("user_id", foundCredential.UserID).
+			Msg("Password reset request throttled")
+
+		return true, nil
+	}
+
 	if err != nil {
+		_, err := handleError(ctx, "false", err)
 		return false, err
 	}
 
@@ -37,12 +56,11 @@ func RequestPasswordReset(
 
 	query := resetURL.Query()
 	query.Set("token", reset.OTT)
-	query.Set("email", email)
 	resetURL.RawQuery = query.Encode()
 
-	err = mailService.SendMail(ctx, []string{email}, "Password Reset", "reset-password.mjml", map[string]string{
+	err = mailService.SendMail(ctx, []string{foundCredential.Username}, "Password Reset", "reset-password.mjml", map[string]string{
 		"token_url": resetURL.String(),
-		"name":      username,
+		"name":      foundCredential.Username,
 	})
 
 	if err != nil {
@@ -3,27 +3,66 @@ package resolvers
 import (
 	"context"
 
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+
+	"github.com/weeb-vip/auth/internal/denylist"
 	"github.com/weeb-vip/auth/internal/services/credential"
+	"github.com/weeb-vip/auth/internal/services/mail"
 	"github.com/weeb-vip/auth/internal/services/passwordreset"
+	"github.com/weeb-vip/auth/internal/services/session"
 )
 
-func ResetPassword(
+func ResetPassword( // nolint
 	ctx context.Context,
 	credentialService credential.Credential,
 	passwordResetService passwordreset.PasswordReset,
+	sessionService session.Session,
+	tokenDenylist denylist.Denylist,
+	mailService mail.MailService,
+	userProducer func(ctx context.Context, message *kafka.Message) error,
 	token string,
 	username string,
 	newPassword string,
 ) (bool, error) {
-	err := passwordResetService.ValidateAndConsumeToken(ctx, username, token)
+	foundCredential, err := credentialService.GetCredentials(ctx, username)
+	if err != nil {
+		return false, err
+	}
+
+	// No token belongs to an unknown username, the error is the same as for a wrong token.
+	if foundCredential == nil || foundCredential.ID == "" {
+		_, err := handleError(ctx, "false", &passwordreset.Error{
+			Code:    passwordreset.PasswordResetErrorInvalidToken,
+			Message: "invalid password reset token",
+		})
+		return false, err
+	}
+
+	// Checked before the token is consumed, so a rejected password can be retried with the same link.
+	err = credentialService.CheckPassword(ctx, username, newPassword)
 	if err != nil {
+		_, err := handleError(ctx, "false", err)
+		return false, err
+	}
+
+	err = passwordResetService.ValidateAndConsumeToken(ctx, foundCredential.ID, token)
+	if err != nil {
+		_, err := handleError(ctx, "false", err)
 		return false, err
 	}
 
 	err = credentialService.UpdatePassword(ctx, username, newPassword)
 	if err != nil {
+		_, err := handleError(ctx, "false", err)
+		return false, err
+	}
+
+	// Whoever reset the password may not be the one signed in, so no session is kept.
+	err = afterPasswordChange(ctx, sessionService, tokenDenylist, mailService, userProducer, foundCredential, PasswordChangeReasonReset, "")
+	if err != nil {
+		_, err := handleError(ctx, "false", err)
 		return false, err
 	}
 
 	return true, nil
-}
\ No newline at end of file
+}
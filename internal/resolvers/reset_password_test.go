@@ -0,0 +1,263 @@
+package resolvers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/db"
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/passwordpolicy"
+	CredentialModels "github.com/weeb-vip/auth/internal/services/credential/models"
+	"github.com/weeb-vip/auth/internal/services/passwordreset"
+	PasswordResetModels "github.com/weeb-vip/auth/internal/services/passwordreset/models"
+)
+
+type resettableCredentialService struct {
+	MockCredentialService
+	updatedPassword string
+}
+
+func (m *resettableCredentialService) GetCredentials(ctx context.Context, username string) (*CredentialModels.Credential, error) {
+	return &CredentialModels.Credential{
+		BaseModel: db.BaseModel{ID: "credential_123"},
+		UserID:    "user_123",
+		Username:  username,
+	}, nil
+}
+
+func (m *resettableCredentialService) UpdatePassword(ctx context.Context, username string, newPassword string) error {
+	m.updatedPassword = newPassword
+
+	return nil
+}
+
+// policyCheckingCredentialService applies the default password policy instead of accepting every password.
+type policyCheckingCredentialService struct {
+	resettableCredentialService
+}
+
+func (m *policyCheckingCredentialService) CheckPassword(ctx context.Context, username string, password string) error {
+	return passwordpolicy.New(config.PasswordPolicyConfig{
+		MinLength:        8,
+		MaxLength:        72,
+		DisallowUsername: true,
+		DisallowCommon:   true,
+		MinScore:         2,
+	}, nil).Check(password, username)
+}
+
+type MockPasswordResetService struct {
+	requested []string
+	consumed  int
+	throttled bool
+}
+
+func (m *MockPasswordResetService) PasswordResetRequest(ctx context.Context, credentialID string) (*PasswordResetModels.PasswordReset, error) {
+	m.requested = append(m.requested, credentialID)
+
+	if m.throttled {
+		return nil, &passwordreset.Error{Code: passwordreset.PasswordResetErrorTooManyRequests, Message: "too many password reset requests, try again later"}
+	}
+
+	return &PasswordResetModels.PasswordReset{CredentialID: credentialID, OTT: "valid_token"}, nil
+}
+
+func (m *MockPasswordResetService) ValidateAndConsumeToken(ctx context.Context, credentialID string, token string) error {
+	if credentialID != "credential_123" || token != "valid_token" {
+		return &passwordreset.Error{Code: passwordreset.PasswordResetErrorInvalidToken, Message: "invalid password reset token"}
+	}
+
+	m.consumed++
+
+	return nil
+}
+
+type revokedSessions struct {
+	userID        string
+	keepSessionID string
+}
+
+type touchedSession struct {
+	sessionID      string
+	refreshTokenID string
+	ipAddress      string
+	userAgent      string
+}
+
+type recordingSessionService struct {
+	MockSessionService
+	revoked []revokedSessions
+	ended   []string
+	touched []touchedSession
+}
+
+func (m *recordingSessionService) TouchSession(ctx context.Context, sessionID string, refreshTokenID string, ipAddress string, userAgent string) error {
+	m.touched = append(m.touched, touchedSession{sessionID: sessionID, refreshTokenID: refreshTokenID, ipAddress: ipAddress, userAgent: userAgent})
+
+	return nil
+}
+
+func (m *recordingSessionService) RevokeUserSessions(ctx context.Context, userID string, keepSessionID string) error {
+	m.revoked = append(m.revoked, revokedSessions{userID: userID, keepSessionID: keepSessionID})
+
+	return nil
+}
+
+func (m *recordingSessionService) RevokeSession(ctx context.Context, userID string, sessionID string) (bool, error) {
+	m.ended = append(m.ended, userID+"/"+sessionID)
+
+	return true, nil
+}
+
+type sentMail struct {
+	to       []string
+	template string
+	values   map[string]string
+}
+
+type recordingMailService struct {
+	sent []sentMail
+}
+
+func (m *recordingMailService) SendMail(ctx context.Context, to []string, subject string, template string, values map[string]string) error {
+	m.sent = append(m.sent, sentMail{to: to, template: template, values: values})
+
+	return nil
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	testConfig := &config.Config{APPConfig: config.AppConfig{PasswordResetBaseURL: "https://weeb.vip/reset-password"}}
+
+	t.Run("mails the link to the address of the account", func(t *testing.T) {
+		ctx, _ := newGraphQLContext()
+		passwordResetService := &MockPasswordResetService{}
+		mailService := &recordingMailService{}
+
+		ok, err := RequestPasswordReset(ctx, &resettableCredentialService{}, passwordResetService, mailService, testConfig, "user@weeb.vip")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		assert.Equal(t, []string{"credential_123"}, passwordResetService.requested)
+		assert.Len(t, mailService.sent, 1)
+		assert.Equal(t, []string{"user@weeb.vip"}, mailService.sent[0].to)
+		assert.Equal(t, "https://weeb.vip/reset-password?token=valid_token", mailService.sent[0].values["token_url"])
+	})
+
+	t.Run("reports success for an unknown username without issuing a token", func(t *testing.T) {
+		ctx, _ := newGraphQLContext()
+		passwordResetService := &MockPasswordResetService{}
+		mailService := &recordingMailService{}
+
+		ok, err := RequestPasswordReset(ctx, &MockCredentialService{}, passwordResetService, mailService, testConfig, "unknown@weeb.vip")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Empty(t, graphql.GetErrors(ctx))
+
+		assert.Empty(t, passwordResetService.requested)
+		assert.Empty(t, mailService.sent)
+	})
+
+	t.Run("reports success for a throttled account like for an unknown username", func(t *testing.T) {
+		ctx, _ := newGraphQLContext()
+		passwordResetService := &MockPasswordResetService{throttled: true}
+		mailService := &recordingMailService{}
+
+		ok, err := RequestPasswordReset(ctx, &resettableCredentialService{}, passwordResetService, mailService, testConfig, "user@weeb.vip")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Empty(t, graphql.GetErrors(ctx))
+
+		assert.Equal(t, []string{"credential_123"}, passwordResetService.requested)
+		assert.Empty(t, mailService.sent)
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("revokes every session and notifies the user", func(t *testing.T) {
+		ctx, _ := newGraphQLContext()
+		credentialService := &resettableCredentialService{}
+		sessionService := &recordingSessionService{}
+		mailService := &recordingMailService{}
+		producer := &recordingProducer{}
+
+		ok, err := ResetPassword(ctx, credentialService, &MockPasswordResetService{}, sessionService, denylist.NewMemoryDenylist(), mailService, producer.produce, "valid_token", "user@weeb.vip", "new-password")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Empty(t, graphql.GetErrors(ctx))
+		assert.Equal(t, "new-password", credentialService.updatedPassword)
+
+		assert.Equal(t, []revokedSessions{{userID: "user_123", keepSessionID: ""}}, sessionService.revoked)
+
+		assert.Len(t, producer.messages, 1)
+		var event PasswordChangedEvent
+		assert.NoError(t, json.Unmarshal(producer.messages[0].Value, &event))
+		assert.Equal(t, PasswordChangedEventName, event.Event)
+		assert.Equal(t, "user_123", event.UserID)
+		assert.Equal(t, PasswordChangeReasonReset, event.Reason)
+
+		assert.Len(t, mailService.sent, 1)
+		assert.Equal(t, []string{"user@weeb.vip"}, mailService.sent[0].to)
+		assert.Equal(t, "password-changed.mjml", mailService.sent[0].template)
+	})
+
+	t.Run("keeps the password and sessions when the token is invalid", func(t *testing.T) {
+		ctx, _ := newGraphQLContext()
+		credentialService := &resettableCredentialService{}
+		sessionService := &recordingSessionService{}
+		mailService := &recordingMailService{}
+		producer := &recordingProducer{}
+
+		ok, err := ResetPassword(ctx, credentialService, &MockPasswordResetService{}, sessionService, denylist.NewMemoryDenylist(), mailService, producer.produce, "stale_token", "user@weeb.vip", "new-password")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, "INVALID_PASSWORD_RESET_TOKEN", graphql.GetErrors(ctx)[0].Extensions["code"])
+
+		assert.Empty(t, credentialService.updatedPassword)
+		assert.Empty(t, sessionService.revoked)
+		assert.Empty(t, producer.messages)
+		assert.Empty(t, mailService.sent)
+	})
+	t.Run("rejects an unknown username", func(t *testing.T) {
+		ctx, _ := newGraphQLContext()
+		credentialService := &MockCredentialService{}
+		passwordResetService := &MockPasswordResetService{}
+		sessionService := &recordingSessionService{}
+
+		ok, err := ResetPassword(ctx, credentialService, passwordResetService, sessionService, denylist.NewMemoryDenylist(), &recordingMailService{}, (&recordingProducer{}).produce, "valid_token", "unknown@weeb.vip", "new-password")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, "INVALID_PASSWORD_RESET_TOKEN", graphql.GetErrors(ctx)[0].Extensions["code"])
+
+		assert.Zero(t, passwordResetService.consumed)
+		assert.Empty(t, sessionService.revoked)
+	})
+
+	t.Run("reports policy violations without consuming the token", func(t *testing.T) {
+		ctx, _ := newGraphQLContext()
+		credentialService := &policyCheckingCredentialService{}
+		passwordResetService := &MockPasswordResetService{}
+		sessionService := &recordingSessionService{}
+
+		ok, err := ResetPassword(ctx, credentialService, passwordResetService, sessionService, denylist.NewMemoryDenylist(), &recordingMailService{}, (&recordingProducer{}).produce, "valid_token", "user@weeb.vip", "Password123")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		errs := graphql.GetErrors(ctx)
+		assert.Len(t, errs, 1)
+		assert.Equal(t, "PASSWORD_POLICY_VIOLATION", errs[0].Extensions["code"])
+		assert.Equal(t, []string{"PASSWORD_TOO_COMMON", "PASSWORD_TOO_WEAK"}, errs[0].Extensions["violations"])
+
+		assert.Zero(t, passwordResetService.consumed)
+		assert.Empty(t, credentialService.updatedPassword)
+		assert.Empty(t, sessionService.revoked)
+	})
+}
@@ -0,0 +1,187 @@
+package resolvers
+
+import (
+	"context"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/jwt"
+	"github.com/weeb-vip/auth/internal/logger"
+	"github.com/weeb-vip/auth/internal/metrics"
+	"github.com/weeb-vip/auth/internal/services/refresh_token"
+	"github.com/weeb-vip/auth/internal/services/session"
+)
+
+// RevokeSession ends one session of the signed in user, deleting it with its refresh tokens. The access
+// tokens of that session stay valid until they expire, unless it is the session making the request, which
+// is signed out the same way as on logout.
+func RevokeSession( // nolint
+	ctx context.Context,
+	config *config.Config,
+	sessionService session.Session,
+	refreshTokenService refresh_token.RefreshToken,
+	jwtTokenizer jwt.Tokenizer,
+	tokenDenylist denylist.Denylist,
+	userProducer func(ctx context.Context, message *kafka.Message) error,
+	sessionID string,
+) (bool, error) {
+	log := logger.FromCtx(ctx)
+
+	userID, err := authenticatedUserID(ctx)
+	if err == nil {
+		err = revokeSession(ctx, config, sessionService, refreshTokenService, jwtTokenizer, tokenDenylist, userID, sessionID)
+	}
+
+	if err != nil {
+		metrics.GetAppMetrics().AuthRequestMetric("revoke_session", metrics.Error)
+		log.Warn().
+			Err(err).
+			Str("user_id", userID).
+			Str("session_id", sessionID).
+			Msg("Failed to revoke session")
+
+		_, err := handleError(ctx, "false", err)
+		return false, err
+	}
+
+	metrics.GetAppMetrics().AuthRequestMetric("revoke_session", metrics.Success)
+
+	log.Info().
+		Str("user_id", userID).
+		Str("session_id", sessionID).
+		Msg("Session revoked")
+
+	publishSessionsRevoked(ctx, userProducer, SessionsRevokedEvent{
+		Event:     SessionsRevokedEventName,
+		UserID:    userID,
+		SessionID: sessionID,
+		Timestamp: time.Now(),
+	})
+
+	return true, nil
+}
+
+func revokeSession(
+	ctx context.Context,
+	config *config.Config,
+	sessionService session.Session,
+	refreshTokenService refresh_token.RefreshToken,
+	jwtTokenizer jwt.Tokenizer,
+	tokenDenylist denylist.Denylist,
+	userID string,
+	sessionID string,
+) error {
+	current := currentSessionID(ctx, jwtTokenizer, refreshTokenService)
+
+	// Matching on the user as well means the session of someone else is reported as not found.
+	revoked, err := sessionService.RevokeSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if !revoked {
+		return &session.Error{
+			Code:    session.SessionErrorSessionNotFound,
+			Message: "session not found",
+		}
+	}
+
+	if sessionID != current {
+		return nil
+	}
+
+	clearTokenCookies(ctx, config)
+
+	return denyCurrentAccessToken(ctx, jwtTokenizer, tokenDenylist)
+}
+
+// RevokeOtherSessions ends every session of the signed in user except the one making the request. Like after
+// a password change, the access tokens issued so far are revoked too and the client of the kept session gets
+// a new one with its refresh token.
+func RevokeOtherSessions( // nolint
+	ctx context.Context,
+	sessionService session.Session,
+	refreshTokenService refresh_token.RefreshToken,
+	jwtTokenizer jwt.Tokenizer,
+	tokenDenylist denylist.Denylist,
+	userProducer func(ctx context.Context, message *kafka.Message) error,
+) (bool, error) {
+	log := logger.FromCtx(ctx)
+	revokedAt := time.Now()
+
+	userID, err := authenticatedUserID(ctx)
+	if err == nil {
+		err = revokeOtherSessions(ctx, sessionService, refreshTokenService, jwtTokenizer, tokenDenylist, userID, revokedAt)
+	}
+
+	if err != nil {
+		metrics.GetAppMetrics().AuthRequestMetric("revoke_other_sessions", metrics.Error)
+		log.Warn().
+			Err(err).
+			Str("user_id", userID).
+			Msg("Failed to revoke other sessions")
+
+		_, err := handleError(ctx, "false", err)
+		return false, err
+	}
+
+	metrics.GetAppMetrics().AuthRequestMetric("revoke_other_sessions", metrics.Success)
+
+	log.Info().
+		Str("user_id", userID).
+		Msg("Other sessions revoked")
+
+	publishSessionsRevoked(ctx, userProducer, SessionsRevokedEvent{
+		Event:         SessionsRevokedEventName,
+		UserID:        userID,
+		OtherSessions: true,
+		Timestamp:     revokedAt,
+	})
+
+	return true, nil
+}
+
+func revokeOtherSessions(
+	ctx context.Context,
+	sessionService session.Session,
+	refreshTokenService refresh_token.RefreshToken,
+	jwtTokenizer jwt.Tokenizer,
+	tokenDenylist denylist.Denylist,
+	userID string,
+	revokedAt time.Time,
+) error {
+	// Without the current session there is no telling which one to keep, and ending them all is a logout.
+	current := currentSessionID(ctx, jwtTokenizer, refreshTokenService)
+	if current == "" {
+		return &session.Error{
+			Code:    session.SessionErrorSessionNotFound,
+			Message: "current session not found",
+		}
+	}
+
+	err := sessionService.RevokeUserSessions(ctx, userID, current)
+	if err != nil {
+		return err
+	}
+
+	return denyUserAccessTokens(ctx, tokenDenylist, userID, revokedAt)
+}
+
+// publishSessionsRevoked reports the revoked sessions, a failure to publish does not change the outcome.
+func publishSessionsRevoked(
+	ctx context.Context,
+	userProducer func(ctx context.Context, message *kafka.Message) error,
+	event SessionsRevokedEvent,
+) {
+	err := publishEvent(ctx, userProducer, event.UserID, event)
+	if err != nil {
+		log := logger.FromCtx(ctx)
+		log.Error().
+			Err(err).
+			Str("user_id", event.UserID).
+			Msg("Failed to publish sessions revoked event")
+	}
+}
@@ -0,0 +1,170 @@
+package resolvers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/http/handlers/responsecontext"
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/jwt"
+)
+
+// ownedSessionService only revokes the sessions it knows to belong to the user, as the repository does.
+type ownedSessionService struct {
+	recordingSessionService
+	owners map[string]string
+}
+
+func (m *ownedSessionService) RevokeSession(ctx context.Context, userID string, sessionID string) (bool, error) {
+	if m.owners[sessionID] != userID {
+		return false, nil
+	}
+
+	return m.recordingSessionService.RevokeSession(ctx, userID, sessionID)
+}
+
+func TestRevokeSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokenizer := newTestTokenizer(t)
+
+	subject := "user_123"
+	refreshToken := "refresh_token_123"
+	accessToken, err := tokenizer.Tokenize(jwt.Claims{Subject: &subject, RefreshToken: &refreshToken})
+	assert.NoError(t, err)
+
+	claims, err := tokenizer.GetClaims(accessToken)
+	assert.NoError(t, err)
+
+	newSessionService := func() *ownedSessionService {
+		return &ownedSessionService{owners: map[string]string{
+			"session_123": "user_123",
+			"session_456": "user_123",
+			"session_789": "user_789",
+		}}
+	}
+
+	t.Run("ends another session of the user", func(t *testing.T) {
+		ctx := newLogoutContext("user_123", accessToken, "")
+		sessionService := newSessionService()
+		tokenDenylist := denylist.NewMemoryDenylist()
+		producer := &recordingProducer{}
+
+		result, err := RevokeSession(ctx, &config.Config{}, sessionService, NewMockRefreshTokenService(ctrl), tokenizer, tokenDenylist, producer.produce, "session_456")
+		assert.NoError(t, err)
+		assert.True(t, result)
+		assert.Empty(t, graphql.GetErrors(ctx))
+		assert.Equal(t, []string{"user_123/session_456"}, sessionService.ended)
+
+		denied, err := tokenDenylist.IsDenied(ctx, denylist.FromClaims(claims))
+		assert.NoError(t, err)
+		assert.False(t, denied, "the session making the request stays signed in")
+		assert.Empty(t, responsecontext.FromContext(ctx).Header().Values("Set-Cookie"))
+
+		assert.Len(t, producer.messages, 1)
+		var event SessionsRevokedEvent
+		assert.NoError(t, json.Unmarshal(producer.messages[0].Value, &event))
+		assert.Equal(t, SessionsRevokedEventName, event.Event)
+		assert.Equal(t, "user_123", event.UserID)
+		assert.Equal(t, "session_456", event.SessionID)
+		assert.False(t, event.OtherSessions)
+	})
+
+	t.Run("signs out when ending the current session", func(t *testing.T) {
+		ctx := newLogoutContext("user_123", accessToken, "")
+		sessionService := newSessionService()
+		tokenDenylist := denylist.NewMemoryDenylist()
+
+		result, err := RevokeSession(ctx, &config.Config{}, sessionService, NewMockRefreshTokenService(ctrl), tokenizer, tokenDenylist, (&recordingProducer{}).produce, "session_123")
+		assert.NoError(t, err)
+		assert.True(t, result)
+		assert.Equal(t, []string{"user_123/session_123"}, sessionService.ended)
+
+		denied, err := tokenDenylist.IsDenied(ctx, denylist.FromClaims(claims))
+		assert.NoError(t, err)
+		assert.True(t, denied)
+		assert.Len(t, responsecontext.FromContext(ctx).Header().Values("Set-Cookie"), 2)
+	})
+
+	t.Run("does not end the session of another user", func(t *testing.T) {
+		ctx := newLogoutContext("user_123", accessToken, "")
+		sessionService := newSessionService()
+		producer := &recordingProducer{}
+
+		result, err := RevokeSession(ctx, &config.Config{}, sessionService, NewMockRefreshTokenService(ctrl), tokenizer, denylist.NewMemoryDenylist(), producer.produce, "session_789")
+		assert.NoError(t, err)
+		assert.False(t, result)
+		assert.Empty(t, sessionService.ended)
+		assert.Empty(t, producer.messages)
+		assert.Equal(t, "SESSION_NOT_FOUND", graphql.GetErrors(ctx)[0].Extensions["code"])
+	})
+
+	t.Run("rejects anonymous requests", func(t *testing.T) {
+		ctx := newLogoutContext("", "", "")
+		sessionService := newSessionService()
+
+		result, err := RevokeSession(ctx, &config.Config{}, sessionService, NewMockRefreshTokenService(ctrl), tokenizer, denylist.NewMemoryDenylist(), (&recordingProducer{}).produce, "session_123")
+		assert.NoError(t, err)
+		assert.False(t, result)
+		assert.Empty(t, sessionService.ended)
+		assert.Equal(t, AccessDeniedCode, graphql.GetErrors(ctx)[0].Extensions["code"])
+	})
+}
+
+func TestRevokeOtherSessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokenizer := newTestTokenizer(t)
+
+	subject := "user_123"
+	refreshToken := "refresh_token_123"
+	accessToken, err := tokenizer.Tokenize(jwt.Claims{Subject: &subject, RefreshToken: &refreshToken})
+	assert.NoError(t, err)
+
+	t.Run("ends every session but the current one", func(t *testing.T) {
+		ctx := newLogoutContext("user_123", accessToken, "")
+		sessionService := &recordingSessionService{}
+		tokenDenylist := denylist.NewMemoryDenylist()
+		producer := &recordingProducer{}
+
+		result, err := RevokeOtherSessions(ctx, sessionService, NewMockRefreshTokenService(ctrl), tokenizer, tokenDenylist, producer.produce)
+		assert.NoError(t, err)
+		assert.True(t, result)
+		assert.Equal(t, []revokedSessions{{userID: "user_123", keepSessionID: "session_123"}}, sessionService.revoked)
+
+		denied, err := tokenDenylist.IsDenied(ctx, denylist.Token{Subject: "user_123", IssuedAt: time.Now().Add(-time.Minute)})
+		assert.NoError(t, err)
+		assert.True(t, denied, "access tokens of the other sessions are revoked")
+
+		var event SessionsRevokedEvent
+		assert.NoError(t, json.Unmarshal(producer.messages[0].Value, &event))
+		assert.Equal(t, "user_123", event.UserID)
+		assert.Empty(t, event.SessionID)
+		assert.True(t, event.OtherSessions)
+	})
+
+	t.Run("keeps every session when the current one is unknown", func(t *testing.T) {
+		untracked, err := tokenizer.Tokenize(jwt.Claims{Subject: &subject})
+		assert.NoError(t, err)
+
+		ctx := newLogoutContext("user_123", untracked, "")
+		sessionService := &recordingSessionService{}
+		producer := &recordingProducer{}
+
+		result, err := RevokeOtherSessions(ctx, sessionService, NewMockRefreshTokenService(ctrl), tokenizer, denylist.NewMemoryDenylist(), producer.produce)
+		assert.NoError(t, err)
+		assert.False(t, result)
+		assert.Empty(t, sessionService.revoked)
+		assert.Empty(t, producer.messages)
+		assert.Equal(t, "SESSION_NOT_FOUND", graphql.GetErrors(ctx)[0].Extensions["code"])
+	})
+}
@@ -0,0 +1,59 @@
+package resolvers
+
+import (
+	"context"
+
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/logger"
+	"github.com/weeb-vip/auth/internal/metrics"
+	"github.com/weeb-vip/auth/internal/services/session"
+	SessionModels "github.com/weeb-vip/auth/internal/services/session/models"
+)
+
+// evictSessions makes room for a new session under the session limit, revoking the access tokens issued for the
+// evicted sessions. The sessions that are kept, on other devices, keep theirs. Signing in still works when that
+// fails, so the failure is only logged.
+func evictSessions(
+	ctx context.Context,
+	sessionService session.Session,
+	tokenDenylist denylist.Denylist,
+	createdSession *SessionModels.Session,
+) {
+	log := logger.FromCtx(ctx)
+
+	evicted, err := sessionService.EvictSessions(ctx, createdSession.UserID, createdSession.ID)
+	if err != nil {
+		metrics.GetAppMetrics().SessionOperationMetric("evict", metrics.Error)
+		log.Error().
+			Err(err).
+			Str("user_id", createdSession.UserID).
+			Msg("Failed to evict sessions over the limit")
+
+		return
+	}
+
+	for _, evictedSession := range evicted {
+		logSessionEvicted(ctx, evictedSession.UserID, evictedSession.ID, session.SessionErrorLimitExceeded)
+
+		err = denySessionAccessTokens(ctx, tokenDenylist, evictedSession.ID)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("user_id", evictedSession.UserID).
+				Str("session_id", evictedSession.ID).
+				Msg("Failed to revoke the access tokens of an evicted session")
+		}
+	}
+}
+
+// logSessionEvicted records a session ended by the session policy rather than by its user.
+func logSessionEvicted(ctx context.Context, userID string, sessionID string, reason session.ErrorCode) {
+	metrics.GetAppMetrics().SessionOperationMetric("evict", metrics.Success)
+
+	log := logger.FromCtx(ctx)
+	log.Info().
+		Str("user_id", userID).
+		Str("session_id", sessionID).
+		Str("reason", reason.String()).
+		Msg("Session evicted")
+}
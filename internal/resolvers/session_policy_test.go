@@ -0,0 +1,99 @@
+package resolvers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/db"
+	"github.com/weeb-vip/auth/internal/denylist"
+	"github.com/weeb-vip/auth/internal/services/session"
+	SessionModels "github.com/weeb-vip/auth/internal/services/session/models"
+)
+
+// policySessionService ends sessions the way a session policy would.
+type policySessionService struct {
+	recordingSessionService
+	checkErr error
+	excess   []*SessionModels.Session
+	evicted  []string
+}
+
+func (m *policySessionService) CheckSession(ctx context.Context, userID string, sessionID string) error {
+	return m.checkErr
+}
+
+func (m *policySessionService) EvictSessions(ctx context.Context, userID string, keepSessionID string) ([]*SessionModels.Session, error) {
+	m.evicted = append(m.evicted, userID+"/"+keepSessionID)
+
+	return m.excess, nil
+}
+
+func TestSessionPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testConfig := &config.Config{APPConfig: config.AppConfig{CookieDomain: ".weeb.vip"}}
+
+	t.Run("does not refresh a session that timed out", func(t *testing.T) {
+		ctx := newClientContext("", "", "10.0.0.1", "Safari")
+		sessionService := &policySessionService{checkErr: &session.Error{
+			Code:    session.SessionErrorIdleTimeout,
+			Message: "session timed out after a period of inactivity",
+		}}
+
+		result, err := RefreshToken(ctx, sessionService, NewMockRefreshTokenService(ctrl), NewMockJWTTokenizer(ctrl), (&recordingProducer{}).produce, testConfig, "refresh_token_123")
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+		assert.Empty(t, sessionService.touched, "the refresh token is not rotated")
+		assert.Equal(t, "SESSION_IDLE_TIMEOUT", graphql.GetErrors(ctx)[0].Extensions["code"])
+	})
+
+	t.Run("ends the oldest sessions beyond the limit on sign in", func(t *testing.T) {
+		ctx := newClientContext("", "", "10.0.0.1", "Safari")
+		sessionService := &policySessionService{excess: []*SessionModels.Session{
+			{BaseModel: db.BaseModel{ID: "session_001"}, UserID: "user_123"},
+		}}
+
+		createdSession, err := sessionService.CreateSession(ctx, "user_123", "10.0.0.1", "Safari")
+		assert.NoError(t, err)
+
+		tokenDenylist := denylist.NewMemoryDenylist()
+
+		result, err := issueSession(ctx, createdSession, sessionService, NewMockRefreshTokenService(ctrl), NewMockJWTTokenizer(ctrl), tokenDenylist, testConfig)
+		assert.NoError(t, err)
+		assert.NotNil(t, result)
+		assert.Equal(t, []string{"user_123/session_123"}, sessionService.evicted)
+
+		issuedAt := time.Now().Add(-time.Minute)
+
+		denied, err := tokenDenylist.IsDenied(ctx, denylist.Token{Subject: "user_123", SessionID: "session_001", IssuedAt: issuedAt})
+		assert.NoError(t, err)
+		assert.True(t, denied, "the access token of the evicted session is revoked")
+
+		denied, err = tokenDenylist.IsDenied(ctx, denylist.Token{Subject: "user_123", SessionID: "session_002", IssuedAt: issuedAt})
+		assert.NoError(t, err)
+		assert.False(t, denied, "the access tokens of the sessions that are kept stay valid")
+	})
+
+	t.Run("keeps the access tokens when no session is evicted", func(t *testing.T) {
+		ctx := newClientContext("", "", "10.0.0.1", "Safari")
+		sessionService := &policySessionService{}
+		tokenDenylist := denylist.NewMemoryDenylist()
+
+		createdSession, err := sessionService.CreateSession(ctx, "user_123", "10.0.0.1", "Safari")
+		assert.NoError(t, err)
+
+		_, err = issueSession(ctx, createdSession, sessionService, NewMockRefreshTokenService(ctrl), NewMockJWTTokenizer(ctrl), tokenDenylist, testConfig)
+		assert.NoError(t, err)
+
+		denied, err := tokenDenylist.IsDenied(ctx, denylist.Token{Subject: "user_123", SessionID: "session_001", IssuedAt: time.Now().Add(-time.Minute)})
+		assert.NoError(t, err)
+		assert.False(t, denied)
+	})
+}
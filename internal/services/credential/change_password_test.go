@@ -0,0 +1,138 @@
+package credential
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/db"
+	"github.com/weeb-vip/auth/internal/passwordpolicy"
+	"github.com/weeb-vip/auth/internal/services/credential/models"
+)
+
+type fakeCredentialsRepository struct {
+	credentials *models.Credential
+}
+
+func (f *fakeCredentialsRepository) AddCredentials(username string, userID string, value string, credType models.CredentialTypes) (*models.Credential, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeCredentialsRepository) GetCredentials(username string) (*models.Credential, error) {
+	if f.credentials.Username != username {
+		return &models.Credential{}, nil
+	}
+
+	copied := *f.credentials
+
+	return &copied, nil
+}
+
+func (f *fakeCredentialsRepository) DeleteCredentials(username string) error {
+	return nil
+}
+
+func (f *fakeCredentialsRepository) UpdatePassword(username string, hashedPassword string) error {
+	f.credentials.Value = hashedPassword
+
+	return nil
+}
+
+func (f *fakeCredentialsRepository) ActivateCredentials(id string) error {
+	return nil
+}
+
+func (f *fakeCredentialsRepository) GetCredentialsByIdentifier(identifier string) (*models.Credential, error) {
+	return f.credentials, nil
+}
+
+func (f *fakeCredentialsRepository) GetCredentialsByUserID(userID string) (*models.Credential, error) {
+	return f.credentials, nil
+}
+
+func newChangePasswordService(t *testing.T) (*credentialService, *fakeCredentialsRepository) {
+	t.Helper()
+
+	hash, err := HashSecret("current-password", MinCost)
+	assert.NoError(t, err)
+
+	repository := &fakeCredentialsRepository{credentials: &models.Credential{
+		BaseModel: db.BaseModel{ID: "credential_123"},
+		Username:  "user@weeb.vip",
+		UserID:    "user_123",
+		Value:     hash,
+		Active:    true,
+	}}
+
+	policy := passwordpolicy.New(config.PasswordPolicyConfig{
+		MinLength:        8,
+		MaxLength:        72,
+		DisallowUsername: true,
+		DisallowCommon:   true,
+		MinScore:         2,
+	}, nil)
+
+	return &credentialService{
+		credentialsRepository: repository,
+		passwordPolicy:        policy,
+		passwordHasher:        newTestPasswordHasher(t),
+		lockoutService:        &fakeLockout{},
+	}, repository
+}
+
+func assertCredentialErrorCode(t *testing.T, err error, code ErrorCode) {
+	t.Helper()
+
+	var credentialErr *Error
+	if assert.True(t, errors.As(err, &credentialErr), "expected a credential error, got %v", err) {
+		assert.Equal(t, code, credentialErr.Code)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("replaces the password", func(t *testing.T) {
+		service, repository := newChangePasswordService(t)
+
+		credentials, err := service.ChangePassword(ctx, "user@weeb.vip", "current-password", "violet-Harbor-92")
+		assert.NoError(t, err)
+		assert.Equal(t, "user_123", credentials.UserID)
+		ok, _ := service.VerifyPassword("violet-Harbor-92", repository.credentials.Value)
+		assert.True(t, ok)
+	})
+
+	t.Run("rejects an incorrect current password", func(t *testing.T) {
+		service, repository := newChangePasswordService(t)
+		hash := repository.credentials.Value
+
+		_, err := service.ChangePassword(ctx, "user@weeb.vip", "wrong-password", "violet-Harbor-92")
+		assertCredentialErrorCode(t, err, CredentialErrorIncorrectPassword)
+		assert.Equal(t, hash, repository.credentials.Value)
+	})
+
+	t.Run("rejects a password violating the policy", func(t *testing.T) {
+		service, repository := newChangePasswordService(t)
+
+		for password, violation := range map[string]string{
+			"":                        "PASSWORD_TOO_SHORT",
+			"current-password":        "PASSWORD_UNCHANGED",
+			"Password123":             "PASSWORD_TOO_COMMON",
+			"user@weeb.vip-violet-92": "PASSWORD_CONTAINS_USERNAME",
+		} {
+			_, err := service.ChangePassword(ctx, "user@weeb.vip", "current-password", password)
+
+			var policyErr *passwordpolicy.Error
+			if assert.True(t, errors.As(err, &policyErr), "expected a password policy error, got %v", err) {
+				assert.Contains(t, policyErr.Codes(), violation)
+			}
+		}
+
+		// Verifying the current password may upgrade its hash, but it must still be the current password.
+		ok, _ := service.VerifyPassword("current-password", repository.credentials.Value)
+		assert.True(t, ok)
+	})
+}
@@ -2,9 +2,18 @@ package credential
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/http/handlers/requestinfo"
+	"github.com/weeb-vip/auth/internal/breachedpasswords"
+	"github.com/weeb-vip/auth/internal/metrics"
+	"github.com/weeb-vip/auth/internal/passwordhash"
+	"github.com/weeb-vip/auth/internal/passwordpolicy"
 	"github.com/weeb-vip/auth/internal/services/credential/models"
 	"github.com/weeb-vip/auth/internal/services/credential/repositories"
+	"github.com/weeb-vip/auth/internal/services/lockout"
 	"github.com/weeb-vip/auth/internal/ulid"
 )
 
@@ -14,13 +23,40 @@ const (
 
 type credentialService struct {
 	credentialsRepository repositories.CredentialsRepository
+	passwordPolicy        passwordpolicy.Policy
+	passwordHasher        *passwordhash.Hasher
+	lockoutService        lockout.Lockout
 }
 
-func NewCredentialService() Credential {
+func NewCredentialService(
+	passwordPolicyConfig config.PasswordPolicyConfig,
+	passwordHashConfig config.PasswordHashConfig,
+	lockoutService lockout.Lockout,
+) Credential {
 	credentialRepository := repositories.GetCredentialsRepository()
 
+	passwordHasher, err := newPasswordHasher(passwordHashConfig)
+	if err != nil {
+		panic(fmt.Errorf("failed to create password hasher: %w", err))
+	}
+
+	var breachChecker passwordpolicy.BreachChecker
+
+	if passwordPolicyConfig.BreachedPasswordsFile != "" {
+		filter, err := breachedpasswords.Open(passwordPolicyConfig.BreachedPasswordsFile)
+		if err != nil {
+			panic(fmt.Errorf("failed to load breached passwords: %w", err))
+		}
+
+		metrics.GetAppMetrics().BreachedPasswordFilterMetric(filter.FalsePositiveRate(), filter.Entries())
+		breachChecker = filter
+	}
+
 	return &credentialService{
 		credentialsRepository: credentialRepository,
+		passwordPolicy:        passwordpolicy.New(passwordPolicyConfig, breachChecker),
+		passwordHasher:        passwordHasher,
+		lockoutService:        lockoutService,
 	}
 }
 
@@ -33,6 +69,11 @@ func (service *credentialService) Register(
 	username string,
 	password string,
 ) (*models.Credential, error) {
+	err := service.CheckPassword(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+
 	hashedPassword, err := service.HashPassword(password)
 	if err != nil {
 		return nil, &Error{
@@ -65,6 +106,13 @@ func (service *credentialService) SignIn( //nolint
 	username string,
 	password string,
 ) (*models.Credential, error) {
+	ip := requestinfo.RemoteIP(ctx)
+
+	err := service.lockoutService.Check(ctx, username, ip)
+	if err != nil {
+		return nil, lockoutError(err)
+	}
+
 	credentials, err := service.credentialsRepository.GetCredentials(username) // nolint
 	if err != nil {
 		return nil, &Error{
@@ -73,11 +121,9 @@ func (service *credentialService) SignIn( //nolint
 		}
 	}
 
-	if credentials == nil {
-		return nil, &Error{
-			Code:    CredentialErrorInvalidCredentials,
-			Message: "invalid credentials",
-		}
+	// The repository returns an empty credential for an unknown username.
+	if credentials == nil || credentials.ID == "" {
+		return nil, service.failedSignIn(ctx, username, ip)
 	}
 
 	if !credentials.Active {
@@ -87,21 +133,60 @@ func (service *credentialService) SignIn( //nolint
 		}
 	}
 
-	if service.VerifyPassword(password, credentials.Value) {
-		return credentials, nil
+	ok, needsRehash := service.VerifyPassword(password, credentials.Value)
+	if !ok {
+		return nil, service.failedSignIn(ctx, username, ip)
+	}
+
+	// Failing to forget earlier failures only makes a later lock come sooner, it must not fail the sign in.
+	_ = service.lockoutService.RecordSuccess(ctx, username)
+
+	if needsRehash {
+		service.rehashPassword(username, password, credentials)
 	}
 
-	return nil, &Error{
+	return credentials, nil
+}
+
+// failedSignIn counts the failure towards a lock and reports the lock when this failure caused one.
+func (service *credentialService) failedSignIn(ctx context.Context, username string, ip string) error {
+	err := service.lockoutService.RecordFailure(ctx, username, ip)
+	if err != nil {
+		return lockoutError(err)
+	}
+
+	return &Error{
 		Code:    CredentialErrorInvalidCredentials,
 		Message: "invalid credentials",
 	}
 }
 
+// rehashPassword upgrades the stored hash of a verified password, a failure keeps the old hash
+// until the next sign in as it still verifies.
+func (service *credentialService) rehashPassword(username string, password string, credentials *models.Credential) {
+	hashedPassword, err := service.HashPassword(password)
+	if err != nil {
+		return
+	}
+
+	err = service.credentialsRepository.UpdatePassword(username, hashedPassword)
+	if err != nil {
+		return
+	}
+
+	credentials.Value = hashedPassword
+}
+
 func (service *credentialService) UpdatePassword(
 	ctx context.Context,
 	username string,
 	newPassword string,
 ) error {
+	err := service.CheckPassword(ctx, username, newPassword)
+	if err != nil {
+		return err
+	}
+
 	hashedPassword, err := service.HashPassword(newPassword)
 	if err != nil {
 		return &Error{
@@ -121,6 +206,43 @@ func (service *credentialService) UpdatePassword(
 	return nil
 }
 
+func (service *credentialService) ChangePassword(
+	ctx context.Context,
+	username string,
+	oldPassword string,
+	newPassword string,
+) (*models.Credential, error) {
+	credentials, err := service.SignIn(ctx, username, oldPassword)
+	if err != nil {
+		var credentialErr *Error
+		if errors.As(err, &credentialErr) && credentialErr.Code == CredentialErrorInvalidCredentials {
+			return nil, &Error{
+				Code:    CredentialErrorIncorrectPassword,
+				Message: "current password is incorrect",
+			}
+		}
+
+		return nil, err
+	}
+
+	if newPassword == oldPassword {
+		return nil, &passwordpolicy.Error{
+			Violations: []passwordpolicy.Violation{passwordpolicy.ViolationUnchanged},
+		}
+	}
+
+	err = service.UpdatePassword(ctx, username, newPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+func (service *credentialService) CheckPassword(ctx context.Context, username string, password string) error {
+	return service.passwordPolicy.Check(password, username)
+}
+
 func (service *credentialService) ActivateCredentials(ctx context.Context, identifier string) error {
 	err := service.credentialsRepository.ActivateCredentials(identifier)
 	if err != nil {
@@ -144,3 +266,37 @@ func (service *credentialService) GetCredentialsByIdentifier(ctx context.Context
 
 	return credentials, nil
 }
+
+func (service *credentialService) GetCredentialsByUserID(ctx context.Context, userID string) (*models.Credential, error) {
+	credentials, err := service.credentialsRepository.GetCredentialsByUserID(userID)
+	if err != nil {
+		return nil, &Error{
+			Code:    CredentialErrorInternalError,
+			Message: err.Error(),
+		}
+	}
+
+	if credentials == nil {
+		return nil, &Error{
+			Code:    CredentialErrorInvalidCredentials,
+			Message: "credentials not found",
+		}
+	}
+
+	return credentials, nil
+}
+
+func lockoutError(err error) error {
+	var lockoutErr *lockout.Error
+	if errors.As(err, &lockoutErr) && lockoutErr.Code == lockout.LockoutErrorLocked {
+		return &Error{
+			Code:    CredentialErrorLocked,
+			Message: lockoutErr.Message,
+		}
+	}
+
+	return &Error{
+		Code:    CredentialErrorInternalError,
+		Message: err.Error(),
+	}
+}
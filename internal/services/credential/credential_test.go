@@ -6,29 +6,39 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/weeb-vip/auth/config"
 	"github.com/weeb-vip/auth/internal/services/credential"
+	"github.com/weeb-vip/auth/internal/services/lockout"
 )
 
+func newCredentialService() credential.Credential {
+	return credential.NewCredentialService(
+		config.PasswordPolicyConfig{MinLength: 8},
+		config.PasswordHashConfig{Algorithm: "bcrypt", BcryptCost: credential.MinCost},
+		lockout.NewLockoutService(config.LockoutConfig{}, config.TokenHashConfig{Secret: "secret"}),
+	)
+}
+
 func TestCredentialService_Register(t *testing.T) {
 	t.Parallel()
 	t.Run("Test Register", func(t *testing.T) {
 		t.Parallel()
 		a := assert.New(t)
 
-		credentialService := credential.NewCredentialService()
+		credentialService := newCredentialService()
 
-		_, err := credentialService.Register(context.TODO(), "username", "password")
+		_, err := credentialService.Register(context.TODO(), "username", "violet-Harbor-92")
 		a.NoError(err)
 	})
 	t.Run("Test Register 2 times - idempotence", func(t *testing.T) {
 		t.Parallel()
 		a := assert.New(t)
 
-		credentialService := credential.NewCredentialService()
+		credentialService := newCredentialService()
 
-		_, err := credentialService.Register(context.TODO(), "username2", "password")
+		_, err := credentialService.Register(context.TODO(), "username2", "violet-Harbor-92")
 		a.NoError(err)
-		_, err = credentialService.Register(context.TODO(), "username2", "password")
+		_, err = credentialService.Register(context.TODO(), "username2", "violet-Harbor-92")
 		a.NoError(err)
 	})
 }
@@ -39,12 +49,12 @@ func TestCredentialService_SignIn(t *testing.T) {
 		t.Parallel()
 		a := assert.New(t)
 
-		credentialService := credential.NewCredentialService()
+		credentialService := newCredentialService()
 
-		_, err := credentialService.Register(context.TODO(), "username", "password")
+		_, err := credentialService.Register(context.TODO(), "username", "violet-Harbor-92")
 		a.NoError(err)
 
-		a.NotNil(credentialService.SignIn(context.TODO(), "username", "password"))
-		a.Nil(credentialService.SignIn(context.TODO(), "username", "password2"))
+		a.NotNil(credentialService.SignIn(context.TODO(), "username", "violet-Harbor-92"))
+		a.Nil(credentialService.SignIn(context.TODO(), "username", "violet-Harbor-93"))
 	})
 }
@@ -4,6 +4,8 @@ const (
 	CredentialErrorInternalError       ErrorCode = "INTERNAL_ERROR"       // nolint
 	CredentialErrorInvalidCredentials  ErrorCode = "INVALID_CREDENTIALS"  // nolint
 	CredentialErrorInactiveCredentials ErrorCode = "INACTIVE_CREDENTIALS" // nolint
+	CredentialErrorIncorrectPassword   ErrorCode = "INCORRECT_PASSWORD"   // nolint
+	CredentialErrorLocked              ErrorCode = "CREDENTIALS_LOCKED"   // nolint
 )
 
 type ErrorCode string
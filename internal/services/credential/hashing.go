@@ -1,26 +1,72 @@
 package credential
 
 import (
+	"fmt"
+
 	"golang.org/x/crypto/bcrypt"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/passwordhash"
 )
 
 const (
 	MinCost     int = 4  // The minimum allowable cost as passed in to GenerateFromPassword.
 	MaxCost     int = 14 // The maximum allowable cost as passed in to GenerateFromPassword.
 	DefaultCost int = 10 // The cost that will actually be set if a cost below MinCost is passed into GenerateFromPassword.
+
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
 )
 
+// newPasswordHasher hashes with the configured algorithm and keeps verifying the other one,
+// so switching algorithms never locks anyone out.
+func newPasswordHasher(cfg config.PasswordHashConfig) (*passwordhash.Hasher, error) {
+	argon2id := passwordhash.Argon2id{
+		Memory:      cfg.Argon2Memory,
+		Iterations:  cfg.Argon2Iterations,
+		Parallelism: cfg.Argon2Parallelism,
+		SaltLength:  argon2SaltLength,
+		KeyLength:   argon2KeyLength,
+	}
+	legacyBcrypt := passwordhash.Bcrypt{Cost: cfg.BcryptCost}
+
+	switch cfg.Algorithm {
+	case argon2id.Name():
+		return passwordhash.New(argon2id, legacyBcrypt), nil
+	case legacyBcrypt.Name():
+		return passwordhash.New(legacyBcrypt, argon2id), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", passwordhash.ErrUnknownAlgorithm, cfg.Algorithm)
+	}
+}
+
 func (service *credentialService) HashPassword(password string) (string, error) {
-	pass, err := bcrypt.GenerateFromPassword([]byte(password), MaxCost)
+	return service.passwordHasher.Hash(password)
+}
+
+// VerifyPassword also reports whether a matching hash should be replaced by one with the current settings.
+func (service *credentialService) VerifyPassword(password, hash string) (bool, bool) {
+	ok, needsRehash, err := service.passwordHasher.Verify(password, hash)
+	if err != nil {
+		return false, false
+	}
+
+	return ok, needsRehash
+}
+
+// HashSecret hashes short lived or high entropy secrets such as recovery codes with bcrypt at the given cost,
+// passwords go through the configured password hasher instead.
+func HashSecret(secret string, cost int) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), cost)
 	if err != nil {
 		return "", err
 	}
 
-	return string(pass), nil
+	return string(hash), nil
 }
 
-func (service *credentialService) VerifyPassword(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+func VerifySecret(secret, hash string) bool {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(secret))
 
 	return err == nil
 }
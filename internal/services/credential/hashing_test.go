@@ -0,0 +1,80 @@
+package credential
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/passwordhash"
+)
+
+// newTestPasswordHasher uses cheap argon2id parameters so tests stay fast.
+func newTestPasswordHasher(t *testing.T) *passwordhash.Hasher {
+	t.Helper()
+
+	hasher, err := newPasswordHasher(config.PasswordHashConfig{
+		Algorithm:         "argon2id",
+		Argon2Memory:      64,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+		BcryptCost:        MinCost,
+	})
+	assert.NoError(t, err)
+
+	return hasher
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+	t.Run("hashes with the configured algorithm", func(t *testing.T) {
+		hasher, err := newPasswordHasher(config.PasswordHashConfig{Algorithm: "bcrypt", BcryptCost: MinCost})
+		assert.NoError(t, err)
+
+		encoded, err := hasher.Hash("violet-Harbor-92")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(encoded, "$2a$04$"))
+	})
+
+	t.Run("rejects an unknown algorithm", func(t *testing.T) {
+		_, err := newPasswordHasher(config.PasswordHashConfig{Algorithm: "md5"})
+		assert.ErrorIs(t, err, passwordhash.ErrUnknownAlgorithm)
+	})
+}
+
+func TestSignInRehash(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("upgrades a legacy bcrypt hash", func(t *testing.T) {
+		service, repository := newChangePasswordService(t)
+		assert.True(t, strings.HasPrefix(repository.credentials.Value, "$2a$"))
+
+		credentials, err := service.SignIn(ctx, "user@weeb.vip", "current-password")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(repository.credentials.Value, "$argon2id$v=19$m=64,t=1,p=1$"))
+		assert.Equal(t, repository.credentials.Value, credentials.Value)
+
+		_, err = service.SignIn(ctx, "user@weeb.vip", "current-password")
+		assert.NoError(t, err)
+	})
+
+	t.Run("keeps a current hash", func(t *testing.T) {
+		service, repository := newChangePasswordService(t)
+		_, _ = service.SignIn(ctx, "user@weeb.vip", "current-password")
+		upgraded := repository.credentials.Value
+
+		_, err := service.SignIn(ctx, "user@weeb.vip", "current-password")
+		assert.NoError(t, err)
+		assert.Equal(t, upgraded, repository.credentials.Value)
+	})
+
+	t.Run("leaves the hash alone on a wrong password", func(t *testing.T) {
+		service, repository := newChangePasswordService(t)
+		legacy := repository.credentials.Value
+
+		_, err := service.SignIn(ctx, "user@weeb.vip", "wrong-password")
+		assertCredentialErrorCode(t, err, CredentialErrorInvalidCredentials)
+		assert.Equal(t, legacy, repository.credentials.Value)
+	})
+}
@@ -10,7 +10,13 @@ type Credential interface {
 	Register(ctx context.Context, username string, password string) (*models.Credential, error)
 	SignIn(ctx context.Context, username string, password string) (*models.Credential, error)
 	GetCredentials(ctx context.Context, username string) (*models.Credential, error)
+	// CheckPassword returns a *passwordpolicy.Error when the password may not be set for the user,
+	// Register, UpdatePassword and ChangePassword run it themselves.
+	CheckPassword(ctx context.Context, username string, password string) error
 	UpdatePassword(ctx context.Context, username string, newPassword string) error
+	// ChangePassword replaces the password after verifying the current one the same way SignIn does.
+	ChangePassword(ctx context.Context, username string, oldPassword string, newPassword string) (*models.Credential, error)
 	ActivateCredentials(ctx context.Context, identifier string) error
 	GetCredentialsByIdentifier(ctx context.Context, identifier string) (*models.Credential, error)
+	GetCredentialsByUserID(ctx context.Context, userID string) (*models.Credential, error)
 }
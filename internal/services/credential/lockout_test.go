@@ -0,0 +1,108 @@
+package credential
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/internal/services/lockout"
+)
+
+// fakeLockout locks a username once it reached maxFailures, it ignores the IP.
+type fakeLockout struct {
+	maxFailures int
+	failures    map[string]int
+}
+
+func (f *fakeLockout) Check(ctx context.Context, username string, ip string) error {
+	if f.maxFailures > 0 && f.failures[username] >= f.maxFailures {
+		return f.lockedError()
+	}
+
+	return nil
+}
+
+func (f *fakeLockout) RecordFailure(ctx context.Context, username string, ip string) error {
+	if f.failures == nil {
+		f.failures = map[string]int{}
+	}
+
+	f.failures[username]++
+
+	return f.Check(ctx, username, ip)
+}
+
+func (f *fakeLockout) RecordSuccess(ctx context.Context, username string) error {
+	delete(f.failures, username)
+
+	return nil
+}
+
+func (f *fakeLockout) RequestUnlock(ctx context.Context, username string) (string, error) {
+	return "", nil
+}
+
+func (f *fakeLockout) Unlock(ctx context.Context, token string) error {
+	return nil
+}
+
+func (f *fakeLockout) CheckMFA(ctx context.Context, userID string) error {
+	return nil
+}
+
+func (f *fakeLockout) RecordMFAFailure(ctx context.Context, userID string) error {
+	return nil
+}
+
+func (f *fakeLockout) RecordMFASuccess(ctx context.Context, userID string) error {
+	return nil
+}
+
+func (f *fakeLockout) lockedError() error {
+	lockedUntil := time.Now().Add(time.Minute)
+
+	return &lockout.Error{Code: lockout.LockoutErrorLocked, Message: "locked", LockedUntil: &lockedUntil}
+}
+
+func TestSignInLockout(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("locks after repeated wrong passwords", func(t *testing.T) {
+		service, _ := newChangePasswordService(t)
+		locker := &fakeLockout{maxFailures: 2}
+		service.lockoutService = locker
+
+		_, err := service.SignIn(ctx, "user@weeb.vip", "wrong-password")
+		assertCredentialErrorCode(t, err, CredentialErrorInvalidCredentials)
+
+		_, err = service.SignIn(ctx, "user@weeb.vip", "wrong-password")
+		assertCredentialErrorCode(t, err, CredentialErrorLocked)
+
+		_, err = service.SignIn(ctx, "user@weeb.vip", "current-password")
+		assertCredentialErrorCode(t, err, CredentialErrorLocked)
+	})
+
+	t.Run("counts unknown usernames", func(t *testing.T) {
+		service, repository := newChangePasswordService(t)
+		locker := &fakeLockout{maxFailures: 5}
+		service.lockoutService = locker
+		repository.credentials.Username = "someone-else@weeb.vip"
+
+		_, err := service.SignIn(ctx, "user@weeb.vip", "current-password")
+		assertCredentialErrorCode(t, err, CredentialErrorInvalidCredentials)
+		assert.Equal(t, 1, locker.failures["user@weeb.vip"])
+	})
+
+	t.Run("forgets failures after a successful sign in", func(t *testing.T) {
+		service, _ := newChangePasswordService(t)
+		locker := &fakeLockout{maxFailures: 5}
+		service.lockoutService = locker
+
+		_, _ = service.SignIn(ctx, "user@weeb.vip", "wrong-password")
+		_, err := service.SignIn(ctx, "user@weeb.vip", "current-password")
+		assert.NoError(t, err)
+		assert.Zero(t, locker.failures["user@weeb.vip"])
+	})
+}
@@ -6,6 +6,7 @@ import (
 
 const PasswordCredential CredentialTypes = "password"
 const TokenCredential CredentialTypes = "token" // nolint
+const WebAuthnCredential CredentialTypes = "webauthn"
 
 type CredentialTypes string
 
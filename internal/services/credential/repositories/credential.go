@@ -21,6 +21,7 @@ type CredentialsRepository interface {
 	UpdatePassword(username string, hashedPassword string) error
 	ActivateCredentials(id string) error
 	GetCredentialsByIdentifier(identifier string) (*models.Credential, error)
+	GetCredentialsByUserID(userID string) (*models.Credential, error)
 }
 
 type credentialsRepository struct {
@@ -115,3 +116,20 @@ func (repository *credentialsRepository) GetCredentialsByIdentifier(identifier s
 
 	return &credentials, nil
 }
+
+func (repository *credentialsRepository) GetCredentialsByUserID(userID string) (*models.Credential, error) {
+	database := repository.DBService.GetDB()
+
+	var credentials models.Credential
+
+	err := database.Where("user_id = ?", userID).First(&credentials).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &credentials, nil
+}
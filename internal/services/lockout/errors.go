@@ -0,0 +1,25 @@
+package lockout
+
+import "time"
+
+const (
+	LockoutErrorInternalError      ErrorCode = "INTERNAL_ERROR"       // nolint
+	LockoutErrorLocked             ErrorCode = "ACCOUNT_LOCKED"       // nolint
+	LockoutErrorInvalidUnlockToken ErrorCode = "INVALID_UNLOCK_TOKEN" // nolint
+)
+
+type ErrorCode string
+
+type Error struct {
+	Code        ErrorCode
+	Message     string
+	LockedUntil *time.Time // set for LockoutErrorLocked.
+}
+
+func (c ErrorCode) String() string {
+	return string(c)
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
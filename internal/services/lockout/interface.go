@@ -0,0 +1,26 @@
+package lockout
+
+import (
+	"context"
+)
+
+// Lockout slows down password guessing by locking a username or a source IP after repeated failed sign ins,
+// each further failure after the threshold doubles the lock up to the configured maximum.
+type Lockout interface {
+	// Check returns an *Error with LockoutErrorLocked while the username or the IP is locked.
+	Check(ctx context.Context, username string, ip string) error
+	// RecordFailure counts a failed sign in and returns an *Error with LockoutErrorLocked when it caused a lock.
+	RecordFailure(ctx context.Context, username string, ip string) error
+	// RecordSuccess forgets the failures of the username, the failures of the IP are kept.
+	RecordSuccess(ctx context.Context, username string) error
+	// RequestUnlock issues an unlock token for a locked username, it returns an empty token when it is not locked
+	// or when the previous token was issued within the resend interval.
+	RequestUnlock(ctx context.Context, username string) (string, error)
+	Unlock(ctx context.Context, token string) error
+	// CheckMFA returns an *Error with LockoutErrorLocked while the second factor of the user is locked.
+	CheckMFA(ctx context.Context, userID string) error
+	// RecordMFAFailure counts a wrong second factor code and returns an *Error with LockoutErrorLocked when
+	// it caused a lock. An emailed unlock token does not lift this lock.
+	RecordMFAFailure(ctx context.Context, userID string) error
+	RecordMFASuccess(ctx context.Context, userID string) error
+}
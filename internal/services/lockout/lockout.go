@@ -0,0 +1,309 @@
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/metrics"
+	"github.com/weeb-vip/auth/internal/services/lockout/models"
+	"github.com/weeb-vip/auth/internal/services/lockout/repositories"
+	"github.com/weeb-vip/auth/internal/tokenhash"
+)
+
+const (
+	defaultMaxUsernameFailures     = 5
+	defaultMaxIPFailures           = 50
+	defaultMaxMFAFailures          = 5
+	defaultFailureWindowInSeconds  = 900
+	defaultBaseLockoutInSeconds    = 60
+	defaultMaxLockoutInSeconds     = 3600
+	defaultUnlockTokenTTLInSeconds = 3600
+	defaultUnlockResendInSeconds   = 300
+)
+
+type lockoutService struct {
+	loginFailuresRepository repositories.LoginFailuresRepository
+	config                  config.LockoutConfig
+	now                     func() time.Time
+}
+
+func NewLockoutService(cfg config.LockoutConfig, tokenHashConfig config.TokenHashConfig) Lockout {
+	tokenHasher, err := tokenhash.New(tokenHashConfig.Secret)
+	if err != nil {
+		panic(fmt.Errorf("failed to create token hasher: %w", err))
+	}
+
+	return &lockoutService{
+		loginFailuresRepository: repositories.NewLoginFailuresRepository(tokenHasher),
+		config:                  cfg,
+		now:                     time.Now,
+	}
+}
+
+func (service *lockoutService) Check(ctx context.Context, username string, ip string) error {
+	now := service.now()
+
+	for _, subject := range service.subjects(username, ip) {
+		failure, err := service.loginFailuresRepository.GetLoginFailure(subject.scope, subject.value)
+		if err != nil {
+			return internalError(err)
+		}
+
+		if failure != nil && failure.IsLocked(now) {
+			return lockedError(failure.LockedUntil)
+		}
+	}
+
+	return nil
+}
+
+func (service *lockoutService) RecordFailure(ctx context.Context, username string, ip string) error {
+	now := service.now()
+
+	var lockedUntil *time.Time
+
+	for _, subject := range service.subjects(username, ip) {
+		threshold := service.threshold(subject.scope)
+
+		failure, err := service.loginFailuresRepository.UpdateLoginFailure(
+			subject.scope,
+			subject.value,
+			func(failure *models.LoginFailure) {
+				service.registerFailure(failure, threshold, now)
+			},
+		)
+		if err != nil {
+			return internalError(err)
+		}
+
+		if failure.IsLocked(now) {
+			metrics.GetAppMetrics().AccountLockoutMetric(string(subject.scope))
+
+			if lockedUntil == nil || failure.LockedUntil.After(*lockedUntil) {
+				lockedUntil = failure.LockedUntil
+			}
+		}
+	}
+
+	if lockedUntil != nil {
+		return lockedError(lockedUntil)
+	}
+
+	return nil
+}
+
+func (service *lockoutService) RecordSuccess(ctx context.Context, username string) error {
+	err := service.loginFailuresRepository.DeleteLoginFailure(models.UsernameScope, normalizeUsername(username))
+	if err != nil {
+		return internalError(err)
+	}
+
+	return nil
+}
+
+func (service *lockoutService) RequestUnlock(ctx context.Context, username string) (string, error) {
+	now := service.now()
+
+	failure, err := service.loginFailuresRepository.GetLoginFailure(models.UsernameScope, normalizeUsername(username))
+	if err != nil {
+		return "", internalError(err)
+	}
+
+	if failure == nil || !failure.IsLocked(now) {
+		return "", nil
+	}
+
+	ttl := time.Duration(orDefault(service.config.UnlockTokenTTLInSeconds, defaultUnlockTokenTTLInSeconds)) * time.Second
+
+	// The token mailed last was issued a ttl before it expires, until the resend interval has passed since then
+	// no new one is mailed, so the endpoint cannot be used to flood the inbox of a locked account.
+	if failure.UnlockTokenExpiresAt != nil {
+		resend := time.Duration(orDefault(service.config.UnlockResendInSeconds, defaultUnlockResendInSeconds)) * time.Second
+		if now.Before(failure.UnlockTokenExpiresAt.Add(-ttl).Add(resend)) {
+			return "", nil
+		}
+	}
+
+	token := uuid.New().String()
+
+	err = service.loginFailuresRepository.SetUnlockToken(failure.ID, token, now.Add(ttl))
+	if err != nil {
+		return "", internalError(err)
+	}
+
+	return token, nil
+}
+
+func (service *lockoutService) Unlock(ctx context.Context, token string) error {
+	failure, err := service.loginFailuresRepository.GetLoginFailureByUnlockToken(token)
+	if err != nil {
+		return internalError(err)
+	}
+
+	if failure == nil || failure.UnlockTokenExpiresAt == nil || !service.now().Before(*failure.UnlockTokenExpiresAt) {
+		return invalidUnlockTokenError()
+	}
+
+	unlocked, err := service.loginFailuresRepository.Unlock(failure.ID)
+	if err != nil {
+		return internalError(err)
+	}
+
+	if !unlocked {
+		return invalidUnlockTokenError()
+	}
+
+	return nil
+}
+
+func (service *lockoutService) CheckMFA(ctx context.Context, userID string) error {
+	failure, err := service.loginFailuresRepository.GetLoginFailure(models.MFAScope, userID)
+	if err != nil {
+		return internalError(err)
+	}
+
+	if failure != nil && failure.IsLocked(service.now()) {
+		return lockedError(failure.LockedUntil)
+	}
+
+	return nil
+}
+
+func (service *lockoutService) RecordMFAFailure(ctx context.Context, userID string) error {
+	now := service.now()
+	threshold := service.threshold(models.MFAScope)
+
+	failure, err := service.loginFailuresRepository.UpdateLoginFailure(
+		models.MFAScope,
+		userID,
+		func(failure *models.LoginFailure) {
+			service.registerFailure(failure, threshold, now)
+		},
+	)
+	if err != nil {
+		return internalError(err)
+	}
+
+	if failure.IsLocked(now) {
+		metrics.GetAppMetrics().AccountLockoutMetric(string(models.MFAScope))
+
+		return lockedError(failure.LockedUntil)
+	}
+
+	return nil
+}
+
+func (service *lockoutService) RecordMFASuccess(ctx context.Context, userID string) error {
+	err := service.loginFailuresRepository.DeleteLoginFailure(models.MFAScope, userID)
+	if err != nil {
+		return internalError(err)
+	}
+
+	return nil
+}
+
+// registerFailure starts counting afresh once the subject has been quiet for the failure window,
+// measured from the end of its last lock so a long lock does not wipe the history it was based on.
+func (service *lockoutService) registerFailure(failure *models.LoginFailure, threshold int, now time.Time) {
+	window := time.Duration(orDefault(service.config.FailureWindowInSeconds, defaultFailureWindowInSeconds)) * time.Second
+
+	lastActivity := failure.LastFailedAt
+	if failure.LockedUntil != nil && (lastActivity == nil || failure.LockedUntil.After(*lastActivity)) {
+		lastActivity = failure.LockedUntil
+	}
+
+	if lastActivity != nil && now.Sub(*lastActivity) > window {
+		failure.Failures = 0
+	}
+
+	failure.Failures++
+	failure.LastFailedAt = &now
+
+	if failure.Failures >= threshold {
+		lockedUntil := now.Add(service.lockDuration(failure.Failures - threshold))
+		failure.LockedUntil = &lockedUntil
+	}
+}
+
+// lockDuration doubles the base lock for every failure past the threshold.
+func (service *lockoutService) lockDuration(excessFailures int) time.Duration {
+	base := time.Duration(orDefault(service.config.BaseLockoutInSeconds, defaultBaseLockoutInSeconds)) * time.Second
+	maximum := time.Duration(orDefault(service.config.MaxLockoutInSeconds, defaultMaxLockoutInSeconds)) * time.Second
+
+	duration := base
+	for i := 0; i < excessFailures && duration < maximum; i++ {
+		duration *= 2
+	}
+
+	if duration > maximum {
+		return maximum
+	}
+
+	return duration
+}
+
+func (service *lockoutService) threshold(scope models.LoginFailureScope) int {
+	switch scope {
+	case models.IPScope:
+		return orDefault(service.config.MaxIPFailures, defaultMaxIPFailures)
+	case models.MFAScope:
+		return orDefault(service.config.MaxMFAFailures, defaultMaxMFAFailures)
+	}
+
+	return orDefault(service.config.MaxUsernameFailures, defaultMaxUsernameFailures)
+}
+
+type subject struct {
+	scope models.LoginFailureScope
+	value string
+}
+
+// subjects skips the IP when the request did not carry one.
+func (service *lockoutService) subjects(username string, ip string) []subject {
+	subjects := []subject{{scope: models.UsernameScope, value: normalizeUsername(username)}}
+
+	if ip != "" {
+		subjects = append(subjects, subject{scope: models.IPScope, value: ip})
+	}
+
+	return subjects
+}
+
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+func orDefault(value int, fallback int) int {
+	if value <= 0 {
+		return fallback
+	}
+
+	return value
+}
+
+func lockedError(lockedUntil *time.Time) error {
+	return &Error{
+		Code:        LockoutErrorLocked,
+		Message:     "too many failed sign in attempts, try again later",
+		LockedUntil: lockedUntil,
+	}
+}
+
+func invalidUnlockTokenError() error {
+	return &Error{
+		Code:    LockoutErrorInvalidUnlockToken,
+		Message: "invalid or expired unlock token",
+	}
+}
+
+func internalError(err error) error {
+	return &Error{
+		Code:    LockoutErrorInternalError,
+		Message: err.Error(),
+	}
+}
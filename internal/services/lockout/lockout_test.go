@@ -0,0 +1,271 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/db"
+	"github.com/weeb-vip/auth/internal/services/lockout/models"
+)
+
+type fakeLoginFailuresRepository struct {
+	failures map[models.LoginFailureScope]map[string]*models.LoginFailure
+	tokens   map[string]string // unlock token to failure ID.
+}
+
+func newFakeLoginFailuresRepository() *fakeLoginFailuresRepository {
+	return &fakeLoginFailuresRepository{
+		failures: map[models.LoginFailureScope]map[string]*models.LoginFailure{
+			models.UsernameScope: {},
+			models.IPScope:       {},
+			models.MFAScope:      {},
+		},
+		tokens: map[string]string{},
+	}
+}
+
+func (f *fakeLoginFailuresRepository) GetLoginFailure(scope models.LoginFailureScope, subject string) (*models.LoginFailure, error) {
+	failure, ok := f.failures[scope][subject]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *failure
+
+	return &copied, nil
+}
+
+func (f *fakeLoginFailuresRepository) UpdateLoginFailure(
+	scope models.LoginFailureScope,
+	subject string,
+	update func(failure *models.LoginFailure),
+) (*models.LoginFailure, error) {
+	failure, ok := f.failures[scope][subject]
+	if !ok {
+		failure = &models.LoginFailure{BaseModel: db.BaseModel{ID: string(scope) + ":" + subject}, Scope: scope, Subject: subject}
+		f.failures[scope][subject] = failure
+	}
+
+	update(failure)
+	copied := *failure
+
+	return &copied, nil
+}
+
+func (f *fakeLoginFailuresRepository) DeleteLoginFailure(scope models.LoginFailureScope, subject string) error {
+	delete(f.failures[scope], subject)
+
+	return nil
+}
+
+func (f *fakeLoginFailuresRepository) SetUnlockToken(id string, token string, expiresAt time.Time) error {
+	for _, failure := range f.failures[models.UsernameScope] {
+		if failure.ID == id {
+			hash := "hash:" + token
+			failure.UnlockTokenHash = &hash
+			failure.UnlockTokenExpiresAt = &expiresAt
+			f.tokens[token] = id
+		}
+	}
+
+	return nil
+}
+
+func (f *fakeLoginFailuresRepository) GetLoginFailureByUnlockToken(token string) (*models.LoginFailure, error) {
+	for _, failure := range f.failures[models.UsernameScope] {
+		if failure.ID == f.tokens[token] && failure.UnlockTokenHash != nil && *failure.UnlockTokenHash == "hash:"+token {
+			copied := *failure
+
+			return &copied, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (f *fakeLoginFailuresRepository) Unlock(id string) (bool, error) {
+	for _, failure := range f.failures[models.UsernameScope] {
+		if failure.ID == id && failure.UnlockTokenHash != nil {
+			failure.Failures = 0
+			failure.LockedUntil = nil
+			failure.UnlockTokenHash = nil
+			failure.UnlockTokenExpiresAt = nil
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func newTestService() (*lockoutService, *fakeLoginFailuresRepository, *time.Time) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	repository := newFakeLoginFailuresRepository()
+
+	return &lockoutService{
+		loginFailuresRepository: repository,
+		config: config.LockoutConfig{
+			MaxUsernameFailures:     3,
+			MaxIPFailures:           5,
+			MaxMFAFailures:          4,
+			FailureWindowInSeconds:  600,
+			BaseLockoutInSeconds:    60,
+			MaxLockoutInSeconds:     300,
+			UnlockTokenTTLInSeconds: 3600,
+			UnlockResendInSeconds:   30,
+		},
+		now: func() time.Time { return now },
+	}, repository, &now
+}
+
+func assertLocked(t *testing.T, err error, until time.Time) {
+	t.Helper()
+
+	var lockoutErr *Error
+	if assert.True(t, errors.As(err, &lockoutErr), "expected a lockout error, got %v", err) {
+		assert.Equal(t, LockoutErrorLocked, lockoutErr.Code)
+		assert.Equal(t, until, *lockoutErr.LockedUntil)
+	}
+}
+
+func TestLockout(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("locks a username after the threshold and doubles the lock", func(t *testing.T) {
+		service, _, now := newTestService()
+
+		assert.NoError(t, service.RecordFailure(ctx, "User@weeb.vip", ""))
+		assert.NoError(t, service.RecordFailure(ctx, "user@weeb.vip", ""))
+		assertLocked(t, service.RecordFailure(ctx, "user@weeb.vip", ""), now.Add(time.Minute))
+		assertLocked(t, service.Check(ctx, "USER@weeb.vip", "10.0.0.1"), now.Add(time.Minute))
+
+		*now = now.Add(time.Minute)
+		assert.NoError(t, service.Check(ctx, "user@weeb.vip", ""))
+		assertLocked(t, service.RecordFailure(ctx, "user@weeb.vip", ""), now.Add(2*time.Minute))
+
+		*now = now.Add(2 * time.Minute)
+		assertLocked(t, service.RecordFailure(ctx, "user@weeb.vip", ""), now.Add(4*time.Minute))
+
+		*now = now.Add(4 * time.Minute)
+		assertLocked(t, service.RecordFailure(ctx, "user@weeb.vip", ""), now.Add(5*time.Minute))
+	})
+
+	t.Run("forgets failures after a quiet window", func(t *testing.T) {
+		service, _, now := newTestService()
+
+		assert.NoError(t, service.RecordFailure(ctx, "user@weeb.vip", ""))
+		assert.NoError(t, service.RecordFailure(ctx, "user@weeb.vip", ""))
+
+		*now = now.Add(11 * time.Minute)
+		assert.NoError(t, service.RecordFailure(ctx, "user@weeb.vip", ""))
+		assert.NoError(t, service.RecordFailure(ctx, "user@weeb.vip", ""))
+	})
+
+	t.Run("resets the username but not the IP on success", func(t *testing.T) {
+		service, repository, _ := newTestService()
+
+		assert.NoError(t, service.RecordFailure(ctx, "user@weeb.vip", "10.0.0.1"))
+		assert.NoError(t, service.RecordFailure(ctx, "user@weeb.vip", "10.0.0.1"))
+		assert.NoError(t, service.RecordSuccess(ctx, "user@weeb.vip"))
+
+		assert.NotContains(t, repository.failures[models.UsernameScope], "user@weeb.vip")
+		assert.Equal(t, 2, repository.failures[models.IPScope]["10.0.0.1"].Failures)
+	})
+
+	t.Run("locks an IP guessing across usernames", func(t *testing.T) {
+		service, _, now := newTestService()
+
+		for i, username := range []string{"a@weeb.vip", "b@weeb.vip", "c@weeb.vip", "d@weeb.vip"} {
+			assert.NoError(t, service.RecordFailure(ctx, username, "10.0.0.1"), i)
+		}
+
+		assertLocked(t, service.RecordFailure(ctx, "e@weeb.vip", "10.0.0.1"), now.Add(time.Minute))
+		assertLocked(t, service.Check(ctx, "f@weeb.vip", "10.0.0.1"), now.Add(time.Minute))
+		assert.NoError(t, service.Check(ctx, "f@weeb.vip", "10.0.0.2"))
+	})
+
+	t.Run("unlocks a username with an emailed token once", func(t *testing.T) {
+		service, _, _ := newTestService()
+
+		token, err := service.RequestUnlock(ctx, "user@weeb.vip")
+		assert.NoError(t, err)
+		assert.Empty(t, token)
+
+		for i := 0; i < 3; i++ {
+			_ = service.RecordFailure(ctx, "user@weeb.vip", "")
+		}
+
+		token, err = service.RequestUnlock(ctx, "user@weeb.vip")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, token)
+
+		assert.NoError(t, service.Unlock(ctx, token))
+		assert.NoError(t, service.Check(ctx, "user@weeb.vip", ""))
+
+		var lockoutErr *Error
+		assert.True(t, errors.As(service.Unlock(ctx, token), &lockoutErr))
+		assert.Equal(t, LockoutErrorInvalidUnlockToken, lockoutErr.Code)
+	})
+
+	t.Run("issues no new unlock token within the resend interval", func(t *testing.T) {
+		service, _, now := newTestService()
+
+		for i := 0; i < 3; i++ {
+			_ = service.RecordFailure(ctx, "user@weeb.vip", "")
+		}
+
+		first, _ := service.RequestUnlock(ctx, "user@weeb.vip")
+		assert.NotEmpty(t, first)
+
+		*now = now.Add(20 * time.Second)
+		token, err := service.RequestUnlock(ctx, "user@weeb.vip")
+		assert.NoError(t, err)
+		assert.Empty(t, token)
+
+		*now = now.Add(20 * time.Second)
+		token, err = service.RequestUnlock(ctx, "user@weeb.vip")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, token)
+		assert.NotEqual(t, first, token)
+	})
+
+	t.Run("rejects an expired unlock token", func(t *testing.T) {
+		service, _, now := newTestService()
+
+		for i := 0; i < 3; i++ {
+			_ = service.RecordFailure(ctx, "user@weeb.vip", "")
+		}
+
+		token, _ := service.RequestUnlock(ctx, "user@weeb.vip")
+		*now = now.Add(time.Hour)
+
+		var lockoutErr *Error
+		assert.True(t, errors.As(service.Unlock(ctx, token), &lockoutErr))
+		assert.Equal(t, LockoutErrorInvalidUnlockToken, lockoutErr.Code)
+	})
+
+	t.Run("locks the second factor of a user apart from sign in", func(t *testing.T) {
+		service, repository, now := newTestService()
+
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, service.RecordMFAFailure(ctx, "user_123"), i)
+		}
+
+		assertLocked(t, service.RecordMFAFailure(ctx, "user_123"), now.Add(time.Minute))
+		assertLocked(t, service.CheckMFA(ctx, "user_123"), now.Add(time.Minute))
+		assert.NoError(t, service.CheckMFA(ctx, "user_456"))
+		assert.NoError(t, service.Check(ctx, "user_123", ""))
+
+		*now = now.Add(time.Minute)
+		assert.NoError(t, service.CheckMFA(ctx, "user_123"))
+		assertLocked(t, service.RecordMFAFailure(ctx, "user_123"), now.Add(2*time.Minute))
+
+		assert.NoError(t, service.RecordMFASuccess(ctx, "user_123"))
+		assert.NotContains(t, repository.failures[models.MFAScope], "user_123")
+	})
+}
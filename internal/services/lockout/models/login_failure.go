@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weeb-vip/auth/internal/db"
+)
+
+const (
+	UsernameScope LoginFailureScope = "username"
+	IPScope       LoginFailureScope = "ip"
+	MFAScope      LoginFailureScope = "mfa" // the subject is a user ID.
+)
+
+type LoginFailureScope string
+
+// LoginFailure counts the recent failed sign ins of one username or one source IP, or the wrong second
+// factor codes of one user.
+type LoginFailure struct {
+	db.BaseModel
+	Scope                LoginFailureScope `json:"scope"`
+	Subject              string            `json:"subject"` // the lowercased username, the IP or the user ID.
+	Failures             int               `json:"failures"`
+	LastFailedAt         *time.Time        `json:"lastFailedAt"`
+	LockedUntil          *time.Time        `json:"lockedUntil"`
+	UnlockToken          string            `json:"-" gorm:"-"` // the raw token, only known when it is issued.
+	UnlockTokenHash      *string           `json:"-" gorm:"column:unlock_token"`
+	UnlockTokenExpiresAt *time.Time        `json:"-"`
+}
+
+func (failure *LoginFailure) IsLocked(now time.Time) bool {
+	return failure.LockedUntil != nil && now.Before(*failure.LockedUntil)
+}
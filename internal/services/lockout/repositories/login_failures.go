@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/weeb-vip/auth/internal/db"
+	"github.com/weeb-vip/auth/internal/services/lockout/models"
+	"github.com/weeb-vip/auth/internal/tokenhash"
+)
+
+type LoginFailuresRepository interface {
+	GetLoginFailure(scope models.LoginFailureScope, subject string) (*models.LoginFailure, error)
+	UpdateLoginFailure(
+		scope models.LoginFailureScope,
+		subject string,
+		update func(failure *models.LoginFailure),
+	) (*models.LoginFailure, error)
+	DeleteLoginFailure(scope models.LoginFailureScope, subject string) error
+	SetUnlockToken(id string, token string, expiresAt time.Time) error
+	GetLoginFailureByUnlockToken(token string) (*models.LoginFailure, error)
+	Unlock(id string) (bool, error)
+}
+
+type loginFailuresRepository struct {
+	DBService   db.DB
+	tokenHasher tokenhash.Hasher
+}
+
+func NewLoginFailuresRepository(tokenHasher tokenhash.Hasher) LoginFailuresRepository {
+	dbService := db.GetDBService()
+
+	return &loginFailuresRepository{
+		DBService:   dbService,
+		tokenHasher: tokenHasher,
+	}
+}
+
+// GetLoginFailure returns nil when the subject has no recorded failures.
+func (repository *loginFailuresRepository) GetLoginFailure(
+	scope models.LoginFailureScope,
+	subject string,
+) (*models.LoginFailure, error) {
+	db := repository.DBService.GetDB()
+
+	var failure models.LoginFailure
+
+	err := db.Where("scope = ? AND subject = ?", scope, subject).First(&failure).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &failure, nil
+}
+
+// UpdateLoginFailure applies update to the row of the subject, creating it first, while holding a row lock
+// so concurrent failures are all counted.
+func (repository *loginFailuresRepository) UpdateLoginFailure(
+	scope models.LoginFailureScope,
+	subject string,
+	update func(failure *models.LoginFailure),
+) (*models.LoginFailure, error) {
+	db := repository.DBService.GetDB()
+
+	var failure models.LoginFailure
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.LoginFailure{Scope: scope, Subject: subject}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("scope = ? AND subject = ?", scope, subject).
+			First(&failure).Error
+		if err != nil {
+			return err
+		}
+
+		update(&failure)
+
+		return tx.Save(&failure).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &failure, nil
+}
+
+func (repository *loginFailuresRepository) DeleteLoginFailure(scope models.LoginFailureScope, subject string) error {
+	db := repository.DBService.GetDB()
+
+	return db.Where("scope = ? AND subject = ?", scope, subject).Delete(&models.LoginFailure{}).Error
+}
+
+// SetUnlockToken replaces any unlock token issued before.
+func (repository *loginFailuresRepository) SetUnlockToken(id string, token string, expiresAt time.Time) error {
+	db := repository.DBService.GetDB()
+
+	return db.Model(&models.LoginFailure{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"unlock_token":            repository.tokenHasher.Hash(token),
+			"unlock_token_expires_at": expiresAt,
+		}).Error
+}
+
+// GetLoginFailureByUnlockToken returns nil when the token does not exist.
+func (repository *loginFailuresRepository) GetLoginFailureByUnlockToken(token string) (*models.LoginFailure, error) {
+	db := repository.DBService.GetDB()
+
+	var failure models.LoginFailure
+
+	err := db.Where("unlock_token = ?", repository.tokenHasher.Hash(token)).First(&failure).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	failure.UnlockToken = token
+
+	return &failure, nil
+}
+
+// Unlock clears the failures and consumes the unlock token, it reports false when the token was already used.
+func (repository *loginFailuresRepository) Unlock(id string) (bool, error) {
+	db := repository.DBService.GetDB()
+
+	result := db.Model(&models.LoginFailure{}).
+		Where("id = ? AND unlock_token IS NOT NULL", id).
+		Updates(map[string]interface{}{
+			"failures":                0,
+			"locked_until":            nil,
+			"unlock_token":            nil,
+			"unlock_token_expires_at": nil,
+		})
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,23 @@
+package mfa
+
+const (
+	MFAErrorInternalError  ErrorCode = "INTERNAL_ERROR"      // nolint
+	MFAErrorNotEnrolled    ErrorCode = "MFA_NOT_ENROLLED"    // nolint
+	MFAErrorAlreadyEnabled ErrorCode = "MFA_ALREADY_ENABLED" // nolint
+	MFAErrorInvalidCode    ErrorCode = "INVALID_MFA_CODE"    // nolint
+)
+
+type ErrorCode string
+
+type Error struct {
+	Code    ErrorCode
+	Message string
+}
+
+func (c ErrorCode) String() string {
+	return string(c)
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
@@ -0,0 +1,31 @@
+package mfa
+
+import (
+	"context"
+)
+
+type Enrollment struct {
+	Secret string
+	URI    string
+}
+
+// VerificationMethod reports which kind of code satisfied a second factor check.
+type VerificationMethod string
+
+const (
+	VerificationMethodTOTP         VerificationMethod = "totp"
+	VerificationMethodRecoveryCode VerificationMethod = "recovery_code"
+)
+
+type MFA interface {
+	Enroll(ctx context.Context, userID string, accountName string) (*Enrollment, error)
+	// ConfirmEnrollment enables mfa and returns the initial set of recovery codes.
+	ConfirmEnrollment(ctx context.Context, userID string, code string) ([]string, error)
+	// Disable removes the secret and recovery codes, callers must verify a code first.
+	Disable(ctx context.Context, userID string) error
+	IsEnabled(ctx context.Context, userID string) (bool, error)
+	// VerifyCode accepts either a TOTP code or an unused recovery code.
+	VerifyCode(ctx context.Context, userID string, code string) (VerificationMethod, error)
+	RegenerateRecoveryCodes(ctx context.Context, userID string) ([]string, error)
+	CountRecoveryCodes(ctx context.Context, userID string) (int, error)
+}
@@ -0,0 +1,198 @@
+package mfa
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/encryption"
+	"github.com/weeb-vip/auth/internal/services/mfa/models"
+	"github.com/weeb-vip/auth/internal/services/mfa/repositories"
+	"github.com/weeb-vip/auth/internal/totp"
+)
+
+type mfaService struct {
+	totpSecretsRepository   repositories.TOTPSecretsRepository
+	recoveryCodesRepository repositories.RecoveryCodesRepository
+	cipher                  encryption.Cipher
+	config                  config.MFAConfig
+	now                     func() time.Time
+}
+
+func NewMFAService(cfg config.MFAConfig) MFA {
+	cipher, err := encryption.New(cfg.EncryptionKey)
+	if err != nil {
+		panic(fmt.Errorf("failed to create mfa cipher: %w", err))
+	}
+
+	return &mfaService{
+		totpSecretsRepository:   repositories.GetTOTPSecretsRepository(),
+		recoveryCodesRepository: repositories.GetRecoveryCodesRepository(),
+		cipher:                  cipher,
+		config:                  cfg,
+		now:                     time.Now,
+	}
+}
+
+func (service *mfaService) Enroll(ctx context.Context, userID string, accountName string) (*Enrollment, error) {
+	existing, err := service.totpSecretsRepository.GetSecret(userID)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	if existing != nil && existing.Enabled {
+		return nil, &Error{
+			Code:    MFAErrorAlreadyEnabled,
+			Message: "multi-factor authentication is already enabled",
+		}
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	encryptedSecret, err := service.cipher.Encrypt(secret)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	_, err = service.totpSecretsRepository.SaveSecret(userID, encryptedSecret)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	return &Enrollment{
+		Secret: secret,
+		URI:    totp.URI(service.config.Issuer, accountName, secret),
+	}, nil
+}
+
+func (service *mfaService) ConfirmEnrollment(ctx context.Context, userID string, code string) ([]string, error) {
+	secret, err := service.getSecret(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if secret.Enabled {
+		return nil, &Error{
+			Code:    MFAErrorAlreadyEnabled,
+			Message: "multi-factor authentication is already enabled",
+		}
+	}
+
+	err = service.verify(secret, code)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := service.generateRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.totpSecretsRepository.EnableSecret(userID)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	return recoveryCodes, nil
+}
+
+func (service *mfaService) Disable(ctx context.Context, userID string) error {
+	err := service.totpSecretsRepository.DeleteSecret(userID)
+	if err != nil {
+		return internalError(err)
+	}
+
+	err = service.recoveryCodesRepository.DeleteCodes(userID)
+	if err != nil {
+		return internalError(err)
+	}
+
+	return nil
+}
+
+func (service *mfaService) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	secret, err := service.totpSecretsRepository.GetSecret(userID)
+	if err != nil {
+		return false, internalError(err)
+	}
+
+	return secret != nil && secret.Enabled, nil
+}
+
+func (service *mfaService) VerifyCode(ctx context.Context, userID string, code string) (VerificationMethod, error) {
+	secret, err := service.getSecret(userID)
+	if err != nil {
+		return "", err
+	}
+
+	if !secret.Enabled {
+		return "", &Error{
+			Code:    MFAErrorNotEnrolled,
+			Message: "multi-factor authentication is not enabled",
+		}
+	}
+
+	if isTOTPCode(code) {
+		return VerificationMethodTOTP, service.verify(secret, code)
+	}
+
+	return VerificationMethodRecoveryCode, service.verifyRecoveryCode(userID, code)
+}
+
+func (service *mfaService) getSecret(userID string) (*models.TOTPSecret, error) {
+	secret, err := service.totpSecretsRepository.GetSecret(userID)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	if secret == nil {
+		return nil, &Error{
+			Code:    MFAErrorNotEnrolled,
+			Message: "multi-factor authentication is not enrolled",
+		}
+	}
+
+	return secret, nil
+}
+
+func (service *mfaService) verify(secret *models.TOTPSecret, code string) error {
+	plainSecret, err := service.cipher.Decrypt(secret.Secret)
+	if err != nil {
+		return internalError(err)
+	}
+
+	step, ok := totp.Validate(plainSecret, code, service.now())
+	if !ok {
+		return invalidCodeError()
+	}
+
+	// A code may only be used once, this also prevents replaying an older code after a newer one was accepted.
+	marked, err := service.totpSecretsRepository.MarkStepUsed(secret.UserID, step)
+	if err != nil {
+		return internalError(err)
+	}
+
+	if !marked {
+		return invalidCodeError()
+	}
+
+	return nil
+}
+
+func internalError(err error) error {
+	return &Error{
+		Code:    MFAErrorInternalError,
+		Message: err.Error(),
+	}
+}
+
+func invalidCodeError() error {
+	return &Error{
+		Code:    MFAErrorInvalidCode,
+		Message: "invalid verification code",
+	}
+}
@@ -0,0 +1,321 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/encryption"
+	"github.com/weeb-vip/auth/internal/services/mfa/models"
+	"github.com/weeb-vip/auth/internal/totp"
+)
+
+type fakeTOTPSecretsRepository struct {
+	secrets map[string]*models.TOTPSecret
+}
+
+func (f *fakeTOTPSecretsRepository) SaveSecret(userID string, encryptedSecret string) (*models.TOTPSecret, error) {
+	secret := &models.TOTPSecret{UserID: userID, Secret: encryptedSecret}
+	f.secrets[userID] = secret
+
+	return secret, nil
+}
+
+func (f *fakeTOTPSecretsRepository) GetSecret(userID string) (*models.TOTPSecret, error) {
+	return f.secrets[userID], nil
+}
+
+func (f *fakeTOTPSecretsRepository) EnableSecret(userID string) error {
+	f.secrets[userID].Enabled = true
+
+	return nil
+}
+
+func (f *fakeTOTPSecretsRepository) MarkStepUsed(userID string, step int64) (bool, error) {
+	secret := f.secrets[userID]
+	if secret.LastUsedStep >= step {
+		return false, nil
+	}
+
+	secret.LastUsedStep = step
+
+	return true, nil
+}
+
+func (f *fakeTOTPSecretsRepository) DeleteSecret(userID string) error {
+	delete(f.secrets, userID)
+
+	return nil
+}
+
+type fakeRecoveryCodesRepository struct {
+	codes []models.RecoveryCode
+}
+
+func (f *fakeRecoveryCodesRepository) ReplaceCodes(userID string, codeHashes []string) error {
+	_ = f.DeleteCodes(userID)
+
+	for i, codeHash := range codeHashes {
+		code := models.RecoveryCode{UserID: userID, CodeHash: codeHash}
+		code.ID = fmt.Sprintf("%s_%d", userID, i)
+		f.codes = append(f.codes, code)
+	}
+
+	return nil
+}
+
+func (f *fakeRecoveryCodesRepository) GetUnusedCodes(userID string) ([]models.RecoveryCode, error) {
+	var codes []models.RecoveryCode
+
+	for _, code := range f.codes {
+		if code.UserID == userID && code.UsedAt == nil {
+			codes = append(codes, code)
+		}
+	}
+
+	return codes, nil
+}
+
+func (f *fakeRecoveryCodesRepository) MarkUsed(id string) (bool, error) {
+	for i := range f.codes {
+		if f.codes[i].ID == id && f.codes[i].UsedAt == nil {
+			usedAt := time.Now()
+			f.codes[i].UsedAt = &usedAt
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (f *fakeRecoveryCodesRepository) CountUnused(userID string) (int64, error) {
+	codes, _ := f.GetUnusedCodes(userID)
+
+	return int64(len(codes)), nil
+}
+
+func (f *fakeRecoveryCodesRepository) DeleteCodes(userID string) error {
+	var codes []models.RecoveryCode
+
+	for _, code := range f.codes {
+		if code.UserID != userID {
+			codes = append(codes, code)
+		}
+	}
+
+	f.codes = codes
+
+	return nil
+}
+
+func newTestService(now *time.Time) (*mfaService, *fakeTOTPSecretsRepository) {
+	cipher, _ := encryption.New("test-key")
+	repository := &fakeTOTPSecretsRepository{secrets: map[string]*models.TOTPSecret{}}
+
+	return &mfaService{
+		totpSecretsRepository:   repository,
+		recoveryCodesRepository: &fakeRecoveryCodesRepository{},
+		cipher:                  cipher,
+		config:                  config.MFAConfig{Issuer: "WEEB VIP"},
+		now:                     func() time.Time { return *now },
+	}, repository
+}
+
+// enableMFA enrolls and confirms mfa for the user, returning the TOTP secret and recovery codes.
+func enableMFA(t *testing.T, service *mfaService, now time.Time) (string, []string) {
+	enrollment, err := service.Enroll(context.TODO(), "user_1", "user@weeb.vip")
+	assert.NoError(t, err)
+
+	code, _ := totp.Code(enrollment.Secret, now)
+	recoveryCodes, err := service.ConfirmEnrollment(context.TODO(), "user_1", code)
+	assert.NoError(t, err)
+
+	return enrollment.Secret, recoveryCodes
+}
+
+func errorCode(err error) ErrorCode {
+	var mfaErr *Error
+	if errors.As(err, &mfaErr) {
+		return mfaErr.Code
+	}
+
+	return ""
+}
+
+func TestMFAService_Enroll(t *testing.T) {
+	t.Run("returns a secret and otpauth uri and stores the secret encrypted", func(t *testing.T) {
+		a := assert.New(t)
+		now := time.Now()
+		service, repository := newTestService(&now)
+
+		enrollment, err := service.Enroll(context.TODO(), "user_1", "user@weeb.vip")
+		a.NoError(err)
+		a.NotEmpty(enrollment.Secret)
+		a.True(strings.HasPrefix(enrollment.URI, "otpauth://totp/"))
+		a.NotEqual(enrollment.Secret, repository.secrets["user_1"].Secret)
+		a.False(repository.secrets["user_1"].Enabled)
+	})
+
+	t.Run("fails when mfa is already enabled", func(t *testing.T) {
+		a := assert.New(t)
+		now := time.Now()
+		service, _ := newTestService(&now)
+
+		enableMFA(t, service, now)
+
+		_, err := service.Enroll(context.TODO(), "user_1", "user@weeb.vip")
+		a.Equal(MFAErrorAlreadyEnabled, errorCode(err))
+	})
+}
+
+func TestMFAService_ConfirmEnrollment(t *testing.T) {
+	t.Run("enables mfa with a valid code and returns recovery codes", func(t *testing.T) {
+		a := assert.New(t)
+		now := time.Now()
+		service, _ := newTestService(&now)
+
+		_, recoveryCodes := enableMFA(t, service, now)
+		a.Len(recoveryCodes, RecoveryCodeCount)
+		a.Regexp(`^[a-z2-9]{5}-[a-z2-9]{5}$`, recoveryCodes[0])
+
+		enabled, err := service.IsEnabled(context.TODO(), "user_1")
+		a.NoError(err)
+		a.True(enabled)
+
+		count, err := service.CountRecoveryCodes(context.TODO(), "user_1")
+		a.NoError(err)
+		a.Equal(RecoveryCodeCount, count)
+	})
+
+	t.Run("rejects an invalid code", func(t *testing.T) {
+		a := assert.New(t)
+		now := time.Now()
+		service, _ := newTestService(&now)
+
+		_, _ = service.Enroll(context.TODO(), "user_1", "user@weeb.vip")
+
+		_, err := service.ConfirmEnrollment(context.TODO(), "user_1", "000000")
+		a.Equal(MFAErrorInvalidCode, errorCode(err))
+
+		enabled, _ := service.IsEnabled(context.TODO(), "user_1")
+		a.False(enabled)
+	})
+
+	t.Run("fails when not enrolled", func(t *testing.T) {
+		now := time.Now()
+		service, _ := newTestService(&now)
+
+		_, err := service.ConfirmEnrollment(context.TODO(), "user_1", "000000")
+		assert.Equal(t, MFAErrorNotEnrolled, errorCode(err))
+	})
+}
+
+func TestMFAService_VerifyCode(t *testing.T) {
+	t.Run("accepts a code only once", func(t *testing.T) {
+		a := assert.New(t)
+		now := time.Now()
+		service, _ := newTestService(&now)
+
+		secret, _ := enableMFA(t, service, now)
+
+		now = now.Add(totp.Period)
+		code, _ := totp.Code(secret, now)
+		method, err := service.VerifyCode(context.TODO(), "user_1", code)
+		a.NoError(err)
+		a.Equal(VerificationMethodTOTP, method)
+
+		_, err = service.VerifyCode(context.TODO(), "user_1", code)
+		a.Equal(MFAErrorInvalidCode, errorCode(err))
+	})
+
+	t.Run("accepts a recovery code only once", func(t *testing.T) {
+		a := assert.New(t)
+		now := time.Now()
+		service, _ := newTestService(&now)
+
+		_, recoveryCodes := enableMFA(t, service, now)
+
+		method, err := service.VerifyCode(context.TODO(), "user_1", strings.ToUpper(recoveryCodes[3]))
+		a.NoError(err)
+		a.Equal(VerificationMethodRecoveryCode, method)
+
+		_, err = service.VerifyCode(context.TODO(), "user_1", recoveryCodes[3])
+		a.Equal(MFAErrorInvalidCode, errorCode(err))
+
+		count, _ := service.CountRecoveryCodes(context.TODO(), "user_1")
+		a.Equal(RecoveryCodeCount-1, count)
+	})
+
+	t.Run("rejects an unknown recovery code", func(t *testing.T) {
+		now := time.Now()
+		service, _ := newTestService(&now)
+
+		enableMFA(t, service, now)
+
+		_, err := service.VerifyCode(context.TODO(), "user_1", "aaaaa-aaaaa")
+		assert.Equal(t, MFAErrorInvalidCode, errorCode(err))
+	})
+
+	t.Run("fails when enrollment was never confirmed", func(t *testing.T) {
+		now := time.Now()
+		service, _ := newTestService(&now)
+
+		enrollment, _ := service.Enroll(context.TODO(), "user_1", "user@weeb.vip")
+		code, _ := totp.Code(enrollment.Secret, now)
+
+		_, err := service.VerifyCode(context.TODO(), "user_1", code)
+		assert.Equal(t, MFAErrorNotEnrolled, errorCode(err))
+	})
+}
+
+func TestMFAService_Disable(t *testing.T) {
+	t.Run("removes the secret and recovery codes", func(t *testing.T) {
+		a := assert.New(t)
+		now := time.Now()
+		service, repository := newTestService(&now)
+
+		enableMFA(t, service, now)
+
+		a.NoError(service.Disable(context.TODO(), "user_1"))
+		a.Nil(repository.secrets["user_1"])
+
+		count, _ := service.CountRecoveryCodes(context.TODO(), "user_1")
+		a.Zero(count)
+	})
+}
+
+func TestMFAService_RegenerateRecoveryCodes(t *testing.T) {
+	t.Run("replaces every previous code", func(t *testing.T) {
+		a := assert.New(t)
+		now := time.Now()
+		service, _ := newTestService(&now)
+
+		_, oldCodes := enableMFA(t, service, now)
+		_, _ = service.VerifyCode(context.TODO(), "user_1", oldCodes[0])
+
+		newCodes, err := service.RegenerateRecoveryCodes(context.TODO(), "user_1")
+		a.NoError(err)
+		a.Len(newCodes, RecoveryCodeCount)
+
+		count, _ := service.CountRecoveryCodes(context.TODO(), "user_1")
+		a.Equal(RecoveryCodeCount, count)
+
+		_, err = service.VerifyCode(context.TODO(), "user_1", oldCodes[1])
+		a.Equal(MFAErrorInvalidCode, errorCode(err))
+	})
+
+	t.Run("fails when mfa is not enabled", func(t *testing.T) {
+		now := time.Now()
+		service, _ := newTestService(&now)
+
+		_, err := service.RegenerateRecoveryCodes(context.TODO(), "user_1")
+		assert.Equal(t, MFAErrorNotEnrolled, errorCode(err))
+	})
+}
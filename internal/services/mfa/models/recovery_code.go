@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weeb-vip/auth/internal/db"
+)
+
+type RecoveryCode struct {
+	db.BaseModel
+	UserID   string     `column:"user_id"`
+	CodeHash string     `column:"code_hash"`
+	UsedAt   *time.Time `column:"used_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"github.com/weeb-vip/auth/internal/db"
+)
+
+type TOTPSecret struct {
+	db.BaseModel
+	UserID       string `column:"user_id"`
+	Secret       string `column:"secret"` // encrypted, never stored in plain text.
+	Enabled      bool   `column:"enabled"`
+	LastUsedStep int64  `column:"last_used_step"`
+}
@@ -0,0 +1,142 @@
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"strings"
+
+	"github.com/weeb-vip/auth/internal/services/credential"
+)
+
+const (
+	RecoveryCodeCount  = 10
+	recoveryCodeLength = 10
+	// Recovery codes carry ~50 bits of entropy, so a low bcrypt cost is enough and keeps verification fast.
+	recoveryCodeHashCost = credential.MinCost
+	// Ambiguous characters (0/o, 1/l/i) are left out so codes are easy to copy from paper.
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+func (service *mfaService) RegenerateRecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+	enabled, err := service.IsEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !enabled {
+		return nil, &Error{
+			Code:    MFAErrorNotEnrolled,
+			Message: "multi-factor authentication is not enabled",
+		}
+	}
+
+	return service.generateRecoveryCodes(userID)
+}
+
+func (service *mfaService) CountRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	count, err := service.recoveryCodesRepository.CountUnused(userID)
+	if err != nil {
+		return 0, internalError(err)
+	}
+
+	return int(count), nil
+}
+
+func (service *mfaService) generateRecoveryCodes(userID string) ([]string, error) {
+	codes := make([]string, 0, RecoveryCodeCount)
+	codeHashes := make([]string, 0, RecoveryCodeCount)
+
+	for i := 0; i < RecoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, internalError(err)
+		}
+
+		codeHash, err := credential.HashSecret(normalizeRecoveryCode(code), recoveryCodeHashCost)
+		if err != nil {
+			return nil, internalError(err)
+		}
+
+		codes = append(codes, code)
+		codeHashes = append(codeHashes, codeHash)
+	}
+
+	err := service.recoveryCodesRepository.ReplaceCodes(userID, codeHashes)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	return codes, nil
+}
+
+func (service *mfaService) verifyRecoveryCode(userID string, code string) error {
+	normalized := normalizeRecoveryCode(code)
+
+	codes, err := service.recoveryCodesRepository.GetUnusedCodes(userID)
+	if err != nil {
+		return internalError(err)
+	}
+
+	for _, recoveryCode := range codes {
+		if !credential.VerifySecret(normalized, recoveryCode.CodeHash) {
+			continue
+		}
+
+		used, err := service.recoveryCodesRepository.MarkUsed(recoveryCode.ID)
+		if err != nil {
+			return internalError(err)
+		}
+
+		if !used {
+			break
+		}
+
+		return nil
+	}
+
+	return invalidCodeError()
+}
+
+// newRecoveryCode returns a code formatted as two dash separated groups, e.g. "abcde-fghjk".
+func newRecoveryCode() (string, error) {
+	var builder strings.Builder
+
+	alphabetSize := big.NewInt(int64(len(recoveryCodeAlphabet)))
+
+	for i := 0; i < recoveryCodeLength; i++ {
+		if i == recoveryCodeLength/2 {
+			builder.WriteByte('-')
+		}
+
+		index, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", err
+		}
+
+		builder.WriteByte(recoveryCodeAlphabet[index.Int64()])
+	}
+
+	return builder.String(), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+
+	return strings.ReplaceAll(code, " ", "")
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != 6 { // nolint
+		return false
+	}
+
+	for _, char := range code {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+
+	return true
+}
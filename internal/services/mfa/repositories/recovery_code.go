@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/weeb-vip/auth/internal/db"
+	"github.com/weeb-vip/auth/internal/services/mfa/models"
+)
+
+type RecoveryCodesRepository interface {
+	ReplaceCodes(userID string, codeHashes []string) error
+	GetUnusedCodes(userID string) ([]models.RecoveryCode, error)
+	MarkUsed(id string) (bool, error)
+	CountUnused(userID string) (int64, error)
+	DeleteCodes(userID string) error
+}
+
+type recoveryCodesRepository struct {
+	DBService db.DB
+}
+
+var recoveryCodesRepositorySingleton RecoveryCodesRepository // nolint
+
+func NewRecoveryCodesRepository() RecoveryCodesRepository {
+	dbService := db.GetDBService()
+
+	return &recoveryCodesRepository{
+		DBService: dbService,
+	}
+}
+
+// ReplaceCodes removes every existing code of the user and stores the new set.
+func (repository *recoveryCodesRepository) ReplaceCodes(userID string, codeHashes []string) error {
+	database := repository.DBService.GetDB()
+
+	codes := make([]models.RecoveryCode, 0, len(codeHashes))
+	for _, codeHash := range codeHashes {
+		codes = append(codes, models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: codeHash,
+		})
+	}
+
+	return database.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+		if err != nil {
+			return err
+		}
+
+		if len(codes) == 0 {
+			return nil
+		}
+
+		return tx.Create(&codes).Error
+	})
+}
+
+func (repository *recoveryCodesRepository) GetUnusedCodes(userID string) ([]models.RecoveryCode, error) {
+	database := repository.DBService.GetDB()
+
+	var codes []models.RecoveryCode
+
+	err := database.Where("user_id = ? AND used_at IS NULL", userID).Find(&codes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// MarkUsed consumes the code, returning false if it was already consumed by a concurrent request.
+func (repository *recoveryCodesRepository) MarkUsed(id string) (bool, error) {
+	database := repository.DBService.GetDB()
+
+	result := database.Model(&models.RecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (repository *recoveryCodesRepository) CountUnused(userID string) (int64, error) {
+	database := repository.DBService.GetDB()
+
+	var count int64
+
+	err := database.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+
+	return count, err
+}
+
+func (repository *recoveryCodesRepository) DeleteCodes(userID string) error {
+	database := repository.DBService.GetDB()
+
+	return database.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
+
+func GetRecoveryCodesRepository() RecoveryCodesRepository {
+	if recoveryCodesRepositorySingleton == nil {
+		recoveryCodesRepositorySingleton = NewRecoveryCodesRepository()
+	}
+
+	return recoveryCodesRepositorySingleton
+}
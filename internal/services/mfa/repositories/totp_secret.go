@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/weeb-vip/auth/internal/db"
+	"github.com/weeb-vip/auth/internal/services/mfa/models"
+)
+
+type TOTPSecretsRepository interface {
+	SaveSecret(userID string, encryptedSecret string) (*models.TOTPSecret, error)
+	GetSecret(userID string) (*models.TOTPSecret, error)
+	EnableSecret(userID string) error
+	MarkStepUsed(userID string, step int64) (bool, error)
+	DeleteSecret(userID string) error
+}
+
+type totpSecretsRepository struct {
+	DBService db.DB
+}
+
+var totpSecretsRepositorySingleton TOTPSecretsRepository // nolint
+
+func NewTOTPSecretsRepository() TOTPSecretsRepository {
+	dbService := db.GetDBService()
+
+	return &totpSecretsRepository{
+		DBService: dbService,
+	}
+}
+
+// SaveSecret stores a new, not yet enabled, secret for the user replacing any pending enrollment.
+func (repository *totpSecretsRepository) SaveSecret(userID string, encryptedSecret string) (*models.TOTPSecret, error) {
+	database := repository.DBService.GetDB()
+
+	secret := models.TOTPSecret{
+		UserID: userID,
+		Secret: encryptedSecret,
+	}
+
+	err := database.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? AND enabled = ?", userID, false).Delete(&models.TOTPSecret{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&secret).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &secret, nil
+}
+
+func (repository *totpSecretsRepository) GetSecret(userID string) (*models.TOTPSecret, error) {
+	database := repository.DBService.GetDB()
+
+	var secret models.TOTPSecret
+
+	err := database.Where("user_id = ?", userID).First(&secret).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &secret, nil
+}
+
+func (repository *totpSecretsRepository) EnableSecret(userID string) error {
+	database := repository.DBService.GetDB()
+
+	return database.Model(&models.TOTPSecret{}).
+		Where("user_id = ?", userID).
+		Update("enabled", true).Error
+}
+
+// MarkStepUsed records the time step of an accepted code, failing if the same or a later step was already used.
+func (repository *totpSecretsRepository) MarkStepUsed(userID string, step int64) (bool, error) {
+	database := repository.DBService.GetDB()
+
+	result := database.Model(&models.TOTPSecret{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (repository *totpSecretsRepository) DeleteSecret(userID string) error {
+	database := repository.DBService.GetDB()
+
+	return database.Where("user_id = ?", userID).Delete(&models.TOTPSecret{}).Error
+}
+
+func GetTOTPSecretsRepository() TOTPSecretsRepository {
+	if totpSecretsRepositorySingleton == nil {
+		totpSecretsRepositorySingleton = NewTOTPSecretsRepository()
+	}
+
+	return totpSecretsRepositorySingleton
+}
@@ -22,4 +22,33 @@ func TestMJML(t *testing.T) {
 		a.NoError(err)
 		a.NotEmpty(result)
 	})
+
+	t.Run("should render the password changed notification", func(t *testing.T) {
+		t.Parallel()
+		a := assert.New(t)
+		mjmlService := mjml.NewMJMLService()
+
+		result, err := mjmlService.GenerateHTMLFromMJML(context.Background(), "password-changed.mjml", map[string]string{
+			"name":       "John Doe",
+			"changed_at": "18 Oct 2026 12:00 UTC",
+		})
+
+		a.NoError(err)
+		a.Contains(*result, "John Doe")
+		a.Contains(*result, "18 Oct 2026 12:00 UTC")
+	})
+	t.Run("should render the unlock account email", func(t *testing.T) {
+		t.Parallel()
+		a := assert.New(t)
+		mjmlService := mjml.NewMJMLService()
+
+		result, err := mjmlService.GenerateHTMLFromMJML(context.Background(), "unlock-account.mjml", map[string]string{
+			"name":       "John Doe",
+			"unlock_url": "https://weeb.vip/auth/unlock?token=abc",
+		})
+
+		a.NoError(err)
+		a.Contains(*result, "John Doe")
+		a.Contains(*result, "https://weeb.vip/auth/unlock?token=abc")
+	})
 }
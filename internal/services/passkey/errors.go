@@ -0,0 +1,22 @@
+package passkey
+
+const (
+	PasskeyErrorInternalError      ErrorCode = "INTERNAL_ERROR"               // nolint
+	PasskeyErrorInvalidCeremony    ErrorCode = "INVALID_WEBAUTHN_CEREMONY"    // nolint
+	PasskeyErrorVerificationFailed ErrorCode = "WEBAUTHN_VERIFICATION_FAILED" // nolint
+)
+
+type ErrorCode string
+
+type Error struct {
+	Code    ErrorCode
+	Message string
+}
+
+func (c ErrorCode) String() string {
+	return string(c)
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
@@ -0,0 +1,28 @@
+package passkey
+
+import (
+	"context"
+
+	"github.com/weeb-vip/auth/internal/services/passkey/models"
+)
+
+// Ceremony is returned by the begin steps, Options is the JSON the client passes to
+// navigator.credentials.create() or navigator.credentials.get() and ID identifies the ceremony on finish.
+type Ceremony struct {
+	ID      string
+	Options string
+}
+
+type Passkey interface {
+	BeginRegistration(ctx context.Context, userID string, username string) (*Ceremony, error)
+	FinishRegistration(
+		ctx context.Context,
+		userID string,
+		ceremonyID string,
+		name string,
+		response string,
+	) (*models.WebauthnCredential, error)
+	BeginLogin(ctx context.Context) (*Ceremony, error)
+	// FinishLogin verifies the assertion and returns the ID of the user owning the passkey.
+	FinishLogin(ctx context.Context, ceremonyID string, response string) (string, error)
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weeb-vip/auth/internal/db"
+)
+
+type CeremonyKind string
+
+const (
+	RegistrationCeremony CeremonyKind = "registration"
+	LoginCeremony        CeremonyKind = "login"
+)
+
+// WebauthnCeremony holds the server side state of a registration or login between its begin and finish steps.
+type WebauthnCeremony struct {
+	db.BaseModel
+	UserID    string       `column:"user_id"`
+	Kind      CeremonyKind `column:"kind"`
+	Data      string       `column:"data"` // JSON encoded webauthn.SessionData.
+	ExpiresAt time.Time    `column:"expires_at"`
+}
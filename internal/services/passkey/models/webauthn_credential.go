@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/weeb-vip/auth/internal/db"
+)
+
+type WebauthnCredential struct {
+	db.BaseModel
+	UserID          string     `column:"user_id"`
+	CredentialID    string     `column:"credential_id"` // base64url encoded raw credential ID.
+	PublicKey       []byte     `column:"public_key"`    // COSE encoded public key.
+	AttestationType string     `column:"attestation_type"`
+	AAGUID          []byte     `column:"aaguid" gorm:"column:aaguid"`
+	SignCount       uint32     `column:"sign_count"`
+	CloneWarning    bool       `column:"clone_warning"`
+	Transports      string     `column:"transports"` // comma separated list of transports.
+	BackupEligible  bool       `column:"backup_eligible"`
+	BackupState     bool       `column:"backup_state"`
+	Name            string     `column:"name"`
+	LastUsedAt      *time.Time `column:"last_used_at"`
+}
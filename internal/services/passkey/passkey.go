@@ -0,0 +1,376 @@
+package passkey
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/services/passkey/models"
+	"github.com/weeb-vip/auth/internal/services/passkey/repositories"
+)
+
+const (
+	defaultCeremonyTTLInSec = 300
+	defaultPasskeyName      = "Passkey"
+	maxPasskeyNameLength    = 100
+)
+
+type passkeyService struct {
+	credentialsRepository repositories.WebauthnCredentialsRepository
+	ceremoniesRepository  repositories.WebauthnCeremoniesRepository
+	webAuthn              *webauthn.WebAuthn
+	config                config.WebAuthnConfig
+	now                   func() time.Time
+}
+
+func NewPasskeyService(cfg config.WebAuthnConfig) Passkey {
+	webAuthn, err := newWebAuthn(cfg)
+	if err != nil {
+		panic(fmt.Errorf("failed to create webauthn relying party: %w", err))
+	}
+
+	return &passkeyService{
+		credentialsRepository: repositories.GetWebauthnCredentialsRepository(),
+		ceremoniesRepository:  repositories.GetWebauthnCeremoniesRepository(),
+		webAuthn:              webAuthn,
+		config:                cfg,
+		now:                   time.Now,
+	}
+}
+
+// newWebAuthn configures the relying party to only accept discoverable credentials with user verification,
+// a passkey therefore proves both possession and the user, so no further factor is asked for on login.
+func newWebAuthn(cfg config.WebAuthnConfig) (*webauthn.WebAuthn, error) {
+	var origins []string
+
+	for _, origin := range strings.Split(cfg.RPOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+
+	return webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     origins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationRequired,
+		},
+	})
+}
+
+func (service *passkeyService) BeginRegistration(ctx context.Context, userID string, username string) (*Ceremony, error) {
+	user, err := service.getUser(userID, username)
+	if err != nil {
+		return nil, err
+	}
+
+	creation, sessionData, err := service.webAuthn.BeginRegistration(
+		user,
+		webauthn.WithExclusions(webauthn.Credentials(user.credentials).CredentialDescriptors()),
+	)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	return service.startCeremony(userID, models.RegistrationCeremony, creation, sessionData)
+}
+
+func (service *passkeyService) FinishRegistration(
+	ctx context.Context,
+	userID string,
+	ceremonyID string,
+	name string,
+	response string,
+) (*models.WebauthnCredential, error) {
+	sessionData, err := service.consumeCeremony(ceremonyID, models.RegistrationCeremony, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedResponse, err := protocol.ParseCredentialCreationResponseBytes([]byte(response))
+	if err != nil {
+		return nil, verificationFailedError(err)
+	}
+
+	user, err := service.getUser(userID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := service.webAuthn.CreateCredential(user, *sessionData, parsedResponse)
+	if err != nil {
+		return nil, verificationFailedError(err)
+	}
+
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	webauthnCredential := &models.WebauthnCredential{
+		UserID:          userID,
+		CredentialID:    base64.RawURLEncoding.EncodeToString(credential.ID),
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		Transports:      strings.Join(transports, ","),
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		Name:            passkeyName(name),
+	}
+
+	err = service.credentialsRepository.AddCredential(webauthnCredential)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	return webauthnCredential, nil
+}
+
+func (service *passkeyService) BeginLogin(ctx context.Context) (*Ceremony, error) {
+	assertion, sessionData, err := service.webAuthn.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	return service.startCeremony("", models.LoginCeremony, assertion, sessionData)
+}
+
+func (service *passkeyService) FinishLogin(ctx context.Context, ceremonyID string, response string) (string, error) {
+	sessionData, err := service.consumeCeremony(ceremonyID, models.LoginCeremony, "")
+	if err != nil {
+		return "", err
+	}
+
+	parsedResponse, err := protocol.ParseCredentialRequestResponseBytes([]byte(response))
+	if err != nil {
+		return "", verificationFailedError(err)
+	}
+
+	var owner *user
+
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		found, err := service.getUser(string(userHandle), "")
+		if err != nil {
+			return nil, err
+		}
+
+		owner = found
+
+		return found, nil
+	}
+
+	credential, err := service.webAuthn.ValidateDiscoverableLogin(handler, *sessionData, parsedResponse)
+	if err != nil {
+		return "", verificationFailedError(err)
+	}
+
+	// A signature counter that did not increase means the private key may have been cloned.
+	if credential.Authenticator.CloneWarning {
+		return "", verificationFailedError(fmt.Errorf("signature counter did not increase"))
+	}
+
+	stored := owner.find(credential.ID)
+	if stored == nil {
+		return "", verificationFailedError(fmt.Errorf("unknown credential"))
+	}
+
+	err = service.credentialsRepository.UpdateAfterLogin(
+		stored.ID,
+		credential.Authenticator.SignCount,
+		credential.Flags.BackupState,
+		service.now(),
+	)
+	if err != nil {
+		return "", internalError(err)
+	}
+
+	return owner.id, nil
+}
+
+func (service *passkeyService) startCeremony(
+	userID string,
+	kind models.CeremonyKind,
+	options interface{},
+	sessionData *webauthn.SessionData,
+) (*Ceremony, error) {
+	ttlInSeconds := service.config.CeremonyTTLInSeconds
+	if ttlInSeconds <= 0 {
+		ttlInSeconds = defaultCeremonyTTLInSec
+	}
+
+	data, err := json.Marshal(sessionData)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	ceremony := &models.WebauthnCeremony{
+		UserID:    userID,
+		Kind:      kind,
+		Data:      string(data),
+		ExpiresAt: service.now().Add(time.Duration(ttlInSeconds) * time.Second),
+	}
+
+	err = service.ceremoniesRepository.AddCeremony(ceremony)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	return &Ceremony{
+		ID:      ceremony.ID,
+		Options: string(optionsJSON),
+	}, nil
+}
+
+func (service *passkeyService) consumeCeremony(
+	ceremonyID string,
+	kind models.CeremonyKind,
+	userID string,
+) (*webauthn.SessionData, error) {
+	ceremony, err := service.ceremoniesRepository.ConsumeCeremony(ceremonyID, kind)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	if ceremony == nil || ceremony.UserID != userID || !service.now().Before(ceremony.ExpiresAt) {
+		return nil, &Error{
+			Code:    PasskeyErrorInvalidCeremony,
+			Message: "invalid or expired webauthn ceremony",
+		}
+	}
+
+	var sessionData webauthn.SessionData
+
+	err = json.Unmarshal([]byte(ceremony.Data), &sessionData)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	return &sessionData, nil
+}
+
+func (service *passkeyService) getUser(userID string, username string) (*user, error) {
+	stored, err := service.credentialsRepository.GetCredentialsByUserID(userID)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	credentials := make([]webauthn.Credential, 0, len(stored))
+
+	for _, credential := range stored {
+		credentialID, err := base64.RawURLEncoding.DecodeString(credential.CredentialID)
+		if err != nil {
+			return nil, internalError(err)
+		}
+
+		var transports []protocol.AuthenticatorTransport
+
+		for _, transport := range strings.Split(credential.Transports, ",") {
+			if transport != "" {
+				transports = append(transports, protocol.AuthenticatorTransport(transport))
+			}
+		}
+
+		credentials = append(credentials, webauthn.Credential{
+			ID:              credentialID,
+			PublicKey:       credential.PublicKey,
+			AttestationType: credential.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: credential.BackupEligible,
+				BackupState:    credential.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:       credential.AAGUID,
+				SignCount:    credential.SignCount,
+				CloneWarning: credential.CloneWarning,
+			},
+		})
+	}
+
+	return &user{
+		id:          userID,
+		name:        username,
+		credentials: credentials,
+		stored:      stored,
+	}, nil
+}
+
+func passkeyName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return defaultPasskeyName
+	}
+
+	if len([]rune(name)) > maxPasskeyNameLength {
+		return string([]rune(name)[:maxPasskeyNameLength])
+	}
+
+	return name
+}
+
+// user adapts a user and their stored passkeys to webauthn.User, the user handle is the user ID.
+type user struct {
+	id          string
+	name        string
+	credentials []webauthn.Credential
+	stored      []models.WebauthnCredential
+}
+
+func (u *user) WebAuthnID() []byte {
+	return []byte(u.id)
+}
+
+func (u *user) WebAuthnName() string {
+	return u.name
+}
+
+func (u *user) WebAuthnDisplayName() string {
+	return u.name
+}
+
+func (u *user) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+func (u *user) find(credentialID []byte) *models.WebauthnCredential {
+	for i, credential := range u.credentials {
+		if bytes.Equal(credential.ID, credentialID) {
+			return &u.stored[i]
+		}
+	}
+
+	return nil
+}
+
+func internalError(err error) error {
+	return &Error{
+		Code:    PasskeyErrorInternalError,
+		Message: err.Error(),
+	}
+}
+
+func verificationFailedError(err error) error {
+	return &Error{
+		Code:    PasskeyErrorVerificationFailed,
+		Message: fmt.Sprintf("webauthn verification failed: %s", err.Error()),
+	}
+}
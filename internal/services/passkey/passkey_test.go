@@ -0,0 +1,374 @@
+package passkey
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/services/passkey/models"
+)
+
+const testOrigin = "https://weeb.vip"
+
+type fakeWebauthnCredentialsRepository struct {
+	credentials []models.WebauthnCredential
+}
+
+func (f *fakeWebauthnCredentialsRepository) AddCredential(credential *models.WebauthnCredential) error {
+	credential.ID = fmt.Sprintf("webauthn_credential_%d", len(f.credentials))
+	f.credentials = append(f.credentials, *credential)
+
+	return nil
+}
+
+func (f *fakeWebauthnCredentialsRepository) GetCredentialsByUserID(userID string) ([]models.WebauthnCredential, error) {
+	var credentials []models.WebauthnCredential
+
+	for _, credential := range f.credentials {
+		if credential.UserID == userID {
+			credentials = append(credentials, credential)
+		}
+	}
+
+	return credentials, nil
+}
+
+func (f *fakeWebauthnCredentialsRepository) UpdateAfterLogin(id string, signCount uint32, backupState bool, usedAt time.Time) error {
+	for i := range f.credentials {
+		if f.credentials[i].ID == id {
+			f.credentials[i].SignCount = signCount
+			f.credentials[i].BackupState = backupState
+			f.credentials[i].LastUsedAt = &usedAt
+		}
+	}
+
+	return nil
+}
+
+type fakeWebauthnCeremoniesRepository struct {
+	ceremonies map[string]*models.WebauthnCeremony
+}
+
+func (f *fakeWebauthnCeremoniesRepository) AddCeremony(ceremony *models.WebauthnCeremony) error {
+	ceremony.ID = fmt.Sprintf("webauthn_ceremony_%d", len(f.ceremonies))
+	f.ceremonies[ceremony.ID] = ceremony
+
+	return nil
+}
+
+func (f *fakeWebauthnCeremoniesRepository) ConsumeCeremony(id string, kind models.CeremonyKind) (*models.WebauthnCeremony, error) {
+	ceremony, ok := f.ceremonies[id]
+	if !ok || ceremony.Kind != kind {
+		return nil, nil
+	}
+
+	delete(f.ceremonies, id)
+
+	return ceremony, nil
+}
+
+func newTestService(t *testing.T, now *time.Time) (*passkeyService, *fakeWebauthnCredentialsRepository) {
+	cfg := config.WebAuthnConfig{RPID: "weeb.vip", RPDisplayName: "WEEB VIP", RPOrigins: testOrigin}
+
+	webAuthn, err := newWebAuthn(cfg)
+	assert.NoError(t, err)
+
+	repository := &fakeWebauthnCredentialsRepository{}
+
+	return &passkeyService{
+		credentialsRepository: repository,
+		ceremoniesRepository:  &fakeWebauthnCeremoniesRepository{ceremonies: map[string]*models.WebauthnCeremony{}},
+		webAuthn:              webAuthn,
+		config:                cfg,
+		now:                   func() time.Time { return *now },
+	}, repository
+}
+
+// softAuthenticator is a software passkey implementing ES256 with "none" attestation.
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+	origin       string
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	credentialID := make([]byte, 16)
+	_, _ = rand.Read(credentialID)
+
+	return &softAuthenticator{key: key, credentialID: credentialID, origin: testOrigin}
+}
+
+type creationOptions struct {
+	PublicKey struct {
+		Challenge string `json:"challenge"`
+		RP        struct {
+			ID string `json:"id"`
+		} `json:"rp"`
+		User struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	} `json:"publicKey"`
+}
+
+type requestOptions struct {
+	PublicKey struct {
+		Challenge string `json:"challenge"`
+		RPID      string `json:"rpId"`
+	} `json:"publicKey"`
+}
+
+// create answers navigator.credentials.create() options with a registration response.
+func (a *softAuthenticator) create(t *testing.T, options string) string {
+	var parsed creationOptions
+	assert.NoError(t, json.Unmarshal([]byte(options), &parsed))
+
+	userHandle, err := base64.RawURLEncoding.DecodeString(parsed.PublicKey.User.ID)
+	assert.NoError(t, err)
+
+	a.userHandle = userHandle
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1, // P-256.
+		XCoord: a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	assert.NoError(t, err)
+
+	attestedCredentialData := make([]byte, 16) // zero AAGUID.
+	attestedCredentialData = binary.BigEndian.AppendUint16(attestedCredentialData, uint16(len(a.credentialID)))
+	attestedCredentialData = append(attestedCredentialData, a.credentialID...)
+	attestedCredentialData = append(attestedCredentialData, publicKey...)
+
+	authData := a.authenticatorData(parsed.PublicKey.RP.ID, 0x40) // attested credential data included.
+	authData = append(authData, attestedCredentialData...)
+
+	attestationObject, err := webauthncbor.Marshal(struct {
+		Format       string         `cbor:"fmt"`
+		AttStatement map[string]any `cbor:"attStmt"`
+		AuthData     []byte         `cbor:"authData"`
+	}{Format: "none", AttStatement: map[string]any{}, AuthData: authData})
+	assert.NoError(t, err)
+
+	return a.response(t, map[string]string{
+		"clientDataJSON":    a.clientData(t, "webauthn.create", parsed.PublicKey.Challenge),
+		"attestationObject": base64.RawURLEncoding.EncodeToString(attestationObject),
+	})
+}
+
+// get answers navigator.credentials.get() options with an assertion response.
+func (a *softAuthenticator) get(t *testing.T, options string) string {
+	var parsed requestOptions
+	assert.NoError(t, json.Unmarshal([]byte(options), &parsed))
+
+	a.signCount++
+
+	authData := a.authenticatorData(parsed.PublicKey.RPID, 0)
+	clientData := a.clientData(t, "webauthn.get", parsed.PublicKey.Challenge)
+
+	clientDataJSON, _ := base64.RawURLEncoding.DecodeString(clientData)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	assert.NoError(t, err)
+
+	return a.response(t, map[string]string{
+		"clientDataJSON":    clientData,
+		"authenticatorData": base64.RawURLEncoding.EncodeToString(authData),
+		"signature":         base64.RawURLEncoding.EncodeToString(signature),
+		"userHandle":        base64.RawURLEncoding.EncodeToString(a.userHandle),
+	})
+}
+
+func (a *softAuthenticator) authenticatorData(rpID string, extraFlags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+
+	authData := append([]byte{}, rpIDHash[:]...)
+	authData = append(authData, 0x01|0x04|extraFlags) // user present and user verified.
+
+	return binary.BigEndian.AppendUint32(authData, a.signCount)
+}
+
+func (a *softAuthenticator) clientData(t *testing.T, ceremonyType string, challenge string) string {
+	clientDataJSON, err := json.Marshal(map[string]string{
+		"type":      ceremonyType,
+		"challenge": challenge,
+		"origin":    a.origin,
+	})
+	assert.NoError(t, err)
+
+	return base64.RawURLEncoding.EncodeToString(clientDataJSON)
+}
+
+func (a *softAuthenticator) response(t *testing.T, response map[string]string) string {
+	id := base64.RawURLEncoding.EncodeToString(a.credentialID)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"id":       id,
+		"rawId":    id,
+		"type":     "public-key",
+		"response": response,
+	})
+	assert.NoError(t, err)
+
+	return string(body)
+}
+
+func errorCode(err error) ErrorCode {
+	var passkeyErr *Error
+	if errors.As(err, &passkeyErr) {
+		return passkeyErr.Code
+	}
+
+	return ""
+}
+
+// register runs a full registration ceremony for user_1 with the authenticator.
+func register(t *testing.T, service *passkeyService, authenticator *softAuthenticator) *models.WebauthnCredential {
+	ceremony, err := service.BeginRegistration(context.TODO(), "user_1", "user@weeb.vip")
+	assert.NoError(t, err)
+
+	credential, err := service.FinishRegistration(context.TODO(), "user_1", ceremony.ID, "Laptop", authenticator.create(t, ceremony.Options))
+	assert.NoError(t, err)
+
+	return credential
+}
+
+func TestPasskeyService_Registration(t *testing.T) {
+	t.Run("stores the passkey of a software authenticator", func(t *testing.T) {
+		a := assert.New(t)
+		now := time.Now()
+		service, repository := newTestService(t, &now)
+		authenticator := newSoftAuthenticator(t)
+
+		credential := register(t, service, authenticator)
+		a.Equal("Laptop", credential.Name)
+		a.Equal(base64.RawURLEncoding.EncodeToString(authenticator.credentialID), credential.CredentialID)
+		a.Equal("none", credential.AttestationType)
+		a.Len(repository.credentials, 1)
+	})
+
+	t.Run("rejects a response for another origin", func(t *testing.T) {
+		now := time.Now()
+		service, _ := newTestService(t, &now)
+		authenticator := newSoftAuthenticator(t)
+		authenticator.origin = "https://evil.example"
+
+		ceremony, _ := service.BeginRegistration(context.TODO(), "user_1", "user@weeb.vip")
+
+		_, err := service.FinishRegistration(context.TODO(), "user_1", ceremony.ID, "", authenticator.create(t, ceremony.Options))
+		assert.Equal(t, PasskeyErrorVerificationFailed, errorCode(err))
+	})
+
+	t.Run("rejects a ceremony started by another user", func(t *testing.T) {
+		now := time.Now()
+		service, _ := newTestService(t, &now)
+		authenticator := newSoftAuthenticator(t)
+
+		ceremony, _ := service.BeginRegistration(context.TODO(), "user_1", "user@weeb.vip")
+
+		_, err := service.FinishRegistration(context.TODO(), "user_2", ceremony.ID, "", authenticator.create(t, ceremony.Options))
+		assert.Equal(t, PasskeyErrorInvalidCeremony, errorCode(err))
+	})
+}
+
+func TestPasskeyService_Login(t *testing.T) {
+	t.Run("returns the user owning the passkey", func(t *testing.T) {
+		a := assert.New(t)
+		now := time.Now()
+		service, repository := newTestService(t, &now)
+		authenticator := newSoftAuthenticator(t)
+		register(t, service, authenticator)
+
+		ceremony, err := service.BeginLogin(context.TODO())
+		a.NoError(err)
+
+		userID, err := service.FinishLogin(context.TODO(), ceremony.ID, authenticator.get(t, ceremony.Options))
+		a.NoError(err)
+		a.Equal("user_1", userID)
+		a.Equal(uint32(1), repository.credentials[0].SignCount)
+		a.NotNil(repository.credentials[0].LastUsedAt)
+	})
+
+	t.Run("only accepts a ceremony once", func(t *testing.T) {
+		now := time.Now()
+		service, _ := newTestService(t, &now)
+		authenticator := newSoftAuthenticator(t)
+		register(t, service, authenticator)
+
+		ceremony, _ := service.BeginLogin(context.TODO())
+		response := authenticator.get(t, ceremony.Options)
+
+		_, err := service.FinishLogin(context.TODO(), ceremony.ID, response)
+		assert.NoError(t, err)
+
+		_, err = service.FinishLogin(context.TODO(), ceremony.ID, response)
+		assert.Equal(t, PasskeyErrorInvalidCeremony, errorCode(err))
+	})
+
+	t.Run("rejects an expired ceremony", func(t *testing.T) {
+		now := time.Now()
+		service, _ := newTestService(t, &now)
+		authenticator := newSoftAuthenticator(t)
+		register(t, service, authenticator)
+
+		ceremony, _ := service.BeginLogin(context.TODO())
+		now = now.Add(defaultCeremonyTTLInSec * time.Second)
+
+		_, err := service.FinishLogin(context.TODO(), ceremony.ID, authenticator.get(t, ceremony.Options))
+		assert.Equal(t, PasskeyErrorInvalidCeremony, errorCode(err))
+	})
+
+	t.Run("rejects a signature from another key", func(t *testing.T) {
+		now := time.Now()
+		service, _ := newTestService(t, &now)
+		authenticator := newSoftAuthenticator(t)
+		register(t, service, authenticator)
+
+		ceremony, _ := service.BeginLogin(context.TODO())
+		authenticator.key, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+		_, err := service.FinishLogin(context.TODO(), ceremony.ID, authenticator.get(t, ceremony.Options))
+		assert.Equal(t, PasskeyErrorVerificationFailed, errorCode(err))
+	})
+
+	t.Run("rejects a signature counter that did not increase", func(t *testing.T) {
+		now := time.Now()
+		service, _ := newTestService(t, &now)
+		authenticator := newSoftAuthenticator(t)
+		register(t, service, authenticator)
+
+		ceremony, _ := service.BeginLogin(context.TODO())
+		_, err := service.FinishLogin(context.TODO(), ceremony.ID, authenticator.get(t, ceremony.Options))
+		assert.NoError(t, err)
+
+		authenticator.signCount = 0
+
+		ceremony, _ = service.BeginLogin(context.TODO())
+		_, err = service.FinishLogin(context.TODO(), ceremony.ID, authenticator.get(t, ceremony.Options))
+		assert.Equal(t, PasskeyErrorVerificationFailed, errorCode(err))
+	})
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/weeb-vip/auth/internal/db"
+	"github.com/weeb-vip/auth/internal/services/passkey/models"
+)
+
+type WebauthnCeremoniesRepository interface {
+	AddCeremony(ceremony *models.WebauthnCeremony) error
+	ConsumeCeremony(id string, kind models.CeremonyKind) (*models.WebauthnCeremony, error)
+}
+
+type webauthnCeremoniesRepository struct {
+	DBService db.DB
+}
+
+var webauthnCeremoniesRepositorySingleton WebauthnCeremoniesRepository // nolint
+
+func NewWebauthnCeremoniesRepository() WebauthnCeremoniesRepository {
+	dbService := db.GetDBService()
+
+	return &webauthnCeremoniesRepository{
+		DBService: dbService,
+	}
+}
+
+func (repository *webauthnCeremoniesRepository) AddCeremony(ceremony *models.WebauthnCeremony) error {
+	database := repository.DBService.GetDB()
+
+	return database.Create(ceremony).Error
+}
+
+// ConsumeCeremony loads and deletes the ceremony so its challenge can only be answered once.
+// It returns nil when the ceremony does not exist or was already consumed by a concurrent request.
+func (repository *webauthnCeremoniesRepository) ConsumeCeremony(
+	id string,
+	kind models.CeremonyKind,
+) (*models.WebauthnCeremony, error) {
+	database := repository.DBService.GetDB()
+
+	var ceremony *models.WebauthnCeremony
+
+	err := database.Transaction(func(tx *gorm.DB) error {
+		var found models.WebauthnCeremony
+
+		err := tx.Where("id = ? AND kind = ?", id, kind).First(&found).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		result := tx.Where("id = ?", id).Delete(&models.WebauthnCeremony{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 1 {
+			ceremony = &found
+		}
+
+		return nil
+	})
+
+	return ceremony, err
+}
+
+func GetWebauthnCeremoniesRepository() WebauthnCeremoniesRepository {
+	if webauthnCeremoniesRepositorySingleton == nil {
+		webauthnCeremoniesRepositorySingleton = NewWebauthnCeremoniesRepository()
+	}
+
+	return webauthnCeremoniesRepositorySingleton
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/weeb-vip/auth/internal/db"
+	"github.com/weeb-vip/auth/internal/services/passkey/models"
+)
+
+type WebauthnCredentialsRepository interface {
+	AddCredential(credential *models.WebauthnCredential) error
+	GetCredentialsByUserID(userID string) ([]models.WebauthnCredential, error)
+	UpdateAfterLogin(id string, signCount uint32, backupState bool, usedAt time.Time) error
+}
+
+type webauthnCredentialsRepository struct {
+	DBService db.DB
+}
+
+var webauthnCredentialsRepositorySingleton WebauthnCredentialsRepository // nolint
+
+func NewWebauthnCredentialsRepository() WebauthnCredentialsRepository {
+	dbService := db.GetDBService()
+
+	return &webauthnCredentialsRepository{
+		DBService: dbService,
+	}
+}
+
+func (repository *webauthnCredentialsRepository) AddCredential(credential *models.WebauthnCredential) error {
+	database := repository.DBService.GetDB()
+
+	return database.Create(credential).Error
+}
+
+func (repository *webauthnCredentialsRepository) GetCredentialsByUserID(userID string) ([]models.WebauthnCredential, error) {
+	database := repository.DBService.GetDB()
+
+	var credentials []models.WebauthnCredential
+
+	err := database.Where("user_id = ?", userID).Find(&credentials).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+func (repository *webauthnCredentialsRepository) UpdateAfterLogin(
+	id string,
+	signCount uint32,
+	backupState bool,
+	usedAt time.Time,
+) error {
+	database := repository.DBService.GetDB()
+
+	return database.Model(&models.WebauthnCredential{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"sign_count":   signCount,
+			"backup_state": backupState,
+			"last_used_at": usedAt,
+		}).Error
+}
+
+func GetWebauthnCredentialsRepository() WebauthnCredentialsRepository {
+	if webauthnCredentialsRepositorySingleton == nil {
+		webauthnCredentialsRepositorySingleton = NewWebauthnCredentialsRepository()
+	}
+
+	return webauthnCredentialsRepositorySingleton
+}
@@ -0,0 +1,23 @@
+package passwordreset
+
+const (
+	PasswordResetErrorInternalError   ErrorCode = "INTERNAL_ERROR"                   // nolint
+	PasswordResetErrorInvalidToken    ErrorCode = "INVALID_PASSWORD_RESET_TOKEN"     // nolint
+	PasswordResetErrorExpiredToken    ErrorCode = "PASSWORD_RESET_TOKEN_EXPIRED"     // nolint
+	PasswordResetErrorTooManyRequests ErrorCode = "TOO_MANY_PASSWORD_RESET_REQUESTS" // nolint
+)
+
+type ErrorCode string
+
+type Error struct {
+	Code    ErrorCode
+	Message string
+}
+
+func (c ErrorCode) String() string {
+	return string(c)
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
@@ -1,11 +1,16 @@
 package models
 
 import (
+	"time"
+
 	"github.com/weeb-vip/auth/internal/db"
 )
 
 type PasswordReset struct {
 	db.BaseModel
-	CredentialID string `json:"credentialId"`
-	OTT          string `json:"ott"`
+	CredentialID  string     `json:"credentialId"`
+	OTT           string     `json:"ott" gorm:"-"` // the raw token, only known when it is issued or presented.
+	OTTHash       string     `json:"-" gorm:"column:ott"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	InvalidatedAt *time.Time `json:"-"` // set once the token is used or replaced by a newer one.
 }
@@ -2,53 +2,137 @@ package passwordreset
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/weeb-vip/auth/config"
 	"github.com/weeb-vip/auth/internal/services/passwordreset/models"
 	"github.com/weeb-vip/auth/internal/services/passwordreset/repositories"
+	"github.com/weeb-vip/auth/internal/tokenhash"
+)
+
+const (
+	defaultTokenTTLInSec      = 3600
+	defaultMaxRequests        = 3
+	defaultRequestWindowInSec = 3600
 )
 
 type passwordResetService struct {
 	passwordResetRepository repositories.PasswordResetRepository
+	config                  config.PasswordResetConfig
+	now                     func() time.Time
 }
 
-func NewPasswordResetService() PasswordReset {
-	passwordResetRepository := repositories.NewPasswordResetRepository()
+func NewPasswordResetService(cfg config.PasswordResetConfig, tokenHashConfig config.TokenHashConfig) PasswordReset {
+	tokenHasher, err := tokenhash.New(tokenHashConfig.Secret)
+	if err != nil {
+		panic(fmt.Errorf("failed to create password reset token hasher: %w", err))
+	}
+
+	passwordResetRepository := repositories.NewPasswordResetRepository(tokenHasher)
 
 	return &passwordResetService{
 		passwordResetRepository: passwordResetRepository,
+		config:                  cfg,
+		now:                     time.Now,
 	}
 }
 
+// PasswordResetRequest issues a new token for the credential, replacing any token issued before it.
 func (service *passwordResetService) PasswordResetRequest(
 	ctx context.Context,
 	credentialID string,
 ) (*models.PasswordReset, error) {
-	ott := uuid.New().String()
+	if credentialID == "" {
+		return nil, &Error{
+			Code:    PasswordResetErrorInternalError,
+			Message: "password reset requested without a credential",
+		}
+	}
+
+	now := service.now()
+	window := time.Duration(orDefault(service.config.RequestWindowInSeconds, defaultRequestWindowInSec)) * time.Second
 
-	return service.passwordResetRepository.AddOTT(credentialID, ott)
+	count, err := service.passwordResetRepository.CountOTTsSince(credentialID, now.Add(-window))
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	if count >= int64(orDefault(service.config.MaxRequests, defaultMaxRequests)) {
+		return nil, &Error{
+			Code:    PasswordResetErrorTooManyRequests,
+			Message: "too many password reset requests, try again later",
+		}
+	}
+
+	ttl := time.Duration(orDefault(service.config.TokenTTLInSeconds, defaultTokenTTLInSec)) * time.Second
+
+	passwordReset, err := service.passwordResetRepository.AddOTT(credentialID, uuid.New().String(), now.Add(ttl))
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	return passwordReset, nil
 }
 
+// ValidateAndConsumeToken accepts the token once, and only for the credential it was issued to.
 func (service *passwordResetService) ValidateAndConsumeToken(
 	ctx context.Context,
-	username string,
+	credentialID string,
 	token string,
 ) error {
+	if credentialID == "" {
+		return invalidTokenError()
+	}
+
 	passwordReset, err := service.passwordResetRepository.GetOTTByToken(token)
 	if err != nil {
-		return err
+		return internalError(err)
+	}
+
+	if passwordReset == nil || passwordReset.InvalidatedAt != nil || passwordReset.CredentialID != credentialID {
+		return invalidTokenError()
 	}
 
-	if passwordReset.OTT == "" {
-		return errors.New("invalid token")
+	if !service.now().Before(passwordReset.ExpiresAt) {
+		return &Error{
+			Code:    PasswordResetErrorExpiredToken,
+			Message: "password reset token expired",
+		}
 	}
 
-	err = service.passwordResetRepository.DeleteOTTByToken(token)
+	consumed, err := service.passwordResetRepository.InvalidateOTT(passwordReset.ID)
 	if err != nil {
-		return err
+		return internalError(err)
+	}
+
+	if !consumed {
+		return invalidTokenError()
 	}
 
 	return nil
 }
+
+func orDefault(value int, fallback int) int {
+	if value <= 0 {
+		return fallback
+	}
+
+	return value
+}
+
+func invalidTokenError() error {
+	return &Error{
+		Code:    PasswordResetErrorInvalidToken,
+		Message: "invalid password reset token",
+	}
+}
+
+func internalError(err error) error {
+	return &Error{
+		Code:    PasswordResetErrorInternalError,
+		Message: err.Error(),
+	}
+}
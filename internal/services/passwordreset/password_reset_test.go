@@ -5,18 +5,25 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/weeb-vip/auth/config"
 	"github.com/weeb-vip/auth/internal/services/credential"
+	"github.com/weeb-vip/auth/internal/services/lockout"
 )
 
 func TestPasswordResetService_PasswordResetRequest(t *testing.T) {
 	t.Run("Test PasswordResetRequest", func(t *testing.T) {
 		a := assert.New(t)
 
-		credentialService := credential.NewCredentialService()
+		cfg, _ := config.LoadConfig()
+		credentialService := credential.NewCredentialService(
+			cfg.PasswordPolicyConfig,
+			cfg.PasswordHashConfig,
+			lockout.NewLockoutService(cfg.LockoutConfig, cfg.TokenHashConfig),
+		)
 
-		cred, err := credentialService.Register(context.TODO(), "username", "password")
+		cred, err := credentialService.Register(context.TODO(), "username", "violet-Harbor-92")
 
-		passwordResetService := NewPasswordResetService()
+		passwordResetService := NewPasswordResetService(cfg.PasswordResetConfig, cfg.TokenHashConfig)
 		_, err = passwordResetService.PasswordResetRequest(context.TODO(), cred.ID)
 		a.NoError(err)
 	})
@@ -0,0 +1,201 @@
+package passwordreset
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/db"
+	"github.com/weeb-vip/auth/internal/services/passwordreset/models"
+)
+
+type fakePasswordResetRepository struct {
+	resets []*models.PasswordReset
+	now    func() time.Time
+}
+
+func (f *fakePasswordResetRepository) AddOTT(credentialID string, ott string, expiresAt time.Time) (*models.PasswordReset, error) {
+	now := f.now()
+
+	for _, reset := range f.resets {
+		if reset.CredentialID == credentialID && reset.InvalidatedAt == nil {
+			reset.InvalidatedAt = &now
+		}
+	}
+
+	reset := &models.PasswordReset{
+		BaseModel:    db.BaseModel{ID: ott, CreatedAt: now},
+		CredentialID: credentialID,
+		OTT:          ott,
+		ExpiresAt:    expiresAt,
+	}
+	f.resets = append(f.resets, reset)
+
+	return reset, nil
+}
+
+func (f *fakePasswordResetRepository) DeleteOTT(credentialID string) error {
+	return nil
+}
+
+func (f *fakePasswordResetRepository) GetOTT(credentialID string) (*models.PasswordReset, error) {
+	return nil, nil
+}
+
+func (f *fakePasswordResetRepository) GetOTTByToken(ott string) (*models.PasswordReset, error) {
+	for _, reset := range f.resets {
+		if reset.OTT == ott {
+			copied := *reset
+
+			return &copied, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (f *fakePasswordResetRepository) DeleteOTTByToken(ott string) error {
+	return nil
+}
+
+func (f *fakePasswordResetRepository) CountOTTsSince(credentialID string, since time.Time) (int64, error) {
+	var count int64
+
+	for _, reset := range f.resets {
+		if reset.CredentialID == credentialID && reset.CreatedAt.After(since) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (f *fakePasswordResetRepository) InvalidateOTT(id string) (bool, error) {
+	for _, reset := range f.resets {
+		if reset.ID == id && reset.InvalidatedAt == nil {
+			now := f.now()
+			reset.InvalidatedAt = &now
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func newTestService() (*passwordResetService, *testClock) {
+	clock := &testClock{now: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)}
+
+	return &passwordResetService{
+		passwordResetRepository: &fakePasswordResetRepository{now: clock.Now},
+		config: config.PasswordResetConfig{
+			TokenTTLInSeconds:      900,
+			MaxRequests:            2,
+			RequestWindowInSeconds: 3600,
+		},
+		now: clock.Now,
+	}, clock
+}
+
+func assertErrorCode(t *testing.T, err error, code ErrorCode) {
+	t.Helper()
+
+	var passwordResetErr *Error
+	if assert.True(t, errors.As(err, &passwordResetErr), "expected a password reset error, got %v", err) {
+		assert.Equal(t, code, passwordResetErr.Code)
+	}
+}
+
+func TestPasswordResetTokens(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("consumes a token once", func(t *testing.T) {
+		service, _ := newTestService()
+
+		reset, err := service.PasswordResetRequest(ctx, "credential_1")
+		assert.NoError(t, err)
+
+		assert.NoError(t, service.ValidateAndConsumeToken(ctx, "credential_1", reset.OTT))
+		assertErrorCode(t, service.ValidateAndConsumeToken(ctx, "credential_1", reset.OTT), PasswordResetErrorInvalidToken)
+	})
+
+	t.Run("rejects a token of another credential without consuming it", func(t *testing.T) {
+		service, _ := newTestService()
+
+		reset, _ := service.PasswordResetRequest(ctx, "credential_1")
+
+		assertErrorCode(t, service.ValidateAndConsumeToken(ctx, "credential_2", reset.OTT), PasswordResetErrorInvalidToken)
+		assertErrorCode(t, service.ValidateAndConsumeToken(ctx, "", reset.OTT), PasswordResetErrorInvalidToken)
+		assert.NoError(t, service.ValidateAndConsumeToken(ctx, "credential_1", reset.OTT))
+	})
+
+	t.Run("does not issue or accept tokens without a credential", func(t *testing.T) {
+		service, _ := newTestService()
+		repository := service.passwordResetRepository.(*fakePasswordResetRepository)
+
+		_, err := service.PasswordResetRequest(ctx, "")
+		assertErrorCode(t, err, PasswordResetErrorInternalError)
+		assert.Empty(t, repository.resets)
+
+		repository.resets = append(repository.resets, &models.PasswordReset{OTT: "orphaned", ExpiresAt: time.Now().Add(time.Hour)})
+		assertErrorCode(t, service.ValidateAndConsumeToken(ctx, "", "orphaned"), PasswordResetErrorInvalidToken)
+	})
+
+	t.Run("rejects an unknown token", func(t *testing.T) {
+		service, _ := newTestService()
+
+		assertErrorCode(t, service.ValidateAndConsumeToken(ctx, "credential_1", "unknown"), PasswordResetErrorInvalidToken)
+	})
+
+	t.Run("rejects an expired token", func(t *testing.T) {
+		service, clock := newTestService()
+
+		reset, _ := service.PasswordResetRequest(ctx, "credential_1")
+		assert.Equal(t, clock.now.Add(15*time.Minute), reset.ExpiresAt)
+
+		clock.now = clock.now.Add(15 * time.Minute)
+		assertErrorCode(t, service.ValidateAndConsumeToken(ctx, "credential_1", reset.OTT), PasswordResetErrorExpiredToken)
+	})
+
+	t.Run("invalidates prior tokens when a new one is issued", func(t *testing.T) {
+		service, _ := newTestService()
+
+		first, _ := service.PasswordResetRequest(ctx, "credential_1")
+		second, _ := service.PasswordResetRequest(ctx, "credential_1")
+		assert.NotEqual(t, first.OTT, second.OTT)
+
+		assertErrorCode(t, service.ValidateAndConsumeToken(ctx, "credential_1", first.OTT), PasswordResetErrorInvalidToken)
+		assert.NoError(t, service.ValidateAndConsumeToken(ctx, "credential_1", second.OTT))
+	})
+
+	t.Run("throttles requests per account", func(t *testing.T) {
+		service, clock := newTestService()
+
+		_, err := service.PasswordResetRequest(ctx, "credential_1")
+		assert.NoError(t, err)
+		_, err = service.PasswordResetRequest(ctx, "credential_1")
+		assert.NoError(t, err)
+
+		_, err = service.PasswordResetRequest(ctx, "credential_1")
+		assertErrorCode(t, err, PasswordResetErrorTooManyRequests)
+
+		_, err = service.PasswordResetRequest(ctx, "credential_2")
+		assert.NoError(t, err)
+
+		clock.now = clock.now.Add(time.Hour)
+		_, err = service.PasswordResetRequest(ctx, "credential_1")
+		assert.NoError(t, err)
+	})
+}
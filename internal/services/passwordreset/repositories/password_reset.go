@@ -2,45 +2,66 @@ package repositories
 
 import (
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 
 	"github.com/weeb-vip/auth/internal/services/passwordreset/models"
+	"github.com/weeb-vip/auth/internal/tokenhash"
 
 	"github.com/weeb-vip/auth/internal/db"
 )
 
 type PasswordResetRepository interface {
-	AddOTT(credentialID string, ott string) (*models.PasswordReset, error)
+	AddOTT(credentialID string, ott string, expiresAt time.Time) (*models.PasswordReset, error)
 	DeleteOTT(credentialID string) error
 	GetOTT(credentialID string) (*models.PasswordReset, error)
 	GetOTTByToken(ott string) (*models.PasswordReset, error)
 	DeleteOTTByToken(ott string) error
+	CountOTTsSince(credentialID string, since time.Time) (int64, error)
+	InvalidateOTT(id string) (bool, error)
 }
 
+// passwordResetRepository only persists the keyed hash of a token, tokens are looked up by hashing the presented value.
 type passwordResetRepository struct {
-	DBService db.DB
+	DBService   db.DB
+	tokenHasher tokenhash.Hasher
 }
 
-var passwordResetRepositorySingleton PasswordResetRepository // nolint
-
-func NewPasswordResetRepository() PasswordResetRepository {
+func NewPasswordResetRepository(tokenHasher tokenhash.Hasher) PasswordResetRepository {
 	dbService := db.GetDBService()
 
 	return &passwordResetRepository{
-		DBService: dbService,
+		DBService:   dbService,
+		tokenHasher: tokenHasher,
 	}
 }
 
-func (repository *passwordResetRepository) AddOTT(credentialID string, ott string) (*models.PasswordReset, error) {
+// AddOTT stores a new token for the credential and invalidates every token issued before it.
+func (repository *passwordResetRepository) AddOTT(
+	credentialID string,
+	ott string,
+	expiresAt time.Time,
+) (*models.PasswordReset, error) {
 	db := repository.DBService.GetDB()
 
 	passwordReset := models.PasswordReset{
 		CredentialID: credentialID,
 		OTT:          ott,
+		OTTHash:      repository.tokenHasher.Hash(ott),
+		ExpiresAt:    expiresAt,
 	}
 
-	err := db.FirstOrCreate(&passwordReset, models.PasswordReset{CredentialID: credentialID}).Error
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.PasswordReset{}).
+			Where("credential_id = ? AND invalidated_at IS NULL", credentialID).
+			Update("invalidated_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&passwordReset).Error
+	})
 
 	if err != nil {
 		return nil, err
@@ -66,7 +87,7 @@ func (repository *passwordResetRepository) GetOTT(credentialID string) (*models.
 
 	var passwordReset models.PasswordReset
 
-	err := db.Where("credential_id = ?", credentialID).First(&passwordReset).Error
+	err := db.Where("credential_id = ? AND invalidated_at IS NULL", credentialID).First(&passwordReset).Error
 
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
@@ -75,30 +96,58 @@ func (repository *passwordResetRepository) GetOTT(credentialID string) (*models.
 	return &passwordReset, nil
 }
 
+// GetOTTByToken returns nil when the token does not exist.
 func (repository *passwordResetRepository) GetOTTByToken(ott string) (*models.PasswordReset, error) {
 	db := repository.DBService.GetDB()
 
 	var passwordReset models.PasswordReset
 
-	err := db.Where("ott = ?", ott).First(&passwordReset).Error
+	err := db.Where("ott = ?", repository.tokenHasher.Hash(ott)).First(&passwordReset).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
 
 	if err != nil {
 		return nil, err
 	}
 
+	passwordReset.OTT = ott
+
 	return &passwordReset, nil
 }
 
 func (repository *passwordResetRepository) DeleteOTTByToken(ott string) error {
 	db := repository.DBService.GetDB()
 
-	return db.Where("ott = ?", ott).Delete(&models.PasswordReset{}).Error
+	return db.Where("ott = ?", repository.tokenHasher.Hash(ott)).Delete(&models.PasswordReset{}).Error
+}
+
+// CountOTTsSince counts the tokens requested for the credential since the given time, used or not.
+func (repository *passwordResetRepository) CountOTTsSince(credentialID string, since time.Time) (int64, error) {
+	db := repository.DBService.GetDB()
+
+	var count int64
+
+	err := db.Model(&models.PasswordReset{}).
+		Where("credential_id = ? AND created_at > ?", credentialID, since).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
 }
 
-func GetPasswordResetRepository() PasswordResetRepository {
-	if passwordResetRepositorySingleton == nil {
-		passwordResetRepositorySingleton = NewPasswordResetRepository()
+// InvalidateOTT consumes the token, returning false if it was already used or replaced.
+func (repository *passwordResetRepository) InvalidateOTT(id string) (bool, error) {
+	db := repository.DBService.GetDB()
+
+	result := db.Model(&models.PasswordReset{}).
+		Where("id = ? AND invalidated_at IS NULL", id).
+		Update("invalidated_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
 	}
 
-	return passwordResetRepositorySingleton
+	return result.RowsAffected == 1, nil
 }
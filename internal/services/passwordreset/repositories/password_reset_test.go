@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/weeb-vip/auth/internal/tokenhash"
+)
+
+// recordingDB never connects, it records the SQL of every statement gorm would send to the database.
+type recordingDB struct {
+	logger.Interface
+	db         *gorm.DB
+	statements []string
+}
+
+func newRecordingDB(t *testing.T) *recordingDB {
+	t.Helper()
+
+	recorder := &recordingDB{Interface: logger.Discard}
+
+	database, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      dryRunConnPool{},
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true, Logger: recorder})
+	assert.NoError(t, err)
+
+	recorder.db = database
+
+	return recorder
+}
+
+var errDryRun = errors.New("dry run, nothing is sent to the database")
+
+// dryRunConnPool lets transactions begin and commit without a connection.
+type dryRunConnPool struct{}
+
+func (dryRunConnPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errDryRun
+}
+
+func (dryRunConnPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, errDryRun
+}
+
+func (dryRunConnPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errDryRun
+}
+
+func (dryRunConnPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func (pool dryRunConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return &dryRunTx{pool}, nil
+}
+
+type dryRunTx struct {
+	dryRunConnPool
+}
+
+func (*dryRunTx) Commit() error {
+	return nil
+}
+
+func (*dryRunTx) Rollback() error {
+	return nil
+}
+
+func (r *recordingDB) GetDB() *gorm.DB {
+	return r.db
+}
+
+func (r *recordingDB) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+func (r *recordingDB) contains(value string) bool {
+	for _, statement := range r.statements {
+		if strings.Contains(statement, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestPasswordResetRepositoryStoresOnlyHashes(t *testing.T) {
+	hasher, _ := tokenhash.New("secret")
+	ott := "6b0d5a0e-8d7c-4a57-9d8e-0f3c2b1a9e77"
+
+	recorder := newRecordingDB(t)
+	repository := &passwordResetRepository{DBService: recorder, tokenHasher: hasher}
+
+	passwordReset, err := repository.AddOTT("credential_1", ott, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, ott, passwordReset.OTT)
+	assert.Equal(t, hasher.Hash(ott), passwordReset.OTTHash)
+
+	_, _ = repository.GetOTTByToken(ott)
+	_, _ = repository.InvalidateOTT(passwordReset.ID)
+	_ = repository.DeleteOTTByToken(ott)
+
+	assert.NotEmpty(t, recorder.statements)
+	assert.False(t, recorder.contains(ott), "raw token reached the database: %v", recorder.statements)
+	assert.True(t, recorder.contains(hasher.Hash(ott)))
+}
+
+func TestPasswordResetRepositoryAddOTTInvalidatesPriorTokens(t *testing.T) {
+	hasher, _ := tokenhash.New("secret")
+
+	recorder := newRecordingDB(t)
+	repository := &passwordResetRepository{DBService: recorder, tokenHasher: hasher}
+
+	_, err := repository.AddOTT("credential_1", "token", time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	assert.Len(t, recorder.statements, 2)
+	assert.Contains(t, recorder.statements[0], "UPDATE `password_resets` SET `invalidated_at`")
+	assert.Contains(t, recorder.statements[0], "credential_id = 'credential_1' AND invalidated_at IS NULL")
+	assert.Contains(t, recorder.statements[1], "INSERT INTO `password_resets`")
+}
@@ -0,0 +1,23 @@
+package refresh_token //nolint
+
+const (
+	RefreshTokenErrorInternalError ErrorCode = "INTERNAL_ERROR"        // nolint
+	RefreshTokenErrorInvalid       ErrorCode = "INVALID_REFRESH_TOKEN" // nolint
+	RefreshTokenErrorExpired       ErrorCode = "REFRESH_TOKEN_EXPIRED" // nolint
+	RefreshTokenErrorReused        ErrorCode = "REFRESH_TOKEN_REUSED"  // nolint
+)
+
+type ErrorCode string
+
+type Error struct {
+	Code    ErrorCode
+	Message string
+}
+
+func (c ErrorCode) String() string {
+	return string(c)
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
@@ -5,7 +5,11 @@ import "github.com/weeb-vip/auth/internal/services/refresh_token/models"
 type RefreshToken interface {
 	GetToken(token string) (*models.RefreshToken, error)
 	GetTokenByUserID(userID string) (*models.RefreshToken, error)
-	CreateToken(userID string) (*models.RefreshToken, error)
-	DeleteToken(userID string) error
+	// CreateToken starts the token family of a session, it is called once per login.
+	CreateToken(userID string, sessionID string) (*models.RefreshToken, error)
+	// RotateToken consumes the token and issues its successor in the same family.
+	// Presenting a token that was already consumed revokes the whole family.
+	RotateToken(current *models.RefreshToken) (*models.RefreshToken, error)
+	DeleteToken(token string) error
 	ValidateToken(token string) (bool, error)
 }
@@ -1,10 +1,17 @@
 package models
 
-import "github.com/weeb-vip/auth/internal/db"
+import (
+	"time"
+
+	"github.com/weeb-vip/auth/internal/db"
+)
 
 type RefreshToken struct {
 	db.BaseModel
-	UserID string `gorm:"column:user_id;type:varchar(36);not null"`
-	Token  string `gorm:"column:token;type:varchar(36);not null"`
-	Expiry int64  `gorm:"column:expiry;type:int;not null"`
+	UserID    string     `gorm:"column:user_id;type:varchar(36);not null"`
+	Token     string     `gorm:"-"` // the raw token, only known when it is issued or presented.
+	TokenHash string     `gorm:"column:token;type:varchar(100);not null"`
+	FamilyID  string     `gorm:"column:family_id;type:varchar(100);not null"` // the ID of the session every token of the family was rotated from.
+	Expiry    int64      `gorm:"column:expiry;type:int;not null"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
 }
@@ -1,25 +1,34 @@
 package refresh_token //nolint
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/weeb-vip/auth/config"
 	"github.com/weeb-vip/auth/internal/services/refresh_token/models"
 	"github.com/weeb-vip/auth/internal/services/refresh_token/repositories"
+	"github.com/weeb-vip/auth/internal/tokenhash"
 	"github.com/weeb-vip/auth/internal/ulid"
 )
 
 type refreshTokenService struct {
 	refreshTokenRepository repositories.RefreshTokenRepository
 	config                 config.RefreshTokenConfig
+	now                    func() time.Time
 }
 
-func NewRefreshTokenService(config config.RefreshTokenConfig) RefreshToken {
-	refreshTokenRepository := repositories.GetRefreshTokenRepository()
+func NewRefreshTokenService(config config.RefreshTokenConfig, tokenHashConfig config.TokenHashConfig) RefreshToken {
+	tokenHasher, err := tokenhash.New(tokenHashConfig.Secret)
+	if err != nil {
+		panic(fmt.Errorf("failed to create refresh token hasher: %w", err))
+	}
+
+	refreshTokenRepository := repositories.NewRefreshTokenRepository(tokenHasher)
 
 	return &refreshTokenService{
 		config:                 config,
 		refreshTokenRepository: refreshTokenRepository,
+		now:                    time.Now,
 	}
 }
 
@@ -31,15 +40,43 @@ func (service *refreshTokenService) GetToken(token string) (*models.RefreshToken
 	return service.refreshTokenRepository.GetRefreshToken(token)
 }
 
-func (service *refreshTokenService) CreateToken(userID string) (*models.RefreshToken, error) {
-	ttl := time.Duration(service.config.TokenTTL) * time.Hour
-	expiry := time.Now().Add(ttl).Unix()
+// CreateToken uses the session ID as the family ID, so the tokens of a session can be revoked together.
+func (service *refreshTokenService) CreateToken(userID string, sessionID string) (*models.RefreshToken, error) {
+	return service.addToken(userID, sessionID)
+}
+
+func (service *refreshTokenService) RotateToken(current *models.RefreshToken) (*models.RefreshToken, error) {
+	if current.UsedAt != nil {
+		return nil, service.revokeFamily(current)
+	}
+
+	if current.Expiry < service.now().Unix() {
+		return nil, &Error{
+			Code:    RefreshTokenErrorExpired,
+			Message: "refresh token expired",
+		}
+	}
 
-	return service.refreshTokenRepository.AddRefreshToken(userID, ulid.New("refresh_token"), expiry)
+	consumed, err := service.refreshTokenRepository.MarkRefreshTokenUsed(current.ID)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	// Another request consumed the token between the read and the update, treat it as a replay.
+	if !consumed {
+		return nil, service.revokeFamily(current)
+	}
+
+	refreshToken, err := service.addToken(current.UserID, current.FamilyID)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	return refreshToken, nil
 }
 
-func (service *refreshTokenService) DeleteToken(userID string) error {
-	return service.refreshTokenRepository.DeleteRefreshToken(userID)
+func (service *refreshTokenService) DeleteToken(token string) error {
+	return service.refreshTokenRepository.DeleteRefreshToken(token)
 }
 
 func (service *refreshTokenService) ValidateToken(token string) (bool, error) {
@@ -48,9 +85,46 @@ func (service *refreshTokenService) ValidateToken(token string) (bool, error) {
 		return false, err
 	}
 
-	if refreshToken.Expiry < time.Now().Unix() {
+	if refreshToken == nil || refreshToken.UsedAt != nil {
+		return false, nil
+	}
+
+	if refreshToken.Expiry < service.now().Unix() {
 		return false, nil
 	}
 
 	return true, nil
 }
+
+func (service *refreshTokenService) addToken(userID string, familyID string) (*models.RefreshToken, error) {
+	ttl := time.Duration(service.config.TokenTTL) * time.Hour
+	expiry := service.now().Add(ttl).Unix()
+
+	return service.refreshTokenRepository.AddRefreshToken(userID, ulid.New("refresh_token"), familyID, expiry)
+}
+
+// revokeFamily deletes every token descending from the same login, a replayed token means one of them leaked.
+func (service *refreshTokenService) revokeFamily(current *models.RefreshToken) error {
+	var err error
+	if current.FamilyID == "" {
+		err = service.refreshTokenRepository.DeleteRefreshToken(current.Token)
+	} else {
+		err = service.refreshTokenRepository.DeleteRefreshTokenFamily(current.FamilyID)
+	}
+
+	if err != nil {
+		return internalError(err)
+	}
+
+	return &Error{
+		Code:    RefreshTokenErrorReused,
+		Message: "refresh token was already used, the session has been revoked",
+	}
+}
+
+func internalError(err error) error {
+	return &Error{
+		Code:    RefreshTokenErrorInternalError,
+		Message: err.Error(),
+	}
+}
@@ -15,7 +15,7 @@ func TestNewRefreshTokenService(t *testing.T) {
 		t.Parallel()
 		a := assert.New(t)
 		cfg, _ := config.LoadConfig()
-		refreshTokenService := refresh_token.NewRefreshTokenService(cfg.RefreshTokenConfig)
+		refreshTokenService := refresh_token.NewRefreshTokenService(cfg.RefreshTokenConfig, cfg.TokenHashConfig)
 
 		a.NotNil(refreshTokenService)
 	})
@@ -24,7 +24,7 @@ func TestNewRefreshTokenService(t *testing.T) {
 		t.Parallel()
 		a := assert.New(t)
 		cfg, _ := config.LoadConfig()
-		refreshTokenService := refresh_token.NewRefreshTokenService(cfg.RefreshTokenConfig)
+		refreshTokenService := refresh_token.NewRefreshTokenService(cfg.RefreshTokenConfig, cfg.TokenHashConfig)
 
 		_, err := refreshTokenService.GetToken("token")
 		a.NoError(err)
@@ -34,7 +34,7 @@ func TestNewRefreshTokenService(t *testing.T) {
 		t.Parallel()
 		a := assert.New(t)
 		cfg, _ := config.LoadConfig()
-		refreshTokenService := refresh_token.NewRefreshTokenService(cfg.RefreshTokenConfig)
+		refreshTokenService := refresh_token.NewRefreshTokenService(cfg.RefreshTokenConfig, cfg.TokenHashConfig)
 
 		err := refreshTokenService.DeleteToken("token")
 		a.NoError(err)
@@ -44,9 +44,9 @@ func TestNewRefreshTokenService(t *testing.T) {
 		t.Parallel()
 		a := assert.New(t)
 		cfg, _ := config.LoadConfig()
-		refreshTokenService := refresh_token.NewRefreshTokenService(cfg.RefreshTokenConfig)
+		refreshTokenService := refresh_token.NewRefreshTokenService(cfg.RefreshTokenConfig, cfg.TokenHashConfig)
 
-		token, err := refreshTokenService.CreateToken("userid")
+		token, err := refreshTokenService.CreateToken("userid", "sessionid")
 		a.NoError(err)
 		a.NotEmpty(token)
 	})
@@ -55,9 +55,9 @@ func TestNewRefreshTokenService(t *testing.T) {
 		t.Parallel()
 		a := assert.New(t)
 		cfg, _ := config.LoadConfig()
-		refreshTokenService := refresh_token.NewRefreshTokenService(cfg.RefreshTokenConfig)
+		refreshTokenService := refresh_token.NewRefreshTokenService(cfg.RefreshTokenConfig, cfg.TokenHashConfig)
 
-		token, err := refreshTokenService.CreateToken("userid")
+		token, err := refreshTokenService.CreateToken("userid", "sessionid")
 		a.NoError(err)
 		a.NotEmpty(token)
 		a.True(refreshTokenService.ValidateToken(token.Token))
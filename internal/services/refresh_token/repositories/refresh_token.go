@@ -2,45 +2,53 @@ package repositories
 
 import (
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 
 	"github.com/weeb-vip/auth/internal/db"
 	"github.com/weeb-vip/auth/internal/services/refresh_token/models"
+	"github.com/weeb-vip/auth/internal/tokenhash"
 )
 
 type RefreshTokenRepository interface {
-	AddRefreshToken(userID string, token string, expiry int64) (*models.RefreshToken, error)
+	AddRefreshToken(userID string, token string, familyID string, expiry int64) (*models.RefreshToken, error)
 	GetRefreshToken(token string) (*models.RefreshToken, error)
 	GetRefreshTokenByUserID(userID string) (*models.RefreshToken, error)
 	DeleteRefreshToken(token string) error
+	MarkRefreshTokenUsed(id string) (bool, error)
+	DeleteRefreshTokenFamily(familyID string) error
 }
 
+// refreshTokenRepository only persists the keyed hash of a token, tokens are looked up by hashing the presented value.
 type refreshTokenRepository struct {
-	DBService db.DB
+	DBService   db.DB
+	tokenHasher tokenhash.Hasher
 }
 
-var refreshTokenRepositorySingleton RefreshTokenRepository // nolint
-
-func NewRefreshTokenRepository() RefreshTokenRepository {
+func NewRefreshTokenRepository(tokenHasher tokenhash.Hasher) RefreshTokenRepository {
 	dbService := db.GetDBService()
 
 	return &refreshTokenRepository{
-		DBService: dbService,
+		DBService:   dbService,
+		tokenHasher: tokenHasher,
 	}
 }
 
 func (repository *refreshTokenRepository) AddRefreshToken(
 	userID string,
 	token string,
+	familyID string,
 	expiry int64,
 ) (*models.RefreshToken, error) {
 	database := repository.DBService.GetDB()
 
 	refreshToken := models.RefreshToken{
-		UserID: userID,
-		Token:  token,
-		Expiry: expiry,
+		UserID:    userID,
+		Token:     token,
+		TokenHash: repository.tokenHasher.Hash(token),
+		FamilyID:  familyID,
+		Expiry:    expiry,
 	}
 
 	err := database.Create(&refreshToken).Error
@@ -51,23 +59,30 @@ func (repository *refreshTokenRepository) AddRefreshToken(
 	return &refreshToken, nil
 }
 
+// GetRefreshToken returns nil when the token does not exist.
 func (repository *refreshTokenRepository) GetRefreshToken(token string) (*models.RefreshToken, error) {
 	database := repository.DBService.GetDB()
 
 	var refreshToken models.RefreshToken
 
-	err := database.Where("token = ?", token).First(&refreshToken).Error
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+	err := database.Where("token = ?", repository.tokenHasher.Hash(token)).First(&refreshToken).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
 		return nil, err
 	}
 
+	refreshToken.Token = token
+
 	return &refreshToken, nil
 }
 
 func (repository *refreshTokenRepository) DeleteRefreshToken(token string) error {
 	database := repository.DBService.GetDB()
 
-	err := database.Where("token = ?", token).Delete(&models.RefreshToken{}).Error
+	err := database.Where("token = ?", repository.tokenHasher.Hash(token)).Delete(&models.RefreshToken{}).Error
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return err
 	}
@@ -75,12 +90,24 @@ func (repository *refreshTokenRepository) DeleteRefreshToken(token string) error
 	return nil
 }
 
-func GetRefreshTokenRepository() RefreshTokenRepository {
-	if refreshTokenRepositorySingleton == nil {
-		refreshTokenRepositorySingleton = NewRefreshTokenRepository()
+// MarkRefreshTokenUsed consumes the token, returning false if it was already consumed by a concurrent request.
+func (repository *refreshTokenRepository) MarkRefreshTokenUsed(id string) (bool, error) {
+	database := repository.DBService.GetDB()
+
+	result := database.Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
 	}
 
-	return refreshTokenRepositorySingleton
+	return result.RowsAffected == 1, nil
+}
+
+func (repository *refreshTokenRepository) DeleteRefreshTokenFamily(familyID string) error {
+	database := repository.DBService.GetDB()
+
+	return database.Where("family_id = ?", familyID).Delete(&models.RefreshToken{}).Error
 }
 
 func (repository *refreshTokenRepository) GetRefreshTokenByUserID(userID string) (*models.RefreshToken, error) {
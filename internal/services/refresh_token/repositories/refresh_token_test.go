@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/weeb-vip/auth/internal/tokenhash"
+)
+
+// recordingDB never connects, it records the SQL of every statement gorm would send to the database.
+type recordingDB struct {
+	logger.Interface
+	db         *gorm.DB
+	statements []string
+}
+
+func newRecordingDB(t *testing.T) *recordingDB {
+	t.Helper()
+
+	recorder := &recordingDB{Interface: logger.Discard}
+
+	database, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:password@tcp(localhost:3306)/auth?parseTime=True",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true, Logger: recorder})
+	assert.NoError(t, err)
+
+	recorder.db = database
+
+	return recorder
+}
+
+func (r *recordingDB) GetDB() *gorm.DB {
+	return r.db
+}
+
+func (r *recordingDB) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+func (r *recordingDB) contains(value string) bool {
+	for _, statement := range r.statements {
+		if strings.Contains(statement, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestRefreshTokenRepositoryStoresOnlyHashes(t *testing.T) {
+	hasher, _ := tokenhash.New("secret")
+	token := "refresh_token_01hzy8x6d3yq0v9v7kq1b7a4mz"
+
+	recorder := newRecordingDB(t)
+	repository := &refreshTokenRepository{DBService: recorder, tokenHasher: hasher}
+
+	refreshToken, err := repository.AddRefreshToken("user_1", token, "family_1", time.Now().Unix())
+	assert.NoError(t, err)
+	assert.Equal(t, token, refreshToken.Token)
+	assert.Equal(t, hasher.Hash(token), refreshToken.TokenHash)
+
+	_, _ = repository.GetRefreshToken(token)
+	_ = repository.DeleteRefreshToken(token)
+
+	assert.Len(t, recorder.statements, 3)
+	assert.False(t, recorder.contains(token), "raw token reached the database: %v", recorder.statements)
+
+	for _, statement := range recorder.statements {
+		assert.Contains(t, statement, hasher.Hash(token))
+	}
+}
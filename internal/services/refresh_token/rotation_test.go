@@ -0,0 +1,193 @@
+package refresh_token //nolint
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/db"
+	"github.com/weeb-vip/auth/internal/services/refresh_token/models"
+)
+
+type fakeRefreshTokenRepository struct {
+	tokens []*models.RefreshToken
+}
+
+func (f *fakeRefreshTokenRepository) AddRefreshToken(userID string, token string, familyID string, expiry int64) (*models.RefreshToken, error) {
+	refreshToken := &models.RefreshToken{
+		BaseModel: db.BaseModel{ID: token},
+		UserID:    userID,
+		Token:     token,
+		FamilyID:  familyID,
+		Expiry:    expiry,
+	}
+	f.tokens = append(f.tokens, refreshToken)
+
+	return refreshToken, nil
+}
+
+func (f *fakeRefreshTokenRepository) GetRefreshToken(token string) (*models.RefreshToken, error) {
+	for _, refreshToken := range f.tokens {
+		if refreshToken.Token == token {
+			copied := *refreshToken
+
+			return &copied, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (f *fakeRefreshTokenRepository) GetRefreshTokenByUserID(userID string) (*models.RefreshToken, error) {
+	for _, refreshToken := range f.tokens {
+		if refreshToken.UserID == userID {
+			return refreshToken, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (f *fakeRefreshTokenRepository) DeleteRefreshToken(token string) error {
+	return f.deleteWhere(func(refreshToken *models.RefreshToken) bool { return refreshToken.Token == token })
+}
+
+func (f *fakeRefreshTokenRepository) MarkRefreshTokenUsed(id string) (bool, error) {
+	for _, refreshToken := range f.tokens {
+		if refreshToken.ID == id && refreshToken.UsedAt == nil {
+			now := time.Now()
+			refreshToken.UsedAt = &now
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (f *fakeRefreshTokenRepository) DeleteRefreshTokenFamily(familyID string) error {
+	return f.deleteWhere(func(refreshToken *models.RefreshToken) bool { return refreshToken.FamilyID == familyID })
+}
+
+func (f *fakeRefreshTokenRepository) deleteWhere(match func(refreshToken *models.RefreshToken) bool) error {
+	kept := f.tokens[:0]
+
+	for _, refreshToken := range f.tokens {
+		if !match(refreshToken) {
+			kept = append(kept, refreshToken)
+		}
+	}
+
+	f.tokens = kept
+
+	return nil
+}
+
+func newTestService(now time.Time) (*refreshTokenService, *fakeRefreshTokenRepository) {
+	repository := &fakeRefreshTokenRepository{}
+
+	return &refreshTokenService{
+		refreshTokenRepository: repository,
+		config:                 config.RefreshTokenConfig{TokenTTL: 1},
+		now:                    func() time.Time { return now },
+	}, repository
+}
+
+func TestRotateToken(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	t.Run("issues a successor in the same family", func(t *testing.T) {
+		t.Parallel()
+		a := assert.New(t)
+		service, _ := newTestService(now)
+
+		created, err := service.CreateToken("user_1", "session_1")
+		a.NoError(err)
+		a.Equal("session_1", created.FamilyID)
+
+		current, _ := service.GetToken(created.Token)
+		rotated, err := service.RotateToken(current)
+		a.NoError(err)
+		a.NotEqual(created.Token, rotated.Token)
+		a.Equal(created.FamilyID, rotated.FamilyID)
+		a.Equal("user_1", rotated.UserID)
+		a.Equal(now.Add(time.Hour).Unix(), rotated.Expiry)
+
+		valid, err := service.ValidateToken(created.Token)
+		a.NoError(err)
+		a.False(valid)
+
+		valid, err = service.ValidateToken(rotated.Token)
+		a.NoError(err)
+		a.True(valid)
+	})
+
+	t.Run("revokes the family when a consumed token is presented again", func(t *testing.T) {
+		t.Parallel()
+		a := assert.New(t)
+		service, repository := newTestService(now)
+
+		created, _ := service.CreateToken("user_1", "session_1")
+		other, _ := service.CreateToken("user_1", "session_2")
+
+		stolen, _ := service.GetToken(created.Token)
+		_, err := service.RotateToken(stolen)
+		a.NoError(err)
+
+		replayed, _ := service.GetToken(created.Token)
+		_, err = service.RotateToken(replayed)
+
+		var refreshTokenErr *Error
+		a.True(errors.As(err, &refreshTokenErr))
+		a.Equal(RefreshTokenErrorReused, refreshTokenErr.Code)
+
+		a.Len(repository.tokens, 1)
+		a.Equal(other.Token, repository.tokens[0].Token)
+	})
+
+	t.Run("treats losing a concurrent rotation as reuse", func(t *testing.T) {
+		t.Parallel()
+		a := assert.New(t)
+		service, repository := newTestService(now)
+
+		created, _ := service.CreateToken("user_1", "session_1")
+
+		first, _ := service.GetToken(created.Token)
+		second, _ := service.GetToken(created.Token)
+
+		_, err := service.RotateToken(first)
+		a.NoError(err)
+
+		_, err = service.RotateToken(second)
+
+		var refreshTokenErr *Error
+		a.True(errors.As(err, &refreshTokenErr))
+		a.Equal(RefreshTokenErrorReused, refreshTokenErr.Code)
+		a.Empty(repository.tokens)
+	})
+
+	t.Run("rejects an expired token", func(t *testing.T) {
+		t.Parallel()
+		a := assert.New(t)
+		service, _ := newTestService(now)
+
+		created, _ := service.CreateToken("user_1", "session_1")
+		service.now = func() time.Time { return now.Add(2 * time.Hour) }
+
+		current, _ := service.GetToken(created.Token)
+		_, err := service.RotateToken(current)
+
+		var refreshTokenErr *Error
+		a.True(errors.As(err, &refreshTokenErr))
+		a.Equal(RefreshTokenErrorExpired, refreshTokenErr.Code)
+
+		valid, err := service.ValidateToken(created.Token)
+		a.NoError(err)
+		a.False(valid)
+	})
+}
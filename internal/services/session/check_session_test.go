@@ -0,0 +1,158 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/db"
+	"github.com/weeb-vip/auth/internal/services/session/models"
+)
+
+// fakeSessionsRepository holds sessions by ID and records which ones were revoked.
+type fakeSessionsRepository struct {
+	sessions map[string]*models.Session
+	revoked  []string
+}
+
+func (f *fakeSessionsRepository) CreateSession(ctx context.Context, userID string, ipAddress string, userAgent string) (*models.Session, error) {
+	return nil, nil
+}
+
+func (f *fakeSessionsRepository) GetSession(ctx context.Context, token string) (*models.Session, error) {
+	return nil, nil
+}
+
+func (f *fakeSessionsRepository) GetSessionByID(ctx context.Context, sessionID string) (*models.Session, error) {
+	return f.sessions[sessionID], nil
+}
+
+func (f *fakeSessionsRepository) ListUserSessions(ctx context.Context, userIDs []string) ([]*models.Session, error) {
+	sessions := make([]*models.Session, 0, len(f.sessions))
+	for _, userID := range userIDs {
+		for _, session := range f.sessions {
+			if session.UserID == userID {
+				sessions = append(sessions, session)
+			}
+		}
+	}
+
+	return sessions, nil
+}
+
+func (f *fakeSessionsRepository) TouchSession(ctx context.Context, sessionID string, refreshTokenID string, ipAddress string, userAgent string) error {
+	return nil
+}
+
+func (f *fakeSessionsRepository) DeleteSession(ctx context.Context, token string) error {
+	return nil
+}
+
+func (f *fakeSessionsRepository) RevokeUserSessions(ctx context.Context, userID string, keepSessionID string) error {
+	return nil
+}
+
+func (f *fakeSessionsRepository) RevokeSession(ctx context.Context, userID string, sessionID string) (bool, error) {
+	f.revoked = append(f.revoked, userID+"/"+sessionID)
+
+	session, found := f.sessions[sessionID]
+	if !found || session.UserID != userID {
+		return false, nil
+	}
+
+	delete(f.sessions, sessionID)
+
+	return true, nil
+}
+
+func newCheckSessionService() (*sessionService, *fakeSessionsRepository) {
+	now := time.Now()
+	repository := &fakeSessionsRepository{sessions: map[string]*models.Session{
+		"session_123": {BaseModel: db.BaseModel{ID: "session_123", CreatedAt: now}, UserID: "user_123", LastSeenAt: now},
+		"session_456": {BaseModel: db.BaseModel{ID: "session_456", CreatedAt: now}, UserID: "user_456", LastSeenAt: now},
+	}}
+
+	return &sessionService{
+		sessionRepository: repository,
+		policy:            NewPolicy(config.SessionConfig{IdleTimeoutInHours: 24, AbsoluteLifetimeInHours: 24 * 30}),
+	}, repository
+}
+
+func sessionErrorCode(err error) ErrorCode {
+	var sessionErr *Error
+	if errors.As(err, &sessionErr) {
+		return sessionErr.Code
+	}
+
+	return ""
+}
+
+func TestCheckSession(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("keeps a session within the policy", func(t *testing.T) {
+		service, repository := newCheckSessionService()
+
+		assert.NoError(t, service.CheckSession(ctx, "user_123", "session_123"))
+		assert.Empty(t, repository.revoked)
+	})
+
+	t.Run("rejects a refresh token whose session is gone and drops its family", func(t *testing.T) {
+		service, repository := newCheckSessionService()
+
+		err := service.CheckSession(ctx, "user_123", "session_removed")
+		assert.Equal(t, SessionErrorRevoked, sessionErrorCode(err))
+		assert.Equal(t, []string{"user_123/session_removed"}, repository.revoked)
+	})
+
+	t.Run("rejects the session of another user without ending it", func(t *testing.T) {
+		service, repository := newCheckSessionService()
+
+		err := service.CheckSession(ctx, "user_123", "session_456")
+		assert.Equal(t, SessionErrorRevoked, sessionErrorCode(err))
+		assert.Contains(t, repository.sessions, "session_456")
+	})
+
+	t.Run("lets refresh tokens without a session through", func(t *testing.T) {
+		service, repository := newCheckSessionService()
+
+		assert.NoError(t, service.CheckSession(ctx, "user_123", ""))
+		assert.Empty(t, repository.revoked)
+	})
+
+	t.Run("ends a session that timed out", func(t *testing.T) {
+		service, repository := newCheckSessionService()
+		repository.sessions["session_123"].LastSeenAt = time.Now().Add(-48 * time.Hour)
+
+		err := service.CheckSession(ctx, "user_123", "session_123")
+		assert.Equal(t, SessionErrorIdleTimeout, sessionErrorCode(err))
+		assert.NotContains(t, repository.sessions, "session_123")
+	})
+}
+
+func TestListUserSessions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("lists the sessions of each user", func(t *testing.T) {
+		service, _ := newCheckSessionService()
+
+		sessions, err := service.ListUserSessions(ctx, []string{"user_123", "user_456"})
+		assert.NoError(t, err)
+		assert.Len(t, sessions["user_123"], 1)
+		assert.Len(t, sessions["user_456"], 1)
+	})
+
+	t.Run("leaves out sessions the policy has ended", func(t *testing.T) {
+		service, repository := newCheckSessionService()
+		repository.sessions["session_123"].LastSeenAt = time.Now().Add(-48 * time.Hour)
+		repository.sessions["session_456"].CreatedAt = time.Now().Add(-60 * 24 * time.Hour)
+
+		sessions, err := service.ListUserSessions(ctx, []string{"user_123", "user_456"})
+		assert.NoError(t, err)
+		assert.Empty(t, sessions)
+	})
+}
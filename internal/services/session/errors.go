@@ -1,9 +1,13 @@
 package session
 
 const (
-	SessionErrorInternalError      ErrorCode = "INTERNAL_ERROR"       // nolint
-	SessionErrorSessionNotFound    ErrorCode = "SESSION_NOT_FOUND"    // nolint
-	SessionErrorInvalidSessionCode ErrorCode = "INVALID_SESSION_CODE" // nolint
+	SessionErrorInternalError      ErrorCode = "INTERNAL_ERROR"         // nolint
+	SessionErrorSessionNotFound    ErrorCode = "SESSION_NOT_FOUND"      // nolint
+	SessionErrorInvalidSessionCode ErrorCode = "INVALID_SESSION_CODE"   // nolint
+	SessionErrorIdleTimeout        ErrorCode = "SESSION_IDLE_TIMEOUT"   // nolint
+	SessionErrorExpired            ErrorCode = "SESSION_EXPIRED"        // nolint
+	SessionErrorLimitExceeded      ErrorCode = "SESSION_LIMIT_EXCEEDED" // nolint
+	SessionErrorRevoked            ErrorCode = "SESSION_REVOKED"        // nolint
 )
 
 type ErrorCode string
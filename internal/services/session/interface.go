@@ -7,5 +7,24 @@ import (
 )
 
 type Session interface {
-	CreateSession(ctx context.Context, userID string) (*models.Session, error)
+	// CreateSession starts a session for the user on the client with the given IP address and user agent.
+	CreateSession(ctx context.Context, userID string, ipAddress string, userAgent string) (*models.Session, error)
+	// TouchSession links the session to the refresh token just issued for it and marks it as seen now.
+	TouchSession(ctx context.Context, sessionID string, refreshTokenID string, ipAddress string, userAgent string) error
+	// ListUserSessions returns the sessions of each of the given users that the session policy still allows,
+	// most recently seen first. Users without such sessions are left out of the map.
+	ListUserSessions(ctx context.Context, userIDs []string) (map[string][]*models.Session, error)
+	// CheckSession ends the session of the user when the session policy says it is over, returning why as an
+	// error. A session that is gone or belongs to someone else is reported revoked. Refresh tokens without a
+	// session, issued before sessions were tracked, are not checked.
+	CheckSession(ctx context.Context, userID string, sessionID string) error
+	// EvictSessions ends the oldest sessions of the user beyond the session limit, never the one with
+	// keepSessionID, and returns them.
+	EvictSessions(ctx context.Context, userID string, keepSessionID string) ([]*models.Session, error)
+	// RevokeUserSessions signs the user out everywhere by deleting their sessions and refresh tokens,
+	// except for the session with the given ID when it is not empty.
+	RevokeUserSessions(ctx context.Context, userID string, keepSessionID string) error
+	// RevokeSession signs the user out of one session by deleting it and its refresh tokens, returning false
+	// when the user has no session with that ID.
+	RevokeSession(ctx context.Context, userID string, sessionID string) (bool, error)
 }
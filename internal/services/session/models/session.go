@@ -1,13 +1,16 @@
 package models
 
 import (
+	"time"
+
 	"github.com/weeb-vip/auth/internal/db"
 )
 
 type Session struct {
 	db.BaseModel
-	UserID    string `column:"user_id"`
-	IPAddress string `column:"ip_address"`
-	UserAgent string `column:"user_agent"`
-	Token     string `column:"token"`
+	UserID     string    `column:"user_id"`
+	IPAddress  string    `column:"ip_address"`
+	UserAgent  string    `column:"user_agent"`
+	Token      string    `column:"token"` // the ID of the latest refresh token issued for the session.
+	LastSeenAt time.Time `column:"last_seen_at"`
 }
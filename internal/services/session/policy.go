@@ -0,0 +1,70 @@
+package session
+
+import (
+	"sort"
+	"time"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/services/session/models"
+)
+
+// Policy decides when sessions end without their user signing out. A zero limit is not enforced.
+type Policy struct {
+	IdleTimeout      time.Duration
+	AbsoluteLifetime time.Duration
+	MaxSessions      int
+}
+
+func NewPolicy(cfg config.SessionConfig) Policy {
+	return Policy{
+		IdleTimeout:      time.Duration(cfg.IdleTimeoutInHours) * time.Hour,
+		AbsoluteLifetime: time.Duration(cfg.AbsoluteLifetimeInHours) * time.Hour,
+		MaxSessions:      cfg.MaxConcurrentSessions,
+	}
+}
+
+// Check returns why the session is over at now, or nil while it can still be refreshed.
+func (policy Policy) Check(session *models.Session, now time.Time) error {
+	if policy.AbsoluteLifetime > 0 && !now.Before(session.CreatedAt.Add(policy.AbsoluteLifetime)) {
+		return &Error{
+			Code:    SessionErrorExpired,
+			Message: "session expired",
+		}
+	}
+
+	if policy.IdleTimeout > 0 && !now.Before(session.LastSeenAt.Add(policy.IdleTimeout)) {
+		return &Error{
+			Code:    SessionErrorIdleTimeout,
+			Message: "session timed out after a period of inactivity",
+		}
+	}
+
+	return nil
+}
+
+// Excess returns the oldest sessions to end so that MaxSessions are left, never the one with keepSessionID.
+func (policy Policy) Excess(sessions []*models.Session, keepSessionID string) []*models.Session {
+	count := len(sessions) - policy.MaxSessions
+	if policy.MaxSessions <= 0 || count <= 0 {
+		return nil
+	}
+
+	oldest := make([]*models.Session, len(sessions))
+	copy(oldest, sessions)
+	sort.SliceStable(oldest, func(i, j int) bool {
+		return oldest[i].CreatedAt.Before(oldest[j].CreatedAt)
+	})
+
+	excess := make([]*models.Session, 0, count)
+	for _, session := range oldest {
+		if len(excess) == count {
+			break
+		}
+
+		if session.ID != keepSessionID {
+			excess = append(excess, session)
+		}
+	}
+
+	return excess
+}
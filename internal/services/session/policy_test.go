@@ -0,0 +1,89 @@
+package session_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/config"
+	"github.com/weeb-vip/auth/internal/db"
+	"github.com/weeb-vip/auth/internal/services/session"
+	"github.com/weeb-vip/auth/internal/services/session/models"
+)
+
+func newSession(id string, createdAt time.Time, lastSeenAt time.Time) *models.Session {
+	return &models.Session{
+		BaseModel:  db.BaseModel{ID: id, CreatedAt: createdAt},
+		UserID:     "user_123",
+		LastSeenAt: lastSeenAt,
+	}
+}
+
+func errorCode(err error) session.ErrorCode {
+	var sessionErr *session.Error
+	if errors.As(err, &sessionErr) {
+		return sessionErr.Code
+	}
+
+	return ""
+}
+
+func TestPolicy_Check(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	policy := session.NewPolicy(config.SessionConfig{IdleTimeoutInHours: 24, AbsoluteLifetimeInHours: 24 * 30})
+
+	t.Run("keeps a session in use", func(t *testing.T) {
+		assert.NoError(t, policy.Check(newSession("session_1", now.Add(-24*time.Hour), now.Add(-time.Hour)), now))
+	})
+
+	t.Run("ends a session that was not refreshed within the idle timeout", func(t *testing.T) {
+		err := policy.Check(newSession("session_1", now.Add(-48*time.Hour), now.Add(-24*time.Hour)), now)
+		assert.Equal(t, session.SessionErrorIdleTimeout, errorCode(err))
+	})
+
+	t.Run("ends a session past its lifetime however active", func(t *testing.T) {
+		err := policy.Check(newSession("session_1", now.Add(-30*24*time.Hour), now), now)
+		assert.Equal(t, session.SessionErrorExpired, errorCode(err))
+	})
+
+	t.Run("does not enforce limits set to zero", func(t *testing.T) {
+		unlimited := session.NewPolicy(config.SessionConfig{})
+		assert.NoError(t, unlimited.Check(newSession("session_1", now.AddDate(-5, 0, 0), now.AddDate(-1, 0, 0)), now))
+	})
+}
+
+func TestPolicy_Excess(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	sessions := []*models.Session{
+		newSession("session_3", now.Add(-1*time.Hour), now),
+		newSession("session_1", now.Add(-3*time.Hour), now),
+		newSession("session_4", now, now),
+		newSession("session_2", now.Add(-2*time.Hour), now),
+	}
+
+	ids := func(sessions []*models.Session) []string {
+		var ids []string
+		for _, session := range sessions {
+			ids = append(ids, session.ID)
+		}
+
+		return ids
+	}
+
+	t.Run("returns the oldest sessions beyond the limit", func(t *testing.T) {
+		policy := session.NewPolicy(config.SessionConfig{MaxConcurrentSessions: 2})
+		assert.Equal(t, []string{"session_1", "session_2"}, ids(policy.Excess(sessions, "session_4")))
+	})
+
+	t.Run("never returns the kept session", func(t *testing.T) {
+		policy := session.NewPolicy(config.SessionConfig{MaxConcurrentSessions: 3})
+		assert.Equal(t, []string{"session_2"}, ids(policy.Excess(sessions, "session_1")))
+	})
+
+	t.Run("returns nothing within the limit or without one", func(t *testing.T) {
+		assert.Empty(t, session.NewPolicy(config.SessionConfig{MaxConcurrentSessions: 4}).Excess(sessions, ""))
+		assert.Empty(t, session.NewPolicy(config.SessionConfig{}).Excess(sessions, ""))
+	})
+}
@@ -3,17 +3,24 @@ package repositories
 import (
 	"context"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 
 	"github.com/weeb-vip/auth/internal/db"
+	RefreshTokenModels "github.com/weeb-vip/auth/internal/services/refresh_token/models"
 	"github.com/weeb-vip/auth/internal/services/session/models"
 )
 
 type SessionsRepository interface {
-	CreateSession(ctx context.Context, userID string) (*models.Session, error)
+	CreateSession(ctx context.Context, userID string, ipAddress string, userAgent string) (*models.Session, error)
 	GetSession(ctx context.Context, token string) (*models.Session, error)
+	GetSessionByID(ctx context.Context, sessionID string) (*models.Session, error)
+	ListUserSessions(ctx context.Context, userIDs []string) ([]*models.Session, error)
+	TouchSession(ctx context.Context, sessionID string, refreshTokenID string, ipAddress string, userAgent string) error
 	DeleteSession(ctx context.Context, token string) error
+	RevokeUserSessions(ctx context.Context, userID string, keepSessionID string) error
+	RevokeSession(ctx context.Context, userID string, sessionID string) (bool, error)
 }
 
 type sessionsRepository struct {
@@ -33,14 +40,17 @@ func NewSessionsRepository() SessionsRepository {
 func (repository *sessionsRepository) CreateSession(
 	ctx context.Context,
 	userID string,
+	ipAddress string,
+	userAgent string,
 ) (*models.Session, error) {
 	database := repository.DBService.GetDB()
 
 	session := models.Session{
-		UserID:    userID,
-		IPAddress: "",
-		UserAgent: "",
-		Token:     "",
+		UserID:     userID,
+		IPAddress:  ipAddress,
+		UserAgent:  userAgent,
+		Token:      "",
+		LastSeenAt: time.Now(),
 	}
 
 	err := database.Create(&session).Error
@@ -67,6 +77,69 @@ func (repository *sessionsRepository) GetSession(
 	return &session, nil
 }
 
+// GetSessionByID returns the session with the ID, or nil when there is none.
+func (repository *sessionsRepository) GetSessionByID(
+	ctx context.Context,
+	sessionID string,
+) (*models.Session, error) {
+	database := repository.DBService.GetDB()
+
+	var session models.Session
+
+	err := database.Where("id = ?", sessionID).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// ListUserSessions returns the sessions of all the given users in one query, most recently seen first.
+func (repository *sessionsRepository) ListUserSessions(
+	ctx context.Context,
+	userIDs []string,
+) ([]*models.Session, error) {
+	database := repository.DBService.GetDB()
+
+	var sessions []*models.Session
+
+	err := database.Where("user_id IN ?", userIDs).Order("last_seen_at DESC").Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// TouchSession links the session to its latest refresh token and records when and from where it was last
+// seen. An empty IP address or user agent keeps the one stored before.
+func (repository *sessionsRepository) TouchSession(
+	ctx context.Context,
+	sessionID string,
+	refreshTokenID string,
+	ipAddress string,
+	userAgent string,
+) error {
+	database := repository.DBService.GetDB()
+
+	updates := map[string]interface{}{
+		"token":        refreshTokenID,
+		"last_seen_at": time.Now(),
+	}
+	if ipAddress != "" {
+		updates["ip_address"] = ipAddress
+	}
+	if userAgent != "" {
+		updates["user_agent"] = userAgent
+	}
+
+	return database.Model(&models.Session{}).Where("id = ?", sessionID).Updates(updates).Error
+}
+
 func (repository *sessionsRepository) DeleteSession(
 	ctx context.Context,
 	token string,
@@ -76,6 +149,57 @@ func (repository *sessionsRepository) DeleteSession(
 	return database.Where("token = ?", token).Delete(&models.Session{}).Error
 }
 
+// RevokeUserSessions deletes the sessions of the user together with their refresh tokens in one transaction,
+// the refresh token family of a session shares its ID. An empty keepSessionID revokes every session.
+func (repository *sessionsRepository) RevokeUserSessions(
+	ctx context.Context,
+	userID string,
+	keepSessionID string,
+) error {
+	database := repository.DBService.GetDB()
+
+	return database.Transaction(func(tx *gorm.DB) error {
+		sessions := tx.Where("user_id = ?", userID)
+		refreshTokens := tx.Where("user_id = ?", userID)
+
+		if keepSessionID != "" {
+			sessions = sessions.Where("id <> ?", keepSessionID)
+			refreshTokens = refreshTokens.Where("family_id <> ?", keepSessionID)
+		}
+
+		err := sessions.Delete(&models.Session{}).Error
+		if err != nil {
+			return err
+		}
+
+		return refreshTokens.Delete(&RefreshTokenModels.RefreshToken{}).Error
+	})
+}
+
+// RevokeSession deletes the session and its refresh tokens in one transaction. Both are matched on the user
+// as well, so a user cannot revoke the session of someone else.
+func (repository *sessionsRepository) RevokeSession(
+	ctx context.Context,
+	userID string,
+	sessionID string,
+) (bool, error) {
+	database := repository.DBService.GetDB()
+
+	revoked := false
+	err := database.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", sessionID, userID).Delete(&models.Session{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		revoked = result.RowsAffected > 0
+
+		return tx.Where("family_id = ? AND user_id = ?", sessionID, userID).Delete(&RefreshTokenModels.RefreshToken{}).Error
+	})
+
+	return revoked, err
+}
+
 func GetSessionsRepository() SessionsRepository {
 	if sessionsRepositorySingleton == nil {
 		sessionsRepositorySingleton = NewSessionsRepository()
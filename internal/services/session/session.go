@@ -2,7 +2,9 @@ package session
 
 import (
 	"context"
+	"time"
 
+	"github.com/weeb-vip/auth/config"
 	"github.com/weeb-vip/auth/internal/services/session/models"
 	"github.com/weeb-vip/auth/internal/services/session/repositories"
 )
@@ -11,23 +13,78 @@ const (
 	ErrSessionNotFound = "session not found"
 )
 
+// maxUserAgentLength is the size of the user_agent column.
+const maxUserAgentLength = 255
+
 type sessionService struct {
 	sessionRepository repositories.SessionsRepository
+	policy            Policy
 }
 
-func NewSessionService() Session {
+func NewSessionService(cfg config.SessionConfig) Session {
 	sessionRepository := repositories.NewSessionsRepository()
 
 	return &sessionService{
 		sessionRepository: sessionRepository,
+		policy:            NewPolicy(cfg),
 	}
 }
 
 func (service *sessionService) CreateSession(
 	ctx context.Context,
 	username string,
+	ipAddress string,
+	userAgent string,
 ) (*models.Session, error) {
-	return service.sessionRepository.CreateSession(ctx, username)
+	return service.sessionRepository.CreateSession(ctx, username, ipAddress, truncate(userAgent, maxUserAgentLength))
+}
+
+func (service *sessionService) TouchSession(
+	ctx context.Context,
+	sessionID string,
+	refreshTokenID string,
+	ipAddress string,
+	userAgent string,
+) error {
+	err := service.sessionRepository.TouchSession(ctx, sessionID, refreshTokenID, ipAddress, truncate(userAgent, maxUserAgentLength))
+	if err != nil {
+		return &Error{
+			Code:    SessionErrorInternalError,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+func (service *sessionService) ListUserSessions(
+	ctx context.Context,
+	userIDs []string,
+) (map[string][]*models.Session, error) {
+	sessionsByUser := make(map[string][]*models.Session, len(userIDs))
+	if len(userIDs) == 0 {
+		return sessionsByUser, nil
+	}
+
+	sessions, err := service.sessionRepository.ListUserSessions(ctx, userIDs)
+	if err != nil {
+		return nil, &Error{
+			Code:    SessionErrorInternalError,
+			Message: err.Error(),
+		}
+	}
+
+	now := time.Now()
+	for _, session := range sessions {
+		// Sessions the policy has ended are only removed on their next refresh, so they are left out here.
+		if service.policy.Check(session, now) != nil {
+			continue
+		}
+
+		sessionsByUser[session.UserID] = append(sessionsByUser[session.UserID], session)
+	}
+
+	return sessionsByUser, nil
 }
 
 func (service *sessionService) GetSession(
@@ -55,3 +112,112 @@ func (service *sessionService) DeleteSession(
 ) error {
 	return service.sessionRepository.DeleteSession(ctx, token)
 }
+
+func (service *sessionService) CheckSession(
+	ctx context.Context,
+	userID string,
+	sessionID string,
+) error {
+	if sessionID == "" {
+		return nil
+	}
+
+	session, err := service.sessionRepository.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return &Error{
+			Code:    SessionErrorInternalError,
+			Message: err.Error(),
+		}
+	}
+
+	if session == nil || session.UserID != userID {
+		// Only matches the family of the user, so the session of someone else is left alone.
+		_, err = service.RevokeSession(ctx, userID, sessionID)
+		if err != nil {
+			return err
+		}
+
+		return &Error{
+			Code:    SessionErrorRevoked,
+			Message: "session revoked",
+		}
+	}
+
+	policyErr := service.policy.Check(session, time.Now())
+	if policyErr == nil {
+		return nil
+	}
+
+	_, err = service.RevokeSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	return policyErr
+}
+
+func (service *sessionService) EvictSessions(
+	ctx context.Context,
+	userID string,
+	keepSessionID string,
+) ([]*models.Session, error) {
+	if service.policy.MaxSessions <= 0 {
+		return nil, nil
+	}
+
+	sessions, err := service.ListUserSessions(ctx, []string{userID})
+	if err != nil {
+		return nil, err
+	}
+
+	excess := service.policy.Excess(sessions[userID], keepSessionID)
+	for _, session := range excess {
+		_, err := service.RevokeSession(ctx, userID, session.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return excess, nil
+}
+
+func (service *sessionService) RevokeUserSessions(
+	ctx context.Context,
+	userID string,
+	keepSessionID string,
+) error {
+	err := service.sessionRepository.RevokeUserSessions(ctx, userID, keepSessionID)
+	if err != nil {
+		return &Error{
+			Code:    SessionErrorInternalError,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+func (service *sessionService) RevokeSession(
+	ctx context.Context,
+	userID string,
+	sessionID string,
+) (bool, error) {
+	revoked, err := service.sessionRepository.RevokeSession(ctx, userID, sessionID)
+	if err != nil {
+		return false, &Error{
+			Code:    SessionErrorInternalError,
+			Message: err.Error(),
+		}
+	}
+
+	return revoked, nil
+}
+
+func truncate(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value
+	}
+
+	return string(runes[:length])
+}
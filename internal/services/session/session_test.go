@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/weeb-vip/auth/config"
 	"github.com/weeb-vip/auth/internal/services/session"
 )
 
@@ -15,7 +16,7 @@ func TestNewSessionService(t *testing.T) {
 		t.Parallel()
 		a := assert.New(t)
 
-		sessionService := session.NewSessionService()
+		sessionService := session.NewSessionService(config.SessionConfig{})
 
 		a.NotNil(sessionService)
 	})
@@ -24,9 +25,9 @@ func TestNewSessionService(t *testing.T) {
 		t.Parallel()
 		a := assert.New(t)
 
-		sessionService := session.NewSessionService()
+		sessionService := session.NewSessionService(config.SessionConfig{})
 
-		_, err := sessionService.CreateSession(context.TODO(), "username")
+		_, err := sessionService.CreateSession(context.TODO(), "username", "127.0.0.1", "test-agent")
 		a.NoError(err)
 	})
 }
@@ -0,0 +1,35 @@
+package tokenhash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+var ErrEmptySecret = errors.New("token hash secret must not be empty")
+
+// Hasher derives the value stored in place of a bearer token, so a leaked row cannot be presented as the token.
+type Hasher interface {
+	Hash(token string) string
+}
+
+type hmacHasher struct {
+	secret []byte
+}
+
+// New returns a Hasher computing a hex encoded HMAC-SHA256 keyed with the given secret.
+func New(secret string) (Hasher, error) {
+	if secret == "" {
+		return nil, ErrEmptySecret
+	}
+
+	return hmacHasher{secret: []byte(secret)}, nil
+}
+
+func (h hmacHasher) Hash(token string) string {
+	mac := hmac.New(sha256.New, h.secret)
+	mac.Write([]byte(token))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
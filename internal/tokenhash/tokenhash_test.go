@@ -0,0 +1,32 @@
+package tokenhash_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/internal/tokenhash"
+)
+
+func TestHasher(t *testing.T) {
+	t.Run("is deterministic for the same secret", func(t *testing.T) {
+		h, err := tokenhash.New("secret")
+		assert.NoError(t, err)
+
+		hash := h.Hash("token")
+		assert.Equal(t, hash, h.Hash("token"))
+		assert.Len(t, hash, 64)
+		assert.NotContains(t, hash, "token")
+	})
+
+	t.Run("depends on the secret", func(t *testing.T) {
+		h, _ := tokenhash.New("secret")
+		other, _ := tokenhash.New("other-secret")
+		assert.NotEqual(t, h.Hash("token"), other.Hash("token"))
+	})
+
+	t.Run("rejects an empty secret", func(t *testing.T) {
+		_, err := tokenhash.New("")
+		assert.ErrorIs(t, err, tokenhash.ErrEmptySecret)
+	})
+}
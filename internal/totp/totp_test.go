@@ -0,0 +1,84 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weeb-vip/auth/internal/totp"
+)
+
+// RFC 6238 appendix B secret for HMAC-SHA1.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	t.Run("matches the RFC 6238 test vectors", func(t *testing.T) {
+		vectors := map[int64]string{
+			59:         "287082",
+			1111111109: "081804",
+			1234567890: "005924",
+			2000000000: "279037",
+		}
+
+		for unix, expected := range vectors {
+			code, err := totp.Code(rfcSecret, time.Unix(unix, 0))
+			assert.NoError(t, err)
+			assert.Equal(t, expected, code)
+		}
+	})
+
+	t.Run("returns error for an invalid secret", func(t *testing.T) {
+		_, err := totp.Code("not base32!", time.Now())
+		assert.Error(t, err)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	t.Run("accepts the current code", func(t *testing.T) {
+		code, _ := totp.Code(rfcSecret, now)
+		step, ok := totp.Validate(rfcSecret, code, now)
+		assert.True(t, ok)
+		assert.Equal(t, totp.Step(now), step)
+	})
+
+	t.Run("accepts the code from the previous time step", func(t *testing.T) {
+		code, _ := totp.Code(rfcSecret, now.Add(-totp.Period))
+		step, ok := totp.Validate(rfcSecret, code, now)
+		assert.True(t, ok)
+		assert.Equal(t, totp.Step(now)-1, step)
+	})
+
+	t.Run("rejects codes outside of the skew window", func(t *testing.T) {
+		code, _ := totp.Code(rfcSecret, now.Add(-3*totp.Period))
+		_, ok := totp.Validate(rfcSecret, code, now)
+		assert.False(t, ok)
+	})
+
+	t.Run("rejects a wrong code", func(t *testing.T) {
+		_, ok := totp.Validate(rfcSecret, "000000", now)
+		assert.False(t, ok)
+	})
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	other, _ := totp.GenerateSecret()
+	assert.NotEqual(t, secret, other)
+}
+
+func TestURI(t *testing.T) {
+	uri := totp.URI("WEEB VIP", "user@weeb.vip", "SECRET")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/WEEB%20VIP:user@weeb.vip?"))
+	assert.Contains(t, uri, "secret=SECRET")
+	assert.Contains(t, uri, "issuer=WEEB+VIP")
+	assert.Contains(t, uri, "digits=6")
+	assert.Contains(t, uri, "period=30")
+}
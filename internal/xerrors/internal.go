@@ -21,6 +21,30 @@ func ServiceError(message string, code string) *gqlerror.Error {
 	}
 }
 
+// PolicyViolationError lists every rule a rejected value broke, so clients can explain all of them at once.
+func PolicyViolationError(message string, code string, violations []string) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message: message,
+		Extensions: map[string]interface{}{
+			"code":       code,
+			"message":    message,
+			"violations": violations,
+		},
+	}
+}
+
+// RateLimitedError tells clients how many seconds to wait before retrying.
+func RateLimitedError(message string, retryAfterSeconds int) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message: message,
+		Extensions: map[string]interface{}{
+			"code":       "RATE_LIMITED",
+			"message":    message,
+			"retryAfter": retryAfterSeconds,
+		},
+	}
+}
+
 func ChallengeError(
 	message string,
 	code string,
@@ -1,5 +1,10 @@
 // Code generated by MockGen. DO NOT EDIT.
 // Source: github.com/weeb-vip/auth/internal/services/credential (interfaces: Credential)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_credentials.go -package=mocks github.com/weeb-vip/auth/internal/services/credential Credential
+//
 
 // Package mocks is a generated GoMock package.
 package mocks
@@ -8,14 +13,15 @@ import (
 	context "context"
 	reflect "reflect"
 
-	gomock "go.uber.org/mock/gomock"
 	models "github.com/weeb-vip/auth/internal/services/credential/models"
+	gomock "go.uber.org/mock/gomock"
 )
 
 // MockCredential is a mock of Credential interface.
 type MockCredential struct {
 	ctrl     *gomock.Controller
 	recorder *MockCredentialMockRecorder
+	isgomock struct{}
 }
 
 // MockCredentialMockRecorder is the mock recorder for MockCredential.
@@ -35,47 +41,134 @@ func (m *MockCredential) EXPECT() *MockCredentialMockRecorder {
 	return m.recorder
 }
 
+// ActivateCredentials mocks base method.
+func (m *MockCredential) ActivateCredentials(ctx context.Context, identifier string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActivateCredentials", ctx, identifier)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ActivateCredentials indicates an expected call of ActivateCredentials.
+func (mr *MockCredentialMockRecorder) ActivateCredentials(ctx, identifier any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActivateCredentials", reflect.TypeOf((*MockCredential)(nil).ActivateCredentials), ctx, identifier)
+}
+
+// ChangePassword mocks base method.
+func (m *MockCredential) ChangePassword(ctx context.Context, username, oldPassword, newPassword string) (*models.Credential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, username, oldPassword, newPassword)
+	ret0, _ := ret[0].(*models.Credential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockCredentialMockRecorder) ChangePassword(ctx, username, oldPassword, newPassword any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockCredential)(nil).ChangePassword), ctx, username, oldPassword, newPassword)
+}
+
+// CheckPassword mocks base method.
+func (m *MockCredential) CheckPassword(ctx context.Context, username, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckPassword", ctx, username, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckPassword indicates an expected call of CheckPassword.
+func (mr *MockCredentialMockRecorder) CheckPassword(ctx, username, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckPassword", reflect.TypeOf((*MockCredential)(nil).CheckPassword), ctx, username, password)
+}
+
 // GetCredentials mocks base method.
-func (m *MockCredential) GetCredentials(arg0 context.Context, arg1 string) (*models.Credential, error) {
+func (m *MockCredential) GetCredentials(ctx context.Context, username string) (*models.Credential, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCredentials", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetCredentials", ctx, username)
 	ret0, _ := ret[0].(*models.Credential)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetCredentials indicates an expected call of GetCredentials.
-func (mr *MockCredentialMockRecorder) GetCredentials(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockCredentialMockRecorder) GetCredentials(ctx, username any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCredentials", reflect.TypeOf((*MockCredential)(nil).GetCredentials), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCredentials", reflect.TypeOf((*MockCredential)(nil).GetCredentials), ctx, username)
+}
+
+// GetCredentialsByIdentifier mocks base method.
+func (m *MockCredential) GetCredentialsByIdentifier(ctx context.Context, identifier string) (*models.Credential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCredentialsByIdentifier", ctx, identifier)
+	ret0, _ := ret[0].(*models.Credential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCredentialsByIdentifier indicates an expected call of GetCredentialsByIdentifier.
+func (mr *MockCredentialMockRecorder) GetCredentialsByIdentifier(ctx, identifier any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCredentialsByIdentifier", reflect.TypeOf((*MockCredential)(nil).GetCredentialsByIdentifier), ctx, identifier)
+}
+
+// GetCredentialsByUserID mocks base method.
+func (m *MockCredential) GetCredentialsByUserID(ctx context.Context, userID string) (*models.Credential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCredentialsByUserID", ctx, userID)
+	ret0, _ := ret[0].(*models.Credential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCredentialsByUserID indicates an expected call of GetCredentialsByUserID.
+func (mr *MockCredentialMockRecorder) GetCredentialsByUserID(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCredentialsByUserID", reflect.TypeOf((*MockCredential)(nil).GetCredentialsByUserID), ctx, userID)
 }
 
 // Register mocks base method.
-func (m *MockCredential) Register(arg0 context.Context, arg1, arg2 string) (*models.Credential, error) {
+func (m *MockCredential) Register(ctx context.Context, username, password string) (*models.Credential, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Register", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "Register", ctx, username, password)
 	ret0, _ := ret[0].(*models.Credential)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Register indicates an expected call of Register.
-func (mr *MockCredentialMockRecorder) Register(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockCredentialMockRecorder) Register(ctx, username, password any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockCredential)(nil).Register), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockCredential)(nil).Register), ctx, username, password)
 }
 
 // SignIn mocks base method.
-func (m *MockCredential) SignIn(arg0 context.Context, arg1, arg2 string) (*models.Credential, error) {
+func (m *MockCredential) SignIn(ctx context.Context, username, password string) (*models.Credential, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SignIn", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "SignIn", ctx, username, password)
 	ret0, _ := ret[0].(*models.Credential)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SignIn indicates an expected call of SignIn.
-func (mr *MockCredentialMockRecorder) SignIn(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockCredentialMockRecorder) SignIn(ctx, username, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignIn", reflect.TypeOf((*MockCredential)(nil).SignIn), ctx, username, password)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockCredential) UpdatePassword(ctx context.Context, username, newPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, username, newPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockCredentialMockRecorder) UpdatePassword(ctx, username, newPassword any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignIn", reflect.TypeOf((*MockCredential)(nil).SignIn), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockCredential)(nil).UpdatePassword), ctx, username, newPassword)
 }
@@ -1,5 +1,10 @@
 // Code generated by MockGen. DO NOT EDIT.
 // Source: github.com/weeb-vip/auth/internal/jwt (interfaces: Tokenizer)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_tokenizer.go -package=mocks github.com/weeb-vip/auth/internal/jwt Tokenizer
+//
 
 // Package mocks is a generated GoMock package.
 package mocks
@@ -7,14 +12,15 @@ package mocks
 import (
 	reflect "reflect"
 
-	gomock "go.uber.org/mock/gomock"
 	jwt "github.com/weeb-vip/auth/internal/jwt"
+	gomock "go.uber.org/mock/gomock"
 )
 
 // MockTokenizer is a mock of Tokenizer interface.
 type MockTokenizer struct {
 	ctrl     *gomock.Controller
 	recorder *MockTokenizerMockRecorder
+	isgomock struct{}
 }
 
 // MockTokenizerMockRecorder is the mock recorder for MockTokenizer.
@@ -34,17 +40,32 @@ func (m *MockTokenizer) EXPECT() *MockTokenizerMockRecorder {
 	return m.recorder
 }
 
+// GetClaims mocks base method.
+func (m *MockTokenizer) GetClaims(token string) (*jwt.Claims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClaims", token)
+	ret0, _ := ret[0].(*jwt.Claims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClaims indicates an expected call of GetClaims.
+func (mr *MockTokenizerMockRecorder) GetClaims(token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClaims", reflect.TypeOf((*MockTokenizer)(nil).GetClaims), token)
+}
+
 // Tokenize mocks base method.
-func (m *MockTokenizer) Tokenize(arg0 jwt.Claims) (string, error) {
+func (m *MockTokenizer) Tokenize(claims jwt.Claims) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Tokenize", arg0)
+	ret := m.ctrl.Call(m, "Tokenize", claims)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Tokenize indicates an expected call of Tokenize.
-func (mr *MockTokenizerMockRecorder) Tokenize(arg0 interface{}) *gomock.Call {
+func (mr *MockTokenizerMockRecorder) Tokenize(claims any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tokenize", reflect.TypeOf((*MockTokenizer)(nil).Tokenize), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tokenize", reflect.TypeOf((*MockTokenizer)(nil).Tokenize), claims)
 }
@@ -2,22 +2,34 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/rs/cors"
 	"github.com/weeb-vip/auth/config"
 	"github.com/weeb-vip/auth/http/handlers"
+	"github.com/weeb-vip/auth/http/handlers/jwks"
+	"github.com/weeb-vip/auth/http/handlers/oauth"
 	"github.com/weeb-vip/auth/http/middleware"
+	"github.com/weeb-vip/auth/internal/denylist"
+	denylistRepositories "github.com/weeb-vip/auth/internal/denylist/repositories"
+	"github.com/weeb-vip/auth/internal/encryption"
+	"github.com/weeb-vip/auth/internal/janitor"
+	janitorRepositories "github.com/weeb-vip/auth/internal/janitor/repositories"
 	"github.com/weeb-vip/auth/internal/jwt"
 	"github.com/weeb-vip/auth/internal/keypair"
+	keypairRepositories "github.com/weeb-vip/auth/internal/keypair/repositories"
 	"github.com/weeb-vip/auth/internal/logger"
 	"github.com/weeb-vip/auth/internal/metrics"
 	observabilityMiddleware "github.com/weeb-vip/auth/internal/middleware"
 	"github.com/weeb-vip/auth/internal/publishkey"
+	"github.com/weeb-vip/auth/internal/services/refresh_token"
+	"github.com/weeb-vip/auth/internal/services/session"
 	"github.com/weeb-vip/auth/internal/tracing"
 
 	"github.com/99designs/gqlgen/graphql/playground"
@@ -60,7 +72,35 @@ func StartServerWithContext(ctx context.Context) error {
 	router.Handle("/metrics", metrics.NewPrometheusInstance().Handler())
 
 	router.Handle("/", playground.Handler("GraphQL playground", "/graphql"))
-	router.Handle("/graphql", handlers.BuildRootHandlerWithContext(ctx, jwt.New(rotatingKey)))
+	tokenizer := jwt.New(rotatingKey)
+
+	oauthClients, err := oauth.ParseClients(cfg.OAuthConfig.Clients)
+	if err != nil {
+		return err
+	}
+
+	tokenDenylist, err := getDenylist(cfg.DenylistConfig)
+	if err != nil {
+		return err
+	}
+
+	err = startJanitor(ctx, cfg.JanitorConfig)
+	if err != nil {
+		return err
+	}
+
+	oauthHandlers := oauth.NewHandlers(
+		oauthClients,
+		tokenizer,
+		refresh_token.NewRefreshTokenService(cfg.RefreshTokenConfig, cfg.TokenHashConfig),
+		session.NewSessionService(cfg.SessionConfig),
+		tokenDenylist,
+	)
+
+	router.Handle("/graphql", handlers.BuildRootHandlerWithContext(ctx, tokenizer, tokenDenylist))
+	router.Handle("/.well-known/jwks.json", jwks.Handler(rotatingKey, time.Second*time.Duration(cfg.APPConfig.JWKSMaxAgeInSeconds)))
+	router.Handle("/oauth/introspect", oauthHandlers.Introspect())
+	router.Handle("/oauth/revoke", oauthHandlers.Revoke())
 	router.Handle("/readyz", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(200) // nolint
 	}))
@@ -111,10 +151,35 @@ func getEnvironment() string {
 }
 
 func getRotatingSigningKey(cfg *config.Config) (keypair.RotatingSigningKey, error) {
-	rotatingKey, err := keypair.NewSigningKeyRotator(
-		publishkey.NewKeyPublisher(
-			cfg.APPConfig.InternalGraphQLURL).
-			PublishToKeyManagementService)
+	algorithm, err := keypair.ParseAlgorithm(cfg.SigningKeyConfig.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []keypair.RotatorOption{
+		keypair.WithAlgorithm(algorithm),
+		keypair.WithRetention(time.Minute * time.Duration(cfg.APPConfig.KeyRetentionInMinutes)),
+	}
+
+	if cfg.SigningKeyConfig.Store != "memory" {
+		store, err := getSigningKeyStore(cfg.SigningKeyConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, keypair.WithStore(store, time.Second*time.Duration(cfg.SigningKeyConfig.SyncIntervalInSeconds)))
+	}
+
+	if cfg.SigningKeyConfig.MaxKeyAgeInMinutes > 0 {
+		options = append(options, keypair.WithMaxAge(time.Minute*time.Duration(cfg.SigningKeyConfig.MaxKeyAgeInMinutes)))
+	}
+
+	publisher, err := getKeyPublisher(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	rotatingKey, err := keypair.NewSigningKeyRotator(publisher.PublishToKeyManagementService, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -125,6 +190,93 @@ func getRotatingSigningKey(cfg *config.Config) (keypair.RotatingSigningKey, erro
 	return rotatingKey, nil
 }
 
+func getDenylist(cfg config.DenylistConfig) (denylist.Denylist, error) {
+	var backend denylist.Denylist
+
+	switch cfg.Store {
+	case "database":
+		backend = denylistRepositories.NewDeniedTokensRepository()
+	case "memory":
+		backend = denylist.NewMemoryDenylist()
+	default:
+		return nil, fmt.Errorf("unknown denylist store %q", cfg.Store)
+	}
+
+	return denylist.NewCachedDenylist(backend, cfg.CacheSize, time.Second*time.Duration(cfg.CacheTTLInSeconds)), nil
+}
+
+func startJanitor(ctx context.Context, cfg config.JanitorConfig) error {
+	if cfg.Disabled {
+		return nil
+	}
+
+	if cfg.IntervalInMinutes <= 0 || cfg.BatchSize <= 0 {
+		return errors.New("the janitor needs a positive interval and batch size, or to be disabled")
+	}
+
+	janitor.New(janitorRepositories.NewJanitorRepository(cfg), cfg.BatchSize).
+		RunInBackground(ctx, time.Minute*time.Duration(cfg.IntervalInMinutes))
+
+	return nil
+}
+
+func getKeyPublisher(cfg *config.Config) (publishkey.KeyPublisher, error) {
+	var publisher publishkey.KeyPublisher
+
+	switch cfg.SigningKeyConfig.Publisher {
+	case "key_service":
+		publisher = publishkey.NewKeyPublisher(
+			cfg.APPConfig.InternalGraphQLURL,
+			time.Second*time.Duration(cfg.SigningKeyConfig.PublishTimeoutInSeconds),
+		)
+	case "file":
+		if cfg.SigningKeyConfig.PublishDirectory == "" {
+			return nil, errors.New("a publish directory is required to publish signing keys to files")
+		}
+
+		publisher = publishkey.NewFilePublisher(cfg.SigningKeyConfig.PublishDirectory)
+	default:
+		return nil, fmt.Errorf("unknown signing key publisher %q", cfg.SigningKeyConfig.Publisher)
+	}
+
+	return publishkey.NewRetryingPublisher(
+		publisher,
+		cfg.SigningKeyConfig.PublishMaxAttempts,
+		time.Millisecond*time.Duration(cfg.SigningKeyConfig.PublishBackoffInMilliseconds),
+	), nil
+}
+
+func getSigningKeyStore(cfg config.SigningKeyConfig) (keypair.KeyStore, error) {
+	if cfg.Store != "database" {
+		return nil, fmt.Errorf("unknown signing key store %q", cfg.Store)
+	}
+
+	masterKey := cfg.MasterKey
+	if cfg.MasterKeyFile != "" {
+		contents, err := os.ReadFile(cfg.MasterKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signing key master key: %w", err)
+		}
+
+		masterKey = strings.TrimSpace(string(contents))
+	}
+
+	if masterKey == "" {
+		return nil, errors.New("a signing key master key is required to store signing keys in the database")
+	}
+
+	cipher, err := encryption.New(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return keypairRepositories.NewSigningKeysRepository(
+		cipher,
+		cfg.LockName,
+		time.Second*time.Duration(cfg.LockTimeoutInSeconds),
+	), nil
+}
+
 func getMinimumDuration(askedDuration time.Duration, minimumDuration time.Duration) time.Duration {
 	if askedDuration < minimumDuration {
 		return minimumDuration